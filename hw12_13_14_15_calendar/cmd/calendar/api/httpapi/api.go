@@ -15,6 +15,21 @@ type Handler interface {
 	GetMonthEvents(res http.ResponseWriter, req *http.Request)
 	UpdateEvent(res http.ResponseWriter, req *http.Request)
 	DeleteEvent(res http.ResponseWriter, req *http.Request)
+	ExportEvents(res http.ResponseWriter, req *http.Request)
+	ImportEvents(res http.ResponseWriter, req *http.Request)
+	FreeBusy(res http.ResponseWriter, req *http.Request)
+	InviteAttendees(res http.ResponseWriter, req *http.Request)
+	RespondInvitation(res http.ResponseWriter, req *http.Request)
+	GetInvitations(res http.ResponseWriter, req *http.Request)
+	AddCalendar(res http.ResponseWriter, req *http.Request)
+	GetCalendars(res http.ResponseWriter, req *http.Request)
+	ShareCalendar(res http.ResponseWriter, req *http.Request)
+	UnshareCalendar(res http.ResponseWriter, req *http.Request)
+	DeleteCalendar(res http.ResponseWriter, req *http.Request)
+	GetCalendarEvents(res http.ResponseWriter, req *http.Request)
+	ListEvents(res http.ResponseWriter, req *http.Request)
+	SearchEvents(res http.ResponseWriter, req *http.Request)
+	WatchEvents(res http.ResponseWriter, req *http.Request)
 }
 
 type Response interface {
@@ -24,6 +39,8 @@ type Response interface {
 
 type ErrorResponse struct {
 	Message string
+	// Conflicts are IDs of events which overlap the event if its date is busy.
+	Conflicts []string `json:"conflicts,omitempty"`
 }
 
 type AddResponse struct {
@@ -85,3 +102,113 @@ func NewDeleteResponse() *DeleteResponse {
 		Error: nil,
 	}
 }
+
+type ImportResult struct {
+	UID   string         `json:"uid"`
+	ID    string         `json:"id,omitempty"`
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+type ImportResponse struct {
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Results  []ImportResult `json:"results"`
+	Error    *ErrorResponse `json:"error,omitempty"`
+}
+
+func NewImportResponse() *ImportResponse {
+	return &ImportResponse{
+		Results: []ImportResult{},
+		Error:   nil,
+	}
+}
+
+type FreeBusyResponse struct {
+	Users []entities.UserBusy `json:"users"`
+	Slots []entities.Interval `json:"slots"`
+	Error *ErrorResponse      `json:"error,omitempty"`
+}
+
+func NewFreeBusyResponse() *FreeBusyResponse {
+	return &FreeBusyResponse{
+		Users: []entities.UserBusy{},
+		Slots: []entities.Interval{},
+		Error: nil,
+	}
+}
+
+type InvitationResponse struct {
+	ID    string         `json:"id"`
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+func NewInvitationResponse() *InvitationResponse {
+	return &InvitationResponse{
+		ID:    "",
+		Error: nil,
+	}
+}
+
+type CalendarResponse struct {
+	ID    string         `json:"id"`
+	Error *ErrorResponse `json:"error,omitempty"`
+}
+
+func NewCalendarResponse() *CalendarResponse {
+	return &CalendarResponse{
+		ID:    "",
+		Error: nil,
+	}
+}
+
+type CalendarsResponse struct {
+	Calendars []*entities.Calendar `json:"calendars"`
+	Error     *ErrorResponse       `json:"error,omitempty"`
+}
+
+func NewCalendarsResponse() *CalendarsResponse {
+	return &CalendarsResponse{
+		Calendars: []*entities.Calendar{},
+		Error:     nil,
+	}
+}
+
+type ListResponse struct {
+	Events []*entities.Event `json:"events"`
+	// NextCursor continues listing from the next page, it is empty on the last page.
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Error      *ErrorResponse `json:"error,omitempty"`
+}
+
+func NewListResponse() *ListResponse {
+	return &ListResponse{
+		Events: []*entities.Event{},
+		Error:  nil,
+	}
+}
+
+type SearchResponse struct {
+	Results []*entities.SearchHit `json:"results"`
+	Error   *ErrorResponse        `json:"error,omitempty"`
+}
+
+func NewSearchResponse() *SearchResponse {
+	return &SearchResponse{
+		Results: []*entities.SearchHit{},
+		Error:   nil,
+	}
+}
+
+// WatchEvent is data of server-sent event with change of event, error is sent as usual response before the stream.
+type WatchEvent struct {
+	Revision uint64          `json:"revision,omitempty"`
+	Kind     string          `json:"kind,omitempty"`
+	Event    *entities.Event `json:"event,omitempty"`
+	Error    *ErrorResponse  `json:"error,omitempty"`
+}
+
+func NewWatchEvent() *WatchEvent {
+	return &WatchEvent{
+		Error: nil,
+	}
+}
@@ -19,7 +19,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(in *jlexer.Lexer, out *UpdateResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(in *jlexer.Lexer, out *WatchEvent) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -38,17 +38,1744 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 			continue
 		}
 		switch key {
+		case "revision":
+			out.Revision = uint64(in.Uint64())
+		case "kind":
+			out.Kind = string(in.String())
+		case "event":
+			if in.IsNull() {
+				in.Skip()
+				out.Event = nil
+			} else {
+				if out.Event == nil {
+					out.Event = new(entities.Event)
+				}
+				easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in, out.Event)
+			}
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(out *jwriter.Writer, in WatchEvent) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Revision != 0 {
+		const prefix string = ",\"revision\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.Revision))
+	}
+	if in.Kind != "" {
+		const prefix string = ",\"kind\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Kind))
+	}
+	if in.Event != nil {
+		const prefix string = ",\"event\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out, *in.Event)
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WatchEvent) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WatchEvent) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WatchEvent) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WatchEvent) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in *jlexer.Lexer, out *entities.Event) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "ID":
+			out.ID = string(in.String())
+		case "Title":
+			out.Title = string(in.String())
+		case "DateTime":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.DateTime).UnmarshalJSON(data))
+			}
+		case "Duration":
+			out.Duration = time.Duration(in.Int64())
+		case "Text":
+			out.Text = string(in.String())
+		case "UserID":
+			out.UserID = string(in.String())
+		case "Reminders":
+			if in.IsNull() {
+				in.Skip()
+				out.Reminders = nil
+			} else {
+				in.Delim('[')
+				if out.Reminders == nil {
+					if !in.IsDelim(']') {
+						out.Reminders = make([]entities.Reminder, 0, 2)
+					} else {
+						out.Reminders = []entities.Reminder{}
+					}
+				} else {
+					out.Reminders = (out.Reminders)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 entities.Reminder
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities1(in, &v1)
+					out.Reminders = append(out.Reminders, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "Recurrence":
+			if in.IsNull() {
+				in.Skip()
+				out.Recurrence = nil
+			} else {
+				if out.Recurrence == nil {
+					out.Recurrence = new(entities.Recurrence)
+				}
+				easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities2(in, out.Recurrence)
+			}
+		case "TimeZone":
+			out.TimeZone = string(in.String())
+		case "Attendees":
+			if in.IsNull() {
+				in.Skip()
+				out.Attendees = nil
+			} else {
+				in.Delim('[')
+				if out.Attendees == nil {
+					if !in.IsDelim(']') {
+						out.Attendees = make([]entities.Attendee, 0, 2)
+					} else {
+						out.Attendees = []entities.Attendee{}
+					}
+				} else {
+					out.Attendees = (out.Attendees)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v2 entities.Attendee
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities3(in, &v2)
+					out.Attendees = append(out.Attendees, v2)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "CalendarID":
+			out.CalendarID = string(in.String())
+		case "Version":
+			out.Version = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out *jwriter.Writer, in entities.Event) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"ID\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"Title\":"
+		out.RawString(prefix)
+		out.String(string(in.Title))
+	}
+	{
+		const prefix string = ",\"DateTime\":"
+		out.RawString(prefix)
+		out.Raw((in.DateTime).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"Duration\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Duration))
+	}
+	{
+		const prefix string = ",\"Text\":"
+		out.RawString(prefix)
+		out.String(string(in.Text))
+	}
+	{
+		const prefix string = ",\"UserID\":"
+		out.RawString(prefix)
+		out.String(string(in.UserID))
+	}
+	{
+		const prefix string = ",\"Reminders\":"
+		out.RawString(prefix)
+		if in.Reminders == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v3, v4 := range in.Reminders {
+				if v3 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities1(out, v4)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"Recurrence\":"
+		out.RawString(prefix)
+		if in.Recurrence == nil {
+			out.RawString("null")
+		} else {
+			easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities2(out, *in.Recurrence)
+		}
+	}
+	{
+		const prefix string = ",\"TimeZone\":"
+		out.RawString(prefix)
+		out.String(string(in.TimeZone))
+	}
+	{
+		const prefix string = ",\"Attendees\":"
+		out.RawString(prefix)
+		if in.Attendees == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Attendees {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities3(out, v6)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"CalendarID\":"
+		out.RawString(prefix)
+		out.String(string(in.CalendarID))
+	}
+	{
+		const prefix string = ",\"Version\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Version))
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities3(in *jlexer.Lexer, out *entities.Attendee) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "UserID":
+			out.UserID = string(in.String())
+		case "Status":
+			out.Status = entities.AttendeeStatus(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities3(out *jwriter.Writer, in entities.Attendee) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"UserID\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.UserID))
+	}
+	{
+		const prefix string = ",\"Status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities2(in *jlexer.Lexer, out *entities.Recurrence) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Freq":
+			out.Freq = entities.Frequency(in.String())
+		case "Interval":
+			out.Interval = int(in.Int())
+		case "ByDay":
+			if in.IsNull() {
+				in.Skip()
+				out.ByDay = nil
+			} else {
+				in.Delim('[')
+				if out.ByDay == nil {
+					if !in.IsDelim(']') {
+						out.ByDay = make([]entities.WeekdayNum, 0, 4)
+					} else {
+						out.ByDay = []entities.WeekdayNum{}
+					}
+				} else {
+					out.ByDay = (out.ByDay)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v7 entities.WeekdayNum
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities4(in, &v7)
+					out.ByDay = append(out.ByDay, v7)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "Count":
+			out.Count = int(in.Int())
+		case "Until":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Until).UnmarshalJSON(data))
+			}
+		case "ExDates":
+			if in.IsNull() {
+				in.Skip()
+				out.ExDates = nil
+			} else {
+				in.Delim('[')
+				if out.ExDates == nil {
+					if !in.IsDelim(']') {
+						out.ExDates = make([]time.Time, 0, 2)
+					} else {
+						out.ExDates = []time.Time{}
+					}
+				} else {
+					out.ExDates = (out.ExDates)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v8 time.Time
+					if data := in.Raw(); in.Ok() {
+						in.AddError((v8).UnmarshalJSON(data))
+					}
+					out.ExDates = append(out.ExDates, v8)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities2(out *jwriter.Writer, in entities.Recurrence) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Freq\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Freq))
+	}
+	{
+		const prefix string = ",\"Interval\":"
+		out.RawString(prefix)
+		out.Int(int(in.Interval))
+	}
+	{
+		const prefix string = ",\"ByDay\":"
+		out.RawString(prefix)
+		if in.ByDay == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v9, v10 := range in.ByDay {
+				if v9 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities4(out, v10)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"Count\":"
+		out.RawString(prefix)
+		out.Int(int(in.Count))
+	}
+	{
+		const prefix string = ",\"Until\":"
+		out.RawString(prefix)
+		out.Raw((in.Until).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"ExDates\":"
+		out.RawString(prefix)
+		if in.ExDates == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v11, v12 := range in.ExDates {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				out.Raw((v12).MarshalJSON())
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities4(in *jlexer.Lexer, out *entities.WeekdayNum) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Ordinal":
+			out.Ordinal = int(in.Int())
+		case "Weekday":
+			out.Weekday = time.Weekday(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities4(out *jwriter.Writer, in entities.WeekdayNum) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Ordinal\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Ordinal))
+	}
+	{
+		const prefix string = ",\"Weekday\":"
+		out.RawString(prefix)
+		out.Int(int(in.Weekday))
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities1(in *jlexer.Lexer, out *entities.Reminder) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Offset":
+			out.Offset = time.Duration(in.Int64())
+		case "Channel":
+			out.Channel = entities.Channel(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities1(out *jwriter.Writer, in entities.Reminder) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Offset\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.Offset))
+	}
+	{
+		const prefix string = ",\"Channel\":"
+		out.RawString(prefix)
+		out.String(string(in.Channel))
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(in *jlexer.Lexer, out *UpdateResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(out *jwriter.Writer, in UpdateResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UpdateResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UpdateResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UpdateResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UpdateResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(in *jlexer.Lexer, out *SearchResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "results":
+			if in.IsNull() {
+				in.Skip()
+				out.Results = nil
+			} else {
+				in.Delim('[')
+				if out.Results == nil {
+					if !in.IsDelim(']') {
+						out.Results = make([]*entities.SearchHit, 0, 8)
+					} else {
+						out.Results = []*entities.SearchHit{}
+					}
+				} else {
+					out.Results = (out.Results)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v13 *entities.SearchHit
+					if in.IsNull() {
+						in.Skip()
+						v13 = nil
+					} else {
+						if v13 == nil {
+							v13 = new(entities.SearchHit)
+						}
+						easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities5(in, v13)
+					}
+					out.Results = append(out.Results, v13)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(out *jwriter.Writer, in SearchResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"results\":"
+		out.RawString(prefix[1:])
+		if in.Results == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v14, v15 := range in.Results {
+				if v14 > 0 {
+					out.RawByte(',')
+				}
+				if v15 == nil {
+					out.RawString("null")
+				} else {
+					easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities5(out, *v15)
+				}
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v SearchResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SearchResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *SearchResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SearchResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities5(in *jlexer.Lexer, out *entities.SearchHit) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Event":
+			if in.IsNull() {
+				in.Skip()
+				out.Event = nil
+			} else {
+				if out.Event == nil {
+					out.Event = new(entities.Event)
+				}
+				easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in, out.Event)
+			}
+		case "Rank":
+			out.Rank = float64(in.Float64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities5(out *jwriter.Writer, in entities.SearchHit) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Event\":"
+		out.RawString(prefix[1:])
+		if in.Event == nil {
+			out.RawString("null")
+		} else {
+			easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out, *in.Event)
+		}
+	}
+	{
+		const prefix string = ",\"Rank\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.Rank))
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(in *jlexer.Lexer, out *ListResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]*entities.Event, 0, 8)
+					} else {
+						out.Events = []*entities.Event{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v16 *entities.Event
+					if in.IsNull() {
+						in.Skip()
+						v16 = nil
+					} else {
+						if v16 == nil {
+							v16 = new(entities.Event)
+						}
+						easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in, v16)
+					}
+					out.Events = append(out.Events, v16)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next_cursor":
+			out.NextCursor = string(in.String())
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(out *jwriter.Writer, in ListResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"events\":"
+		out.RawString(prefix[1:])
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v17, v18 := range in.Events {
+				if v17 > 0 {
+					out.RawByte(',')
+				}
+				if v18 == nil {
+					out.RawString("null")
+				} else {
+					easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out, *v18)
+				}
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.NextCursor != "" {
+		const prefix string = ",\"next_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.NextCursor))
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ListResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ListResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ListResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ListResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(in *jlexer.Lexer, out *InvitationResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(out *jwriter.Writer, in InvitationResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v InvitationResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v InvitationResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *InvitationResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *InvitationResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(in *jlexer.Lexer, out *IndexResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "message":
+			out.Message = string(in.String())
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(out *jwriter.Writer, in IndexResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"message\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Message))
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v IndexResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v IndexResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *IndexResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *IndexResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(in *jlexer.Lexer, out *ImportResult) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "uid":
+			out.UID = string(in.String())
 		case "id":
 			out.ID = string(in.String())
 		case "error":
 			if in.IsNull() {
 				in.Skip()
-				out.Error = nil
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(out *jwriter.Writer, in ImportResult) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"uid\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.UID))
+	}
+	if in.ID != "" {
+		const prefix string = ",\"id\":"
+		out.RawString(prefix)
+		out.String(string(in.ID))
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ImportResult) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ImportResult) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ImportResult) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ImportResult) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi6(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(in *jlexer.Lexer, out *ImportResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "imported":
+			out.Imported = int(in.Int())
+		case "failed":
+			out.Failed = int(in.Int())
+		case "results":
+			if in.IsNull() {
+				in.Skip()
+				out.Results = nil
+			} else {
+				in.Delim('[')
+				if out.Results == nil {
+					if !in.IsDelim(']') {
+						out.Results = make([]ImportResult, 0, 1)
+					} else {
+						out.Results = []ImportResult{}
+					}
+				} else {
+					out.Results = (out.Results)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v19 ImportResult
+					(v19).UnmarshalEasyJSON(in)
+					out.Results = append(out.Results, v19)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(out *jwriter.Writer, in ImportResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"imported\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Imported))
+	}
+	{
+		const prefix string = ",\"failed\":"
+		out.RawString(prefix)
+		out.Int(int(in.Failed))
+	}
+	{
+		const prefix string = ",\"results\":"
+		out.RawString(prefix)
+		if in.Results == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v20, v21 := range in.Results {
+				if v20 > 0 {
+					out.RawByte(',')
+				}
+				(v21).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ImportResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ImportResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ImportResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ImportResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi7(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(in *jlexer.Lexer, out *GetResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]*entities.Event, 0, 8)
+					} else {
+						out.Events = []*entities.Event{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v22 *entities.Event
+					if in.IsNull() {
+						in.Skip()
+						v22 = nil
+					} else {
+						if v22 == nil {
+							v22 = new(entities.Event)
+						}
+						easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in, v22)
+					}
+					out.Events = append(out.Events, v22)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(out *jwriter.Writer, in GetResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"events\":"
+		out.RawString(prefix[1:])
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v23, v24 := range in.Events {
+				if v23 > 0 {
+					out.RawByte(',')
+				}
+				if v24 == nil {
+					out.RawString("null")
+				} else {
+					easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out, *v24)
+				}
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v GetResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v GetResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *GetResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *GetResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi8(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(in *jlexer.Lexer, out *FreeBusyResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "users":
+			if in.IsNull() {
+				in.Skip()
+				out.Users = nil
+			} else {
+				in.Delim('[')
+				if out.Users == nil {
+					if !in.IsDelim(']') {
+						out.Users = make([]entities.UserBusy, 0, 1)
+					} else {
+						out.Users = []entities.UserBusy{}
+					}
+				} else {
+					out.Users = (out.Users)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v25 entities.UserBusy
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities6(in, &v25)
+					out.Users = append(out.Users, v25)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "slots":
+			if in.IsNull() {
+				in.Skip()
+				out.Slots = nil
+			} else {
+				in.Delim('[')
+				if out.Slots == nil {
+					if !in.IsDelim(']') {
+						out.Slots = make([]entities.Interval, 0, 1)
+					} else {
+						out.Slots = []entities.Interval{}
+					}
+				} else {
+					out.Slots = (out.Slots)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v26 entities.Interval
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(in, &v26)
+					out.Slots = append(out.Slots, v26)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "error":
+			if in.IsNull() {
+				in.Skip()
+				out.Error = nil
+			} else {
+				if out.Error == nil {
+					out.Error = new(ErrorResponse)
+				}
+				(*out.Error).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(out *jwriter.Writer, in FreeBusyResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"users\":"
+		out.RawString(prefix[1:])
+		if in.Users == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v27, v28 := range in.Users {
+				if v27 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities6(out, v28)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"slots\":"
+		out.RawString(prefix)
+		if in.Slots == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v29, v30 := range in.Slots {
+				if v29 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(out, v30)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Error != nil {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		(*in.Error).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v FreeBusyResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FreeBusyResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *FreeBusyResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FreeBusyResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi9(l, v)
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(in *jlexer.Lexer, out *entities.Interval) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Start":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Start).UnmarshalJSON(data))
+			}
+		case "End":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.End).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(out *jwriter.Writer, in entities.Interval) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Start\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.Start).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"End\":"
+		out.RawString(prefix)
+		out.Raw((in.End).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities6(in *jlexer.Lexer, out *entities.UserBusy) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "UserID":
+			out.UserID = string(in.String())
+		case "Busy":
+			if in.IsNull() {
+				in.Skip()
+				out.Busy = nil
 			} else {
-				if out.Error == nil {
-					out.Error = new(ErrorResponse)
+				in.Delim('[')
+				if out.Busy == nil {
+					if !in.IsDelim(']') {
+						out.Busy = make([]entities.Interval, 0, 1)
+					} else {
+						out.Busy = []entities.Interval{}
+					}
+				} else {
+					out.Busy = (out.Busy)[:0]
 				}
-				(*out.Error).UnmarshalEasyJSON(in)
+				for !in.IsDelim(']') {
+					var v31 entities.Interval
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(in, &v31)
+					out.Busy = append(out.Busy, v31)
+					in.WantComma()
+				}
+				in.Delim(']')
 			}
 		default:
 			in.SkipRecursive()
@@ -60,47 +1787,137 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(out *jwriter.Writer, in UpdateResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities6(out *jwriter.Writer, in entities.UserBusy) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"id\":"
+		const prefix string = ",\"UserID\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.ID))
+		out.String(string(in.UserID))
 	}
-	if in.Error != nil {
-		const prefix string = ",\"error\":"
+	{
+		const prefix string = ",\"Busy\":"
 		out.RawString(prefix)
-		(*in.Error).MarshalEasyJSON(out)
+		if in.Busy == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v32, v33 := range in.Busy {
+				if v32 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities7(out, v33)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(in *jlexer.Lexer, out *ErrorResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Message":
+			out.Message = string(in.String())
+		case "conflicts":
+			if in.IsNull() {
+				in.Skip()
+				out.Conflicts = nil
+			} else {
+				in.Delim('[')
+				if out.Conflicts == nil {
+					if !in.IsDelim(']') {
+						out.Conflicts = make([]string, 0, 4)
+					} else {
+						out.Conflicts = []string{}
+					}
+				} else {
+					out.Conflicts = (out.Conflicts)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v34 string
+					v34 = string(in.String())
+					out.Conflicts = append(out.Conflicts, v34)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(out *jwriter.Writer, in ErrorResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Message\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Message))
+	}
+	if len(in.Conflicts) != 0 {
+		const prefix string = ",\"conflicts\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v35, v36 := range in.Conflicts {
+				if v35 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v36))
+			}
+			out.RawByte(']')
+		}
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v UpdateResponse) MarshalJSON() ([]byte, error) {
+func (v ErrorResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(&w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v UpdateResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(w, v)
+func (v ErrorResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *UpdateResponse) UnmarshalJSON(data []byte) error {
+func (v *ErrorResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(&r, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *UpdateResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi(l, v)
+func (v *ErrorResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi10(l, v)
 }
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(in *jlexer.Lexer, out *IndexResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(in *jlexer.Lexer, out *DeleteResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -119,8 +1936,8 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 			continue
 		}
 		switch key {
-		case "message":
-			out.Message = string(in.String())
+		case "id":
+			out.ID = string(in.String())
 		case "error":
 			if in.IsNull() {
 				in.Skip()
@@ -141,14 +1958,14 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(out *jwriter.Writer, in IndexResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(out *jwriter.Writer, in DeleteResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"message\":"
+		const prefix string = ",\"id\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Message))
+		out.String(string(in.ID))
 	}
 	if in.Error != nil {
 		const prefix string = ",\"error\":"
@@ -159,29 +1976,29 @@ func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v IndexResponse) MarshalJSON() ([]byte, error) {
+func (v DeleteResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(&w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v IndexResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(w, v)
+func (v DeleteResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *IndexResponse) UnmarshalJSON(data []byte) error {
+func (v *DeleteResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(&r, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *IndexResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi1(l, v)
+func (v *DeleteResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi11(l, v)
 }
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(in *jlexer.Lexer, out *GetResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(in *jlexer.Lexer, out *CalendarsResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -200,33 +2017,33 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 			continue
 		}
 		switch key {
-		case "events":
+		case "calendars":
 			if in.IsNull() {
 				in.Skip()
-				out.Events = nil
+				out.Calendars = nil
 			} else {
 				in.Delim('[')
-				if out.Events == nil {
+				if out.Calendars == nil {
 					if !in.IsDelim(']') {
-						out.Events = make([]*entities.Event, 0, 8)
+						out.Calendars = make([]*entities.Calendar, 0, 8)
 					} else {
-						out.Events = []*entities.Event{}
+						out.Calendars = []*entities.Calendar{}
 					}
 				} else {
-					out.Events = (out.Events)[:0]
+					out.Calendars = (out.Calendars)[:0]
 				}
 				for !in.IsDelim(']') {
-					var v1 *entities.Event
+					var v37 *entities.Calendar
 					if in.IsNull() {
 						in.Skip()
-						v1 = nil
+						v37 = nil
 					} else {
-						if v1 == nil {
-							v1 = new(entities.Event)
+						if v37 == nil {
+							v37 = new(entities.Calendar)
 						}
-						easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in, v1)
+						easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities8(in, v37)
 					}
-					out.Events = append(out.Events, v1)
+					out.Calendars = append(out.Calendars, v37)
 					in.WantComma()
 				}
 				in.Delim(']')
@@ -251,25 +2068,25 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(out *jwriter.Writer, in GetResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(out *jwriter.Writer, in CalendarsResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"events\":"
+		const prefix string = ",\"calendars\":"
 		out.RawString(prefix[1:])
-		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		if in.Calendars == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 			out.RawString("null")
 		} else {
 			out.RawByte('[')
-			for v2, v3 := range in.Events {
-				if v2 > 0 {
+			for v38, v39 := range in.Calendars {
+				if v38 > 0 {
 					out.RawByte(',')
 				}
-				if v3 == nil {
+				if v39 == nil {
 					out.RawString("null")
 				} else {
-					easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out, *v3)
+					easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities8(out, *v39)
 				}
 			}
 			out.RawByte(']')
@@ -284,29 +2101,29 @@ func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v GetResponse) MarshalJSON() ([]byte, error) {
+func (v CalendarsResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(&w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v GetResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(w, v)
+func (v CalendarsResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *GetResponse) UnmarshalJSON(data []byte) error {
+func (v *CalendarsResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(&r, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *GetResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi2(l, v)
+func (v *CalendarsResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi12(l, v)
 }
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(in *jlexer.Lexer, out *entities.Event) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities8(in *jlexer.Lexer, out *entities.Calendar) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -329,18 +2146,29 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDoma
 			out.ID = string(in.String())
 		case "Title":
 			out.Title = string(in.String())
-		case "DateTime":
-			if data := in.Raw(); in.Ok() {
-				in.AddError((out.DateTime).UnmarshalJSON(data))
+		case "ACL":
+			if in.IsNull() {
+				in.Skip()
+				out.ACL = nil
+			} else {
+				in.Delim('[')
+				if out.ACL == nil {
+					if !in.IsDelim(']') {
+						out.ACL = make([]entities.ACLEntry, 0, 2)
+					} else {
+						out.ACL = []entities.ACLEntry{}
+					}
+				} else {
+					out.ACL = (out.ACL)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v40 entities.ACLEntry
+					easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities9(in, &v40)
+					out.ACL = append(out.ACL, v40)
+					in.WantComma()
+				}
+				in.Delim(']')
 			}
-		case "Duration":
-			out.Duration = time.Duration(in.Int64())
-		case "Text":
-			out.Text = string(in.String())
-		case "UserID":
-			out.UserID = string(in.String())
-		case "TimeNotify":
-			out.TimeNotify = time.Duration(in.Int64())
 		default:
 			in.SkipRecursive()
 		}
@@ -351,7 +2179,7 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDoma
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities(out *jwriter.Writer, in entities.Event) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities8(out *jwriter.Writer, in entities.Calendar) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -366,33 +2194,24 @@ func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDoma
 		out.String(string(in.Title))
 	}
 	{
-		const prefix string = ",\"DateTime\":"
-		out.RawString(prefix)
-		out.Raw((in.DateTime).MarshalJSON())
-	}
-	{
-		const prefix string = ",\"Duration\":"
-		out.RawString(prefix)
-		out.Int64(int64(in.Duration))
-	}
-	{
-		const prefix string = ",\"Text\":"
-		out.RawString(prefix)
-		out.String(string(in.Text))
-	}
-	{
-		const prefix string = ",\"UserID\":"
-		out.RawString(prefix)
-		out.String(string(in.UserID))
-	}
-	{
-		const prefix string = ",\"TimeNotify\":"
+		const prefix string = ",\"ACL\":"
 		out.RawString(prefix)
-		out.Int64(int64(in.TimeNotify))
+		if in.ACL == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v41, v42 := range in.ACL {
+				if v41 > 0 {
+					out.RawByte(',')
+				}
+				easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities9(out, v42)
+			}
+			out.RawByte(']')
+		}
 	}
 	out.RawByte('}')
 }
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(in *jlexer.Lexer, out *ErrorResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities9(in *jlexer.Lexer, out *entities.ACLEntry) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -411,8 +2230,10 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 			continue
 		}
 		switch key {
-		case "Message":
-			out.Message = string(in.String())
+		case "UserID":
+			out.UserID = string(in.String())
+		case "Role":
+			out.Role = entities.Role(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -423,42 +2244,23 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(out *jwriter.Writer, in ErrorResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarInternalDomainEntities9(out *jwriter.Writer, in entities.ACLEntry) {
 	out.RawByte('{')
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"Message\":"
+		const prefix string = ",\"UserID\":"
 		out.RawString(prefix[1:])
-		out.String(string(in.Message))
+		out.String(string(in.UserID))
+	}
+	{
+		const prefix string = ",\"Role\":"
+		out.RawString(prefix)
+		out.String(string(in.Role))
 	}
 	out.RawByte('}')
 }
-
-// MarshalJSON supports json.Marshaler interface
-func (v ErrorResponse) MarshalJSON() ([]byte, error) {
-	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(&w, v)
-	return w.Buffer.BuildBytes(), w.Error
-}
-
-// MarshalEasyJSON supports easyjson.Marshaler interface
-func (v ErrorResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(w, v)
-}
-
-// UnmarshalJSON supports json.Unmarshaler interface
-func (v *ErrorResponse) UnmarshalJSON(data []byte) error {
-	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(&r, v)
-	return r.Error()
-}
-
-// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *ErrorResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi3(l, v)
-}
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(in *jlexer.Lexer, out *DeleteResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(in *jlexer.Lexer, out *CalendarResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -499,7 +2301,7 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(out *jwriter.Writer, in DeleteResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(out *jwriter.Writer, in CalendarResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -517,29 +2319,29 @@ func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v DeleteResponse) MarshalJSON() ([]byte, error) {
+func (v CalendarResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(&w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v DeleteResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(w, v)
+func (v CalendarResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *DeleteResponse) UnmarshalJSON(data []byte) error {
+func (v *CalendarResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(&r, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *DeleteResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi4(l, v)
+func (v *CalendarResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi13(l, v)
 }
-func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(in *jlexer.Lexer, out *AddResponse) {
+func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(in *jlexer.Lexer, out *AddResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -580,7 +2382,7 @@ func easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 		in.Consumed()
 	}
 }
-func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(out *jwriter.Writer, in AddResponse) {
+func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(out *jwriter.Writer, in AddResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -600,23 +2402,23 @@ func easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarA
 // MarshalJSON supports json.Marshaler interface
 func (v AddResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(&w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v AddResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(w, v)
+	easyjsonC1cedd36EncodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *AddResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(&r, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *AddResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi5(l, v)
+	easyjsonC1cedd36DecodeGithubComShipa988HwOtusHw12131415CalendarCmdCalendarApiHttpapi14(l, v)
 }
@@ -83,6 +83,9 @@ func initConfig() {
 	if dsn, ok := viper.Get("DSN").(string); dsn != "" && ok {
 		cfg.DB.DSN = dsn
 	}
+	if secret, ok := viper.Get("AUTH_SECRET").(string); secret != "" && ok {
+		cfg.Auth.Secret = secret
+	}
 }
 
 // Execute adds all child cmd to the root cmd and sets flags appropriately.
@@ -1,15 +1,22 @@
 package app
 
 import (
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/changefeed"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/controllers/grpcserver"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/controllers/httpserver"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/adminserver"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/auth"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/db"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/logger"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 
+	"context"
 	"net"
 	"os"
 	"os/signal"
@@ -39,21 +46,52 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 		return errors.Wrapf(err, "can't init logger")
 	}
 
+	shutdownTracing, err := tracing.Init(tracing.Config{
+		Service:  "calendar",
+		Exporter: cfg.Tracing.Exporter,
+		Endpoint: cfg.Tracing.Endpoint,
+		File:     cfg.Tracing.File,
+		Ratio:    cfg.Tracing.Ratio,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "can't init tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error(context.Background(), errors.Wrap(err, "can't flush spans"))
+		}
+	}()
+
 	repo, err := InitRepo(cfg, logger)
 	if err != nil {
 		return errors.Wrapf(err, "can't init repository")
 	}
-	calendar := usecases.NewCalendar(repo, nil, logger)
+	checks := health.NewHealth(logger)
+	if dbRepo, ok := repo.(*db.EventRepo); ok {
+		checks.AddReadiness("postgres", health.CheckerFunc(func(ctx context.Context) error {
+			return dbRepo.Connect(ctx, cfg.DB.DSN)
+		}))
+	}
+	repo = metrics.NewEventRepo(repo, cfg.RepoType)
+	authenticator, err := auth.NewAuthenticator(cfg.Auth.Mode, cfg.Auth.Algorithm, cfg.Auth.Secret, cfg.Auth.JWKS, cfg.Auth.UserClaim)
+	if err != nil {
+		return errors.Wrapf(err, "can't init authenticator")
+	}
+	if cfg.Auth.Mode == auth.ModeHeader {
+		logger.Warn(context.Background(), "x-user-id header is trusted without authentication, use it only for local development")
+	}
+	feed := changefeed.NewFeed(cfg.Changes.History, cfg.Changes.Buffer)
+	calendar := usecases.NewCalendar(repo, nil, feed, cfg.Idempotency.TTL, logger)
 	// set executors for api.
 	apiHandler := httpserver.NewAPIHandler(calendar, logger)
 	// prepare http handler with all middlewares for server.
-	httpHandler := httpserver.GetHandler(logger, apiHandler)
+	httpHandler := httpserver.GetHandler(logger, apiHandler, authenticator)
 
 	wg := &sync.WaitGroup{}
 	// prepare http server with handler
 	httpServer := httpserver.NewHTTPServer(wg, logger, httpHandler)
 	// prepare grpc server with handler
-	grpcServer := grpcserver.NewGRPCServer(wg, logger, calendar)
+	grpcServer := grpcserver.NewGRPCServer(wg, logger, calendar, authenticator, checks)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
@@ -68,6 +106,10 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 	wg.Add(1)
 	go grpcServer.ServeGW(net.JoinHostPort("0.0.0.0", cfg.API.GRPCPort), net.JoinHostPort("0.0.0.0", cfg.API.GRPCGWPort))
 
+	// admin server isn't added to wg, the app keeps working without metrics and probes.
+	adminServer := adminserver.NewAdminServer(net.JoinHostPort("0.0.0.0", cfg.Admin.Port), checks, logger)
+	go adminServer.Serve()
+
 	go func() {
 		wg.Wait()
 		quit <- os.Interrupt
@@ -75,9 +117,12 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 
 	<-quit
 
+	// watchers are disconnected, so servers don't wait for them
+	feed.Close()
 	httpServer.StopServe()
 	grpcServer.StopServe()
 	grpcServer.StopGWServe()
+	adminServer.StopServe()
 
 	return nil
 }
@@ -1,10 +1,17 @@
 package app
 
+import "time"
+
 type Config struct {
-	Log      Log    `yaml:"log"`
-	API      API    `yaml:"api"`
-	RepoType string `yaml:"repotype"`
-	DB       DB     `yaml:"db"`
+	Log         Log         `yaml:"log"`
+	API         API         `yaml:"api"`
+	RepoType    string      `yaml:"repotype"`
+	DB          DB          `yaml:"db"`
+	Auth        Auth        `yaml:"auth"`
+	Changes     Changes     `yaml:"changes"`
+	Admin       Admin       `yaml:"admin"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Idempotency Idempotency `yaml:"idempotency"`
 }
 type Log struct {
 	File  string `yaml:"file"`
@@ -21,3 +28,37 @@ type DB struct {
 	Driver string `yaml:"driver"`
 	DSN    string `yaml:"dsn"`
 }
+
+// Auth mode is jwt or header, header mode trusts x-user-id and is only for local development.
+type Auth struct {
+	Mode      string `yaml:"mode"`
+	Algorithm string `yaml:"algorithm"`
+	Secret    string `yaml:"secret"`
+	JWKS      string `yaml:"jwks"`
+	UserClaim string `yaml:"userclaim"`
+}
+
+// Changes are sizes of change feed: history is count of the last changes kept for resuming watchers, buffer is count of changes waiting for slow watcher.
+type Changes struct {
+	History int `yaml:"history"`
+	Buffer  int `yaml:"buffer"`
+}
+
+// Admin is port of admin server with /metrics, /healthz and /readyz endpoints.
+type Admin struct {
+	Port string `yaml:"port"`
+}
+
+// Tracing exporter is otlp, stdout, file or none, endpoint is OTLP/HTTP url of collector, ratio is share of sampled
+// traces started by the app.
+type Tracing struct {
+	Exporter string  `yaml:"exporter"`
+	Endpoint string  `yaml:"endpoint"`
+	File     string  `yaml:"file"`
+	Ratio    float64 `yaml:"ratio"`
+}
+
+// Idempotency ttl is how long response of request with Idempotency-Key header is replayed, zero means 24h.
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl"`
+}
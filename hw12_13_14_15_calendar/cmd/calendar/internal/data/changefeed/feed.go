@@ -0,0 +1,135 @@
+package changefeed
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const (
+	DefaultHistory = 1024
+	DefaultBuffer  = 64
+)
+
+var ErrClosed = errors.New("change feed is stopped")
+
+var _ entities.ChangeFeed = (*Feed)(nil)
+
+// Feed keeps the last changes in memory and delivers new ones to subscribers of their users.
+// Revisions start from 1 on every start of service, so clients with revision from the future must reload events.
+type Feed struct {
+	mux         *sync.Mutex
+	history     []entities.Change
+	size        int
+	buffer      int
+	revision    uint64
+	subscribers map[*subscriber]bool
+	closed      bool
+}
+
+type subscriber struct {
+	userID  string
+	changes chan entities.Change
+}
+
+// NewFeed makes feed keeping history of changes for resuming and buffer of changes for every subscriber, zero sizes are defaults.
+func NewFeed(history, buffer int) *Feed {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Feed{
+		mux:         &sync.Mutex{},
+		history:     make([]entities.Change, 0, history),
+		size:        history,
+		buffer:      buffer,
+		subscribers: make(map[*subscriber]bool),
+	}
+}
+
+// Publish doesn't wait for subscribers, subscriber with full buffer is dropped to resume later.
+func (f *Feed) Publish(change entities.Change) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.closed {
+		return
+	}
+	f.revision++
+	change.Revision = f.revision
+	if len(f.history) == f.size {
+		copy(f.history, f.history[1:])
+		f.history = f.history[:f.size-1]
+	}
+	f.history = append(f.history, change)
+
+	for s := range f.subscribers {
+		if !change.VisibleTo(s.userID) {
+			continue
+		}
+		select {
+		case s.changes <- change:
+		default:
+			f.drop(s)
+		}
+	}
+}
+
+func (f *Feed) Subscribe(userID string, after uint64) (*entities.Subscription, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if after > f.revision || (after > 0 && after < f.oldest()-1) {
+		return nil, errors.Wrapf(entities.ErrRevisionGone, "revision %v, feed has changes from %v to %v", after, f.oldest(), f.revision)
+	}
+	backlog := []entities.Change{}
+	if after > 0 {
+		for _, change := range f.history {
+			if change.Revision > after && change.VisibleTo(userID) {
+				backlog = append(backlog, change)
+			}
+		}
+	}
+	s := &subscriber{userID: userID, changes: make(chan entities.Change, f.buffer)}
+	f.subscribers[s] = true
+	return &entities.Subscription{
+		Backlog: backlog,
+		Changes: s.changes,
+		Cancel: func() {
+			f.mux.Lock()
+			defer f.mux.Unlock()
+			f.drop(s)
+		},
+	}, nil
+}
+
+// Close stops feed, channels of all subscribers are closed.
+func (f *Feed) Close() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.closed = true
+	for s := range f.subscribers {
+		f.drop(s)
+	}
+}
+
+// oldest returns revision of the oldest kept change, caller must hold lock.
+func (f *Feed) oldest() uint64 {
+	if len(f.history) == 0 {
+		return f.revision + 1
+	}
+	return f.history[0].Revision
+}
+
+// drop unsubscribes subscriber, caller must hold lock.
+func (f *Feed) drop(s *subscriber) {
+	if f.subscribers[s] {
+		delete(f.subscribers, s)
+		close(s.changes)
+	}
+}
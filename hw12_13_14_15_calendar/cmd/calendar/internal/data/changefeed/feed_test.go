@@ -0,0 +1,87 @@
+package changefeed
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+func change(userIDs ...string) entities.Change {
+	return entities.Change{Kind: entities.ChangeCreated, Event: &entities.Event{}, UserIDs: userIDs}
+}
+
+func TestFeed(t *testing.T) {
+	t.Run("publish to watchers", func(t *testing.T) {
+		feed := NewFeed(0, 0)
+		alice, err := feed.Subscribe("alice", 0)
+		require.Nil(t, err)
+		require.Empty(t, alice.Backlog)
+		bob, err := feed.Subscribe("bob", 0)
+		require.Nil(t, err)
+
+		feed.Publish(change("alice"))
+		feed.Publish(change("alice", "bob"))
+
+		require.Equal(t, uint64(1), (<-alice.Changes).Revision)
+		require.Equal(t, uint64(2), (<-alice.Changes).Revision)
+		require.Equal(t, uint64(2), (<-bob.Changes).Revision)
+		require.Len(t, bob.Changes, 0)
+
+		bob.Cancel()
+		_, ok := <-bob.Changes
+		require.False(t, ok)
+		bob.Cancel()
+	})
+	t.Run("resume", func(t *testing.T) {
+		feed := NewFeed(3, 0)
+		for i := 0; i < 5; i++ {
+			feed.Publish(change("alice"))
+		}
+		sub, err := feed.Subscribe("alice", 3)
+		require.Nil(t, err)
+		require.Len(t, sub.Backlog, 2)
+		require.Equal(t, uint64(4), sub.Backlog[0].Revision)
+
+		sub, err = feed.Subscribe("alice", 2)
+		require.Nil(t, err)
+		require.Len(t, sub.Backlog, 3, "history keeps revisions from 3")
+
+		_, err = feed.Subscribe("alice", 1)
+		require.True(t, errors.Is(err, entities.ErrRevisionGone))
+		_, err = feed.Subscribe("alice", 6)
+		require.True(t, errors.Is(err, entities.ErrRevisionGone), "revision from the previous start of service")
+
+		sub, err = feed.Subscribe("bob", 3)
+		require.Nil(t, err)
+		require.Empty(t, sub.Backlog)
+	})
+	t.Run("slow subscriber", func(t *testing.T) {
+		feed := NewFeed(0, 2)
+		sub, err := feed.Subscribe("alice", 0)
+		require.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			feed.Publish(change("alice"))
+		}
+		revisions := []uint64{}
+		for c := range sub.Changes {
+			revisions = append(revisions, c.Revision)
+		}
+		require.Equal(t, []uint64{1, 2}, revisions, "subscriber is dropped and resumes from revision 2")
+	})
+	t.Run("close", func(t *testing.T) {
+		feed := NewFeed(0, 0)
+		sub, err := feed.Subscribe("alice", 0)
+		require.Nil(t, err)
+		feed.Close()
+		_, ok := <-sub.Changes
+		require.False(t, ok)
+		sub.Cancel()
+
+		_, err = feed.Subscribe("alice", 0)
+		require.Equal(t, ErrClosed, err)
+		feed.Publish(change("alice"))
+	})
+}
@@ -0,0 +1,67 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
+)
+
+// watchInterval is interval between checks of watched health.
+const watchInterval = 5 * time.Second
+
+// healthMethodPrefix is prefix of grpc.health.v1 methods, they are called by probes without authentication.
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+var _ healthpb.HealthServer = (*healthServer)(nil)
+
+// healthServer is grpc.health.v1.Health service, the server ("") and CalendarService are serving while calendar is ready.
+type healthServer struct {
+	checks *health.Health
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownService(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %v", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: s.servingStatus(ctx)}, nil
+}
+
+// Watch sends status at once and then every time it changes.
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if !knownService(req.GetService()) {
+		return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+	}
+	tick := time.NewTicker(watchInterval)
+	defer tick.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := s.servingStatus(ctx); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+func (s *healthServer) servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if s.checks.Ready(ctx).OK() {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+func knownService(service string) bool {
+	return service == "" || service == "CalendarService"
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,50 +17,73 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	api "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/api/grpcapi"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/controllers/icalendar"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/auth"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
 )
 
 var headers = []string{
 	util.AuthHeaderKey,
+	util.TimeZoneHeaderKey,
+	util.IdempotencyHeaderKey,
+	// W3C trace context of gateway request
+	"traceparent",
+	"tracestate",
 }
 
 type GRPCServer struct {
-	logger   mainusecase.Logger
-	wg       *sync.WaitGroup
-	calendar usecases.Calendar
+	logger        mainusecase.Logger
+	wg            *sync.WaitGroup
+	calendar      usecases.Calendar
+	authenticator auth.Authenticator
+	// checks are served by grpc.health.v1.Health, the service isn't registered without checks.
+	checks   *health.Health
 	server   *grpc.Server
 	gwserver *http.Server
 }
 
-func NewGRPCServer(wg *sync.WaitGroup, logger mainusecase.Logger, calendar usecases.Calendar) *GRPCServer {
+func NewGRPCServer(wg *sync.WaitGroup, logger mainusecase.Logger, calendar usecases.Calendar, authenticator auth.Authenticator, checks *health.Health) *GRPCServer {
 	return &GRPCServer{
-		logger:   logger,
-		wg:       wg,
-		calendar: calendar}
+		logger:        logger,
+		wg:            wg,
+		calendar:      calendar,
+		authenticator: authenticator,
+		checks:        checks}
 }
 
 func (cs *GRPCServer) AddEvent(ctx context.Context, req *api.AddEventRequest) (*api.AddEventResponse, error) {
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	id, err := cs.calendar.MakeEvent(nctx, req.GetTitle(), req.GetDatetime(), req.GetText(), userid, req.GetDuration(), req.GetTimenotify())
+	timezone := req.GetTimezone()
+	if timezone == "" {
+		timezone = util.GetTimeZone(ctx)
+	}
+
+	reminders, err := fromPBReminders(req.GetTimenotify(), req.GetReminders())
 	if err != nil {
-		if errors.Is(err, entities.ErrDateBusy) {
-			return nil, status.Error(codes.AlreadyExists, err.Error())
-		}
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, err := cs.calendar.MakeEvent(nctx, req.GetTitle(), req.GetDatetime(), req.GetText(), userid, req.GetDuration(), reminders, req.GetRrule(), req.GetExdate(), timezone, req.GetCalendarId(), idempotencyKey(ctx))
+	if err != nil {
+		return nil, useCaseError(err)
 	}
 
 	resp := &api.AddEventResponse{
-		Id: id,
+		Id:      id,
+		Version: entities.InitialVersion,
 	}
 	return resp, nil
 }
@@ -68,9 +92,9 @@ func (cs *GRPCServer) DeleteEvent(ctx context.Context, req *api.DeleteEventReque
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	id, err := cs.calendar.DeleteEvent(nctx, userid, req.GetId())
+	id, err := cs.calendar.DeleteEvent(nctx, userid, req.GetId(), expectedVersion(req.GetExpectedVersion()))
 	if err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, useCaseError(err)
 	}
 
 	resp := &api.DeleteEventResponse{
@@ -83,13 +107,20 @@ func (cs *GRPCServer) UpdateEvent(ctx context.Context, req *api.UpdateEventReque
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	id, err := cs.calendar.UpdateEvent(nctx, userid, req.GetId(), req.GetTitle(), req.GetDatetime(), req.GetText(), req.GetDuration(), req.GetTimenotify())
+	reminders, err := fromPBReminders(req.GetTimenotify(), req.GetReminders())
 	if err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, version, err := cs.calendar.UpdateEvent(nctx, userid, req.GetId(), req.GetTitle(), req.GetDatetime(), req.GetText(), req.GetDuration(), reminders, req.GetRrule(), req.GetExdate(), req.GetTimezone(),
+		expectedVersion(req.GetExpectedVersion()), idempotencyKey(ctx))
+	if err != nil {
+		return nil, useCaseError(err)
 	}
 
 	resp := &api.UpdateEventResponse{
-		Id: id,
+		Id:      id,
+		Version: version,
 	}
 	return resp, nil
 }
@@ -98,7 +129,7 @@ func (cs *GRPCServer) GetDateEvent(ctx context.Context, req *api.GetDateEventReq
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	events, err := cs.calendar.GetDateEvents(nctx, req.GetDate(), userid)
+	events, err := cs.calendar.GetDateEvents(nctx, req.GetDate(), userid, util.GetTimeZone(ctx))
 	if err != nil {
 		return nil, status.Error(codes.Aborted, err.Error())
 	}
@@ -118,7 +149,7 @@ func (cs *GRPCServer) GetWeekEvent(ctx context.Context, req *api.GetWeekEventReq
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	events, err := cs.calendar.GetWeekEvents(nctx, req.GetDate(), userid)
+	events, err := cs.calendar.GetWeekEvents(nctx, req.GetDate(), userid, util.GetTimeZone(ctx))
 	if err != nil {
 		return nil, status.Error(codes.Aborted, err.Error())
 	}
@@ -138,7 +169,7 @@ func (cs *GRPCServer) GetMonthEvent(ctx context.Context, req *api.GetMonthEventR
 	nctx := util.SetRequestID(ctx)
 	userid := util.GetUserID(ctx)
 
-	events, err := cs.calendar.GetMonthEvents(nctx, req.GetDate(), userid)
+	events, err := cs.calendar.GetMonthEvents(nctx, req.GetDate(), userid, util.GetTimeZone(ctx))
 	if err != nil {
 		return nil, status.Error(codes.Aborted, err.Error())
 	}
@@ -154,6 +185,305 @@ func (cs *GRPCServer) GetMonthEvent(ctx context.Context, req *api.GetMonthEventR
 	return resp, nil
 }
 
+func (cs *GRPCServer) ExportEvents(ctx context.Context, req *api.ExportEventsRequest) (*api.ExportEventsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	events, err := cs.calendar.ExportEvents(nctx, req.GetStart(), req.GetEnd(), userid, util.GetTimeZone(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	ics := &strings.Builder{}
+	if err := icalendar.Encode(ics, events); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &api.ExportEventsResponse{
+		Calendar: ics.String(),
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) FreeBusy(ctx context.Context, req *api.FreeBusyRequest) (*api.FreeBusyResponse, error) {
+	nctx := util.SetRequestID(ctx)
+
+	users := make([]usecases.FreeBusyUser, 0, len(req.GetUsers()))
+	for _, user := range req.GetUsers() {
+		users = append(users, usecases.FreeBusyUser{UserID: user.GetUserid(), WorkingHours: user.GetWorkingHours(), TimeZone: user.GetTimezone()})
+	}
+	slots := ""
+	if req.GetSlots() != 0 {
+		slots = strconv.Itoa(int(req.GetSlots()))
+	}
+
+	freeBusy, err := cs.calendar.FreeBusy(nctx, req.GetStart(), req.GetEnd(), req.GetDuration(), slots, util.GetTimeZone(ctx), users)
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	resp := &api.FreeBusyResponse{
+		Users: make([]*api.UserBusy, 0, len(freeBusy.Users)),
+	}
+	for _, user := range freeBusy.Users {
+		busy, err := toPBIntervals(user.Busy)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Users = append(resp.Users, &api.UserBusy{Userid: user.UserID, Busy: busy})
+	}
+	resp.Slots, err = toPBIntervals(freeBusy.Slots)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) InviteAttendees(ctx context.Context, req *api.InviteAttendeesRequest) (*api.InviteAttendeesResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.InviteAttendees(nctx, userid, req.GetId(), req.GetAttendees())
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.InviteAttendeesResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) RespondInvitation(ctx context.Context, req *api.RespondInvitationRequest) (*api.RespondInvitationResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.RespondInvitation(nctx, userid, req.GetId(), req.GetStatus())
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	resp := &api.RespondInvitationResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) GetInvitations(ctx context.Context, req *api.GetInvitationsRequest) (*api.GetInvitationsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	events, err := cs.calendar.GetInvitations(nctx, userid, req.GetStatus(), util.GetTimeZone(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	evs, err := toPBEvents(events)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &api.GetInvitationsResponse{
+		Events: evs,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) AddCalendar(ctx context.Context, req *api.AddCalendarRequest) (*api.AddCalendarResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.MakeCalendar(nctx, userid, req.GetTitle())
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.AddCalendarResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) GetCalendars(ctx context.Context, req *api.GetCalendarsRequest) (*api.GetCalendarsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	calendars, err := cs.calendar.GetCalendars(nctx, userid)
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.GetCalendarsResponse{
+		Calendars: toPBCalendars(calendars),
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) ShareCalendar(ctx context.Context, req *api.ShareCalendarRequest) (*api.ShareCalendarResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.ShareCalendar(nctx, userid, req.GetId(), req.GetUserid(), req.GetRole())
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.ShareCalendarResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) UnshareCalendar(ctx context.Context, req *api.UnshareCalendarRequest) (*api.UnshareCalendarResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.UnshareCalendar(nctx, userid, req.GetId(), req.GetUserid())
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.UnshareCalendarResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) DeleteCalendar(ctx context.Context, req *api.DeleteCalendarRequest) (*api.DeleteCalendarResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	id, err := cs.calendar.DeleteCalendar(nctx, userid, req.GetId())
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.DeleteCalendarResponse{
+		Id: id,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) GetCalendarEvents(ctx context.Context, req *api.GetCalendarEventsRequest) (*api.GetCalendarEventsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	events, err := cs.calendar.GetCalendarEvents(nctx, userid, req.GetId(), req.GetStart(), req.GetEnd(), util.GetTimeZone(ctx))
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	evs, err := toPBEvents(events)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &api.GetCalendarEventsResponse{
+		Events: evs,
+	}
+	return resp, nil
+}
+
+// ListEvents uses default page size if page_size isn't set, recurring events are listed as whole series.
+func (cs *GRPCServer) ListEvents(ctx context.Context, req *api.ListEventsRequest) (*api.ListEventsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	pageSize := ""
+	if req.GetPageSize() != 0 {
+		pageSize = strconv.Itoa(int(req.GetPageSize()))
+	}
+	page, err := cs.calendar.ListEvents(nctx, userid, req.GetStart(), req.GetEnd(), req.GetSearch(), req.GetSort(), pageSize, req.GetCursor(), util.GetTimeZone(ctx))
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	evs, err := toPBEvents(page.Events)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &api.ListEventsResponse{
+		Events:     evs,
+		NextCursor: page.Next,
+	}
+	return resp, nil
+}
+
+func (cs *GRPCServer) SearchEvents(ctx context.Context, req *api.SearchEventsRequest) (*api.SearchEventsResponse, error) {
+	nctx := util.SetRequestID(ctx)
+	userid := util.GetUserID(ctx)
+
+	limit := ""
+	if req.GetLimit() != 0 {
+		limit = strconv.Itoa(int(req.GetLimit()))
+	}
+	hits, err := cs.calendar.SearchEvents(nctx, userid, req.GetQuery(), limit, util.GetTimeZone(ctx))
+	if err != nil {
+		return nil, useCaseError(err)
+	}
+
+	resp := &api.SearchEventsResponse{
+		Results: make([]*api.SearchResult, 0, len(hits)),
+	}
+	for _, hit := range hits {
+		event, err := toPBEvent(hit.Event)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Results = append(resp.Results, &api.SearchResult{Event: event, Rank: hit.Rank})
+	}
+	return resp, nil
+}
+
+// WatchEvents sends missed changes after the revision and then new ones until client leaves.
+// Stream is finished with Unavailable if client is too slow or server stops, client resumes from the last received revision.
+func (cs *GRPCServer) WatchEvents(req *api.WatchEventsRequest, stream api.CalendarService_WatchEventsServer) error {
+	ctx := stream.Context()
+	userid := util.GetUserID(ctx)
+
+	revision := ""
+	if req.GetAfterRevision() != 0 {
+		revision = strconv.FormatUint(req.GetAfterRevision(), 10)
+	}
+	sub, err := cs.calendar.WatchEvents(ctx, userid, revision, util.GetTimeZone(ctx))
+	if err != nil {
+		return useCaseError(err)
+	}
+	defer sub.Cancel()
+
+	for _, change := range sub.Backlog {
+		if err := sendChange(stream, change); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case change, ok := <-sub.Changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "watching is interrupted, resume from the last received revision")
+			}
+			if err := sendChange(stream, change); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func sendChange(stream api.CalendarService_WatchEventsServer, change entities.Change) error {
+	event, err := toPBEvent(change.Event)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.Send(&api.EventChange{
+		Revision: change.Revision,
+		Kind:     string(change.Kind),
+		Event:    event,
+	})
+}
+
 func (cs *GRPCServer) ServeGW(addr string, addrgw string) {
 	defer cs.wg.Done()
 	ctx := context.Background()
@@ -212,9 +542,13 @@ func (cs *GRPCServer) Serve(listener net.Listener) {
 	cs.logger.Info(context.Background(), "starting grpc server at %v", listener.Addr().String())
 
 	cs.server = grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(cs.loggingUnary, cs.authUnary)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(cs.tracingUnary, cs.loggingUnary, cs.authUnary, cs.timeZoneUnary)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(cs.tracingStream, cs.loggingStream, cs.authStream, cs.timeZoneStream)),
 	)
 	api.RegisterCalendarServiceServer(cs.server, cs)
+	if cs.checks != nil {
+		healthpb.RegisterHealthServer(cs.server, &healthServer{checks: cs.checks})
+	}
 
 	if err := cs.server.Serve(listener); err != http.ErrServerClosed {
 		cs.logger.Error(context.Background(), errors.Wrapf(err, "can't start grpc server at %v", listener.Addr().String()))
@@ -229,24 +563,112 @@ func (cs *GRPCServer) StopServe() {
 	cs.server.GracefulStop()
 }
 
+// authUnary authenticates user by metadata, the gateway passes Authorization header as authorization metadata.
+// Health checks are called by probes, so they aren't authenticated.
 func (cs *GRPCServer) authUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	userID := ""
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+	newCtx, err := cs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(newCtx, req)
+}
 
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if uID, ok := md[util.AuthHeaderKey]; ok {
-			userID = strings.Join(uID, ",")
-		} else {
-			return nil, status.Error(codes.PermissionDenied, "unauthorized user")
-		}
+func (cs *GRPCServer) authStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, stream)
+	}
+	newCtx, err := cs.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpc_middleware.WrappedServerStream{ServerStream: stream, WrappedContext: newCtx})
+}
+
+func (cs *GRPCServer) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	userID, err := cs.authenticator.Authenticate(func(key string) string {
+		return strings.Join(md.Get(key), ",")
+	})
+	if err != nil {
+		cs.logger.Warn(ctx, "authentication failed: %v", err)
+		return nil, status.Error(codes.PermissionDenied, "unauthorized user")
 	}
-	newCtx := util.SetUserID(ctx, userID)
 	cs.logger.Info(ctx, "user id:%v incoming request", userID)
+	return util.SetUserID(ctx, userID), nil
+}
+
+// timeZoneUnary puts requester time zone from x-timezone metadata to context, UTC is used without metadata.
+func (cs *GRPCServer) timeZoneUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	newCtx, err := setTimeZone(ctx)
+	if err != nil {
+		return nil, err
+	}
 	return handler(newCtx, req)
 }
 
+func (cs *GRPCServer) timeZoneStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	newCtx, err := setTimeZone(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpc_middleware.WrappedServerStream{ServerStream: stream, WrappedContext: newCtx})
+}
+
+func setTimeZone(ctx context.Context) (context.Context, error) {
+	timeZone := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if tz, ok := md[util.TimeZoneHeaderKey]; ok && len(tz) > 0 {
+			timeZone = tz[0]
+		}
+	}
+	if _, err := entities.LoadLocation(timeZone); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return util.SetTimeZone(ctx, timeZone), nil
+}
+
+// expectedVersion converts expected_version to version of use case, zero means any version.
+func expectedVersion(version int64) string {
+	if version == 0 {
+		return ""
+	}
+	return strconv.FormatInt(version, 10)
+}
+
+// idempotencyKey returns key from idempotency-key metadata, empty key means request without idempotency.
+func idempotencyKey(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(util.IdempotencyHeaderKey); len(keys) > 0 {
+			return keys[0]
+		}
+	}
+	return ""
+}
+
 func (cs *GRPCServer) loggingUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	start := time.Now()
+	ri := requestInfo(ctx, start, info.FullMethod)
+	newctx := util.SetRequestID(ctx)
+	h, err := handler(newctx, req)
+	// after executing rpc
+	cs.logResult(newctx, ri, start, err)
+	return h, err
+}
+
+// loggingStream logs stream when it is finished, latency of stream is its duration.
+func (cs *GRPCServer) loggingStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ri := requestInfo(stream.Context(), start, info.FullMethod)
+	newctx := util.SetRequestID(stream.Context())
+	err := handler(srv, &grpc_middleware.WrappedServerStream{ServerStream: stream, WrappedContext: newctx})
+	cs.logResult(newctx, ri, start, err)
+	return err
+}
 
+func requestInfo(ctx context.Context, start time.Time, method string) *util.HTTPReqInfo {
 	clientIP := "unknown"
 	if p, ok := peer.FromContext(ctx); ok {
 		clientIP = p.Addr.String()
@@ -258,23 +680,51 @@ func (cs *GRPCServer) loggingUnary(ctx context.Context, req interface{}, info *g
 			useragent = strings.Join(ua, ",")
 		}
 	}
-	ri := util.NewHTTPReqInfo(clientIP, start, info.FullMethod, "", "proto3", useragent)
+	return util.NewHTTPReqInfo(clientIP, start, method, "", "proto3", useragent)
+}
 
-	newctx := util.SetRequestID(ctx)
-	h, err := handler(newctx, req)
-	// after executing rpc
+func (cs *GRPCServer) logResult(ctx context.Context, ri *util.HTTPReqInfo, start time.Time, err error) {
 	s, _ := status.FromError(err)
 	ri.Code = s.Code().String()
 	ri.Latency = time.Since(start)
 	//logging
-	cs.logRequest(newctx, ri)
-	return h, err
+	cs.logRequest(ctx, ri)
+	metrics.ObserveGRPC(ri.Method, ri.Code, ri.Latency)
 }
 
 func (cs *GRPCServer) logRequest(ctx context.Context, ri *util.HTTPReqInfo) {
 	cs.logger.Info(ctx, "%s [%s] %s %s %s %s %s [%s]", ri.IP, ri.Start, ri.Method, ri.Path, ri.Httpver, ri.Code, ri.Latency, ri.Useragent)
 }
 
+// useCaseError returns status of use case error, AlreadyExists for busy date, PermissionDenied for calendar access,
+// OutOfRange for gone revision of changes, FailedPrecondition for stale version of event, InvalidArgument for invalid
+// idempotency key or incorrect version and Aborted for others including reused or pending idempotency key.
+func useCaseError(err error) error {
+	switch {
+	case errors.Is(err, entities.ErrDateBusy):
+		return busyError(err)
+	case errors.Is(err, entities.ErrAccessDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, entities.ErrRevisionGone):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, entities.ErrVersionConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entities.ErrIdempotencyKey), errors.Is(err, entities.ErrVersionFormat):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Aborted, err.Error())
+}
+
+// busyError returns AlreadyExists status with IDs of overlapping events in DateBusyDetails.
+func busyError(err error) error {
+	st := status.New(codes.AlreadyExists, err.Error())
+	detailed, derr := st.WithDetails(&api.DateBusyDetails{Conflicts: entities.ConflictingEventIDs(err)})
+	if derr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
 func toPBEvents(events []*entities.Event) (*api.Events, error) {
 	grpcEvents := make([]*api.Event, 0, len(events))
 	for _, event := range events {
@@ -289,10 +739,19 @@ func toPBEvents(events []*entities.Event) (*api.Events, error) {
 
 func toPBEvent(event *entities.Event) (*api.Event, error) {
 	pbe := &api.Event{
-		Id:     event.ID,
-		Title:  event.Title,
-		Text:   event.Text,
-		Userid: event.UserID,
+		Id:            event.ID,
+		Title:         event.Title,
+		Text:          event.Text,
+		Userid:        event.UserID,
+		Rrule:         event.Recurrence.RRule(),
+		Exdate:        event.ExDate(),
+		Timezone:      event.TimeZone,
+		Version:       event.Version,
+		LocalDatetime: event.DateTime.Format(time.RFC3339),
+	}
+	pbe.CalendarId = event.CalendarID
+	for _, a := range event.Attendees {
+		pbe.Attendees = append(pbe.Attendees, &api.Attendee{Userid: a.UserID, Status: string(a.Status)})
 	}
 	pbdt, err := ptypes.TimestampProto(event.DateTime)
 	if err != nil {
@@ -301,10 +760,59 @@ func toPBEvent(event *entities.Event) (*api.Event, error) {
 	pbe.Datetime = pbdt
 	pbd := ptypes.DurationProto(event.Duration)
 	pbe.Duration = pbd
-	pbn := ptypes.DurationProto(event.TimeNotify)
-	pbe.Timenotify = pbn
+	for _, r := range event.Reminders {
+		pbe.Reminders = append(pbe.Reminders, &api.Reminder{Offset: ptypes.DurationProto(r.Offset), Channel: string(r.Channel)})
+	}
+	if len(event.Reminders) > 0 {
+		pbe.Timenotify = ptypes.DurationProto(event.Reminders[0].Offset)
+	}
 	return pbe, nil
 }
+
+// fromPBReminders returns reminders in format of use cases, legacy timenotify is used if reminders aren't set.
+func fromPBReminders(timeNotify string, pbReminders []*api.Reminder) (string, error) {
+	if len(pbReminders) == 0 {
+		return timeNotify, nil
+	}
+	reminders := make([]string, 0, len(pbReminders))
+	for _, pbr := range pbReminders {
+		offset, err := ptypes.Duration(pbr.GetOffset())
+		if err != nil {
+			return "", err
+		}
+		r := entities.Reminder{Offset: offset, Channel: entities.Channel(pbr.GetChannel())}
+		reminders = append(reminders, r.String())
+	}
+	return strings.Join(reminders, ","), nil
+}
+func toPBIntervals(intervals []entities.Interval) ([]*api.Interval, error) {
+	pbIntervals := make([]*api.Interval, 0, len(intervals))
+	for _, in := range intervals {
+		start, err := ptypes.TimestampProto(in.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := ptypes.TimestampProto(in.End)
+		if err != nil {
+			return nil, err
+		}
+		pbIntervals = append(pbIntervals, &api.Interval{Start: start, End: end})
+	}
+	return pbIntervals, nil
+}
+
+func toPBCalendars(calendars []*entities.Calendar) []*api.Calendar {
+	pbCalendars := make([]*api.Calendar, 0, len(calendars))
+	for _, calendar := range calendars {
+		pbc := &api.Calendar{Id: calendar.ID, Title: calendar.Title}
+		for _, entry := range calendar.ACL {
+			pbc.Acl = append(pbc.Acl, &api.ACLEntry{Userid: entry.UserID, Role: string(entry.Role)})
+		}
+		pbCalendars = append(pbCalendars, pbc)
+	}
+	return pbCalendars
+}
+
 func injectHeadersIntoMetadata(ctx context.Context, req *http.Request) metadata.MD {
 	pairs := make([]string, 0, len(headers))
 	for _, h := range headers {
@@ -3,21 +3,30 @@ package grpcserver
 import (
 	"context"
 	"net"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/gogo/protobuf/types"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 
 	api "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/api/grpcapi"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/changefeed"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/auth"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
 )
 
@@ -54,28 +63,31 @@ func TestGRPCServer(t *testing.T) {
 		Nanos:   ptypesDu.Nanos,
 	}
 
-	ptypesTn := types.DurationProto(testEvent.TimeNotify)
+	ptypesTn := types.DurationProto(testEvent.Reminders[0].Offset)
 	pbTn := &duration.Duration{
 		Seconds: ptypesTn.Seconds,
 		Nanos:   ptypesTn.Nanos,
 	}
 
 	testPBEvent := &api.Event{
-		Id:         testEvent.ID,
-		Title:      testEvent.Title,
-		Datetime:   pbTs,
-		Duration:   pbDu,
-		Text:       testEvent.Text,
-		Userid:     testEvent.UserID,
-		Timenotify: pbTn,
+		Id:            testEvent.ID,
+		Title:         testEvent.Title,
+		Datetime:      pbTs,
+		Duration:      pbDu,
+		Text:          testEvent.Text,
+		Userid:        testEvent.UserID,
+		Timenotify:    pbTn,
+		Reminders:     []*api.Reminder{{Offset: pbTn}},
+		Timezone:      testEvent.TimeZone,
+		LocalDatetime: testEvent.DateTime.Format(time.RFC3339),
 	}
 	testPBEvents := &api.Events{Event: []*api.Event{testPBEvent}}
 
 	repo := mocks.NewMockRepo(testEvent)
 	logger := mocks.NewMockLogger()
-	calendar := usecases.NewCalendar(repo, nil, logger)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
 
-	server := NewGRPCServer(wg, logger, calendar)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
 	listener := bufconn.Listen(buffer)
 
 	ctx := context.Background()
@@ -127,6 +139,21 @@ func TestGRPCServer(t *testing.T) {
 		require.NotNil(t, err)
 		require.Contains(t, err.Error(), codes.Aborted.String())
 	})
+	t.Run("add: later occurrence overlaps event", func(t *testing.T) {
+		// the second occurrence starts inside of the test event, repository checks only the first one.
+		req := &api.AddEventRequest{Title: title, Text: text, Datetime: "2020-07-06 13:00:00", Duration: "1h", Rrule: "FREQ=DAILY"}
+		//auth
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user-id", userid))
+
+		r, err := client.AddEvent(ctx, req)
+
+		require.Nil(t, r)
+		st := status.Convert(err)
+		require.Equal(t, codes.AlreadyExists, st.Code())
+		details, ok := st.Details()[0].(*api.DateBusyDetails)
+		require.True(t, ok)
+		require.Equal(t, []string{id}, details.GetConflicts())
+	})
 
 	t.Run("delete: ok", func(t *testing.T) {
 		req := &api.DeleteEventRequest{Id: id}
@@ -212,6 +239,33 @@ func TestGRPCServer(t *testing.T) {
 		require.Nil(t, err)
 		require.EqualValues(t, expresp.GetEvents(), r.GetEvents())
 	})
+	t.Run("getdate: requester time zone", func(t *testing.T) {
+		//auth
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user-id", userid, "x-timezone", "Asia/Tokyo"))
+
+		r, err := client.GetDateEvent(ctx, &api.GetDateEventRequest{Date: date})
+
+		require.Nil(t, err)
+		require.Len(t, r.GetEvents().GetEvent(), 1)
+		require.Equal(t, "2020-07-07T21:12:12+09:00", r.GetEvents().GetEvent()[0].GetLocalDatetime())
+		require.Equal(t, testPBEvent.GetDatetime().GetSeconds(), r.GetEvents().GetEvent()[0].GetDatetime().GetSeconds())
+
+		// the next local day in Tokyo starts at 2020-07-07 15:00 UTC.
+		r, err = client.GetDateEvent(ctx, &api.GetDateEventRequest{Date: "2020-07-08"})
+
+		require.Nil(t, err)
+		require.Empty(t, r.GetEvents().GetEvent())
+	})
+	t.Run("getdate: bad time zone", func(t *testing.T) {
+		//auth
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user-id", userid, "x-timezone", "Mars/Olympus"))
+
+		r, err := client.GetDateEvent(ctx, &api.GetDateEventRequest{Date: date})
+
+		require.Nil(t, r)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), codes.InvalidArgument.String())
+	})
 	t.Run("getdate: unauthorized add", func(t *testing.T) {
 		req := &api.GetDateEventRequest{Date: date}
 
@@ -303,6 +357,610 @@ func TestGRPCServer(t *testing.T) {
 		require.Contains(t, err.Error(), codes.Aborted.String())
 	})
 
+	t.Run("export: ok", func(t *testing.T) {
+		req := &api.ExportEventsRequest{Start: "2020-07-01", End: "2020-08-01"}
+		//auth
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user-id", userid))
+
+		r, err := client.ExportEvents(ctx, req)
+
+		require.Nil(t, err)
+		require.Contains(t, r.GetCalendar(), "BEGIN:VCALENDAR")
+		require.Contains(t, r.GetCalendar(), "UID:"+id)
+		require.Contains(t, r.GetCalendar(), "SUMMARY:"+title)
+	})
+	t.Run("export: bad no End field", func(t *testing.T) {
+		req := &api.ExportEventsRequest{Start: "2020-07-01"}
+		//auth
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-user-id", userid))
+
+		r, err := client.ExportEvents(ctx, req)
+
+		require.Nil(t, r)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), codes.Aborted.String())
+	})
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerDateBusy(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	server := NewGRPCServer(wg, logger, usecases.NewCalendar(repo, nil, nil, 0, logger), auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	//auth
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	first, err := client.AddEvent(ctx, &api.AddEventRequest{Title: title, Text: text, Datetime: dt, Duration: dur})
+	require.Nil(t, err)
+
+	// the event starts inside of the first one.
+	r, err := client.AddEvent(ctx, &api.AddEventRequest{Title: title, Text: text, Datetime: "2020-07-07 13:00:00", Duration: "1h"})
+
+	require.Nil(t, r)
+	st := status.Convert(err)
+	require.Equal(t, codes.AlreadyExists, st.Code())
+	require.Len(t, st.Details(), 1)
+	details, ok := st.Details()[0].(*api.DateBusyDetails)
+	require.True(t, ok)
+	require.Equal(t, []string{first.GetId()}, details.GetConflicts())
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerIdempotency(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	server := NewGRPCServer(wg, logger, usecases.NewCalendar(repo, nil, nil, 0, logger), auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	//auth
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid, "idempotency-key", "add-1"))
+	req := &api.AddEventRequest{Title: title, Text: text, Datetime: dt, Duration: dur}
+	first, err := client.AddEvent(ctx, req)
+	require.Nil(t, err)
+
+	// the replay doesn't make the second event which would overlap the first one.
+	replay, err := client.AddEvent(ctx, req)
+	require.Nil(t, err)
+	require.Equal(t, first.GetId(), replay.GetId())
+
+	_, err = client.AddEvent(ctx, &api.AddEventRequest{Title: "another", Text: text, Datetime: dt, Duration: dur})
+	require.Equal(t, codes.Aborted, status.Code(err), "key is reused with another payload")
+
+	updateCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid, "idempotency-key", "update-1"))
+	update := &api.UpdateEventRequest{Id: first.GetId(), Title: "renamed", Text: text, Datetime: dt, Duration: dur}
+	updated, err := client.UpdateEvent(updateCtx, update)
+	require.Nil(t, err)
+	updated, err = client.UpdateEvent(updateCtx, update)
+	require.Nil(t, err)
+	require.Equal(t, first.GetId(), updated.GetId())
+
+	longCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid, "idempotency-key", strings.Repeat("k", 256)))
+	_, err = client.AddEvent(longCtx, req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	server.StopServe()
+	wg.Wait()
+}
+
+// unsavedResponses is repository which fails to save responses of idempotency keys.
+type unsavedResponses struct {
+	*inmemory.EventRepo
+}
+
+func (r unsavedResponses) CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) error {
+	return errors.New("database is unavailable")
+}
+
+func TestGRPCServerIdempotencyNotCompleted(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	server := NewGRPCServer(wg, logger, usecases.NewCalendar(unsavedResponses{repo}, nil, nil, 0, logger), auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	//auth
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid, "idempotency-key", "add-1"))
+	req := &api.AddEventRequest{Title: title, Text: text, Datetime: dt, Duration: dur}
+	_, err = client.AddEvent(ctx, req)
+	require.NotNil(t, err, "unsaved response is error")
+
+	// the key stays reserved, so retry doesn't add the event again.
+	_, err = client.AddEvent(ctx, req)
+	require.Equal(t, codes.Aborted, status.Code(err))
+	require.Contains(t, err.Error(), entities.ErrIdempotencyPending.Error())
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerVersion(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	server := NewGRPCServer(wg, logger, usecases.NewCalendar(repo, nil, nil, 0, logger), auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	//auth
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	added, err := client.AddEvent(ctx, &api.AddEventRequest{Title: title, Text: text, Datetime: dt, Duration: dur})
+	require.Nil(t, err)
+	require.Equal(t, int64(1), added.GetVersion())
+
+	update := &api.UpdateEventRequest{Id: added.GetId(), Title: "renamed", Text: text, Datetime: dt, Duration: dur, ExpectedVersion: added.GetVersion()}
+	updated, err := client.UpdateEvent(ctx, update)
+	require.Nil(t, err)
+	require.Equal(t, int64(2), updated.GetVersion())
+
+	_, err = client.UpdateEvent(ctx, update)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err), "stale update is rejected")
+	_, err = client.DeleteEvent(ctx, &api.DeleteEventRequest{Id: added.GetId(), ExpectedVersion: added.GetVersion()})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err), "stale delete is rejected")
+
+	got, err := client.GetDateEvent(ctx, &api.GetDateEventRequest{Date: date})
+	require.Nil(t, err)
+	require.Len(t, got.GetEvents().GetEvent(), 1)
+	require.Equal(t, int64(2), got.GetEvents().GetEvent()[0].GetVersion())
+	_, err = client.DeleteEvent(ctx, &api.DeleteEventRequest{Id: added.GetId(), ExpectedVersion: updated.GetVersion()})
+	require.Nil(t, err)
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerFreeBusy(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	other := "11112233-4455-6677-8899-aabbccddeeff"
+	_, err = calendar.MakeEvent(context.Background(), title, "2020-07-07 08:00:00", text, userid, "3h", "", "", "", "", "", "")
+	require.Nil(t, err)
+	_, err = calendar.MakeEvent(context.Background(), title, "2020-07-07 11:30:00", text, other, "1h", "", "", "", "", "", "")
+	require.Nil(t, err)
+
+	//auth
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	r, err := client.FreeBusy(ctx, &api.FreeBusyRequest{
+		Users: []*api.FreeBusyUser{
+			{Userid: userid},
+			// working hours of the user in Moscow are 06:00-15:00 UTC.
+			{Userid: other, WorkingHours: "09:00-18:00", Timezone: "Europe/Moscow"},
+		},
+		Start:    "2020-07-07 00:00:00",
+		End:      "2020-07-08 00:00:00",
+		Duration: "1h",
+		Slots:    3,
+	})
+
+	require.Nil(t, err)
+	require.Len(t, r.GetUsers(), 2)
+	require.Len(t, r.GetUsers()[0].GetBusy(), 1)
+	require.Equal(t, other, r.GetUsers()[1].GetUserid())
+	startsAt := make([]string, 0, len(r.GetSlots()))
+	for _, slot := range r.GetSlots() {
+		startsAt = append(startsAt, time.Unix(slot.GetStart().GetSeconds(), 0).UTC().Format(entities.LayoutISO))
+	}
+	require.Equal(t, []string{"2020-07-07 06:00:00", "2020-07-07 07:00:00", "2020-07-07 12:30:00"}, startsAt)
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerInvitations(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	guest := "11112233-4455-6677-8899-aabbccddeeff"
+	id, err := calendar.MakeEvent(context.Background(), title, "2020-07-07 08:00:00", text, userid, "1h", "", "", "", "", "", "")
+	require.Nil(t, err)
+
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	guestCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", guest))
+
+	_, err = client.InviteAttendees(guestCtx, &api.InviteAttendeesRequest{Id: id, Attendees: []string{userid}})
+	require.NotNil(t, err, "only owner can invite")
+
+	_, err = client.InviteAttendees(ownerCtx, &api.InviteAttendeesRequest{Id: id, Attendees: []string{guest}})
+	require.Nil(t, err)
+
+	r, err := client.GetInvitations(guestCtx, &api.GetInvitationsRequest{Status: string(entities.NeedsAction)})
+	require.Nil(t, err)
+	require.Len(t, r.GetEvents().GetEvent(), 1)
+
+	_, err = client.RespondInvitation(guestCtx, &api.RespondInvitationRequest{Id: id, Status: "maybe"})
+	require.NotNil(t, err)
+	_, err = client.RespondInvitation(guestCtx, &api.RespondInvitationRequest{Id: id, Status: string(entities.Accepted)})
+	require.Nil(t, err)
+
+	day, err := client.GetDateEvent(guestCtx, &api.GetDateEventRequest{Date: "2020-07-07"})
+	require.Nil(t, err)
+	require.Len(t, day.GetEvents().GetEvent(), 1)
+	require.Equal(t, []*api.Attendee{{Userid: guest, Status: string(entities.Accepted)}}, day.GetEvents().GetEvent()[0].GetAttendees())
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerCalendars(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	editor := "11112233-4455-6677-8899-aabbccddeeff"
+	viewer := "22112233-4455-6677-8899-aabbccddeeff"
+	freebusy := "33112233-4455-6677-8899-aabbccddeeff"
+	ownerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	editorCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", editor))
+	viewerCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", viewer))
+	freebusyCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", freebusy))
+
+	created, err := client.AddCalendar(ownerCtx, &api.AddCalendarRequest{Title: "team"})
+	require.Nil(t, err)
+	calendarID := created.GetId()
+	for user, role := range map[string]entities.Role{editor: entities.RoleEditor, viewer: entities.RoleViewer, freebusy: entities.RoleFreeBusy} {
+		_, err = client.ShareCalendar(ownerCtx, &api.ShareCalendarRequest{Id: calendarID, Userid: user, Role: string(role)})
+		require.Nil(t, err)
+	}
+	_, err = client.ShareCalendar(editorCtx, &api.ShareCalendarRequest{Id: calendarID, Userid: editor, Role: string(entities.RoleOwner)})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "only owner can share calendar")
+
+	event, err := client.AddEvent(editorCtx, &api.AddEventRequest{Title: title, Datetime: "2020-07-07 08:00:00", Duration: "1h", Text: text, CalendarId: calendarID})
+	require.Nil(t, err)
+	_, err = client.AddEvent(viewerCtx, &api.AddEventRequest{Title: title, Datetime: "2020-07-07 10:00:00", Duration: "1h", Text: text, CalendarId: calendarID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "viewer can't add events")
+	_, err = client.UpdateEvent(viewerCtx, &api.UpdateEventRequest{Id: event.GetId(), Title: "new title", Datetime: "2020-07-07 08:00:00", Duration: "1h"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "viewer can't update events")
+
+	r, err := client.GetCalendarEvents(viewerCtx, &api.GetCalendarEventsRequest{Id: calendarID, Start: "2020-07-07", End: "2020-07-08"})
+	require.Nil(t, err)
+	require.Len(t, r.GetEvents().GetEvent(), 1)
+	require.Equal(t, title, r.GetEvents().GetEvent()[0].GetTitle())
+
+	r, err = client.GetCalendarEvents(freebusyCtx, &api.GetCalendarEventsRequest{Id: calendarID, Start: "2020-07-07", End: "2020-07-08"})
+	require.Nil(t, err)
+	require.Len(t, r.GetEvents().GetEvent(), 1)
+	require.Equal(t, "busy", r.GetEvents().GetEvent()[0].GetTitle())
+	require.Empty(t, r.GetEvents().GetEvent()[0].GetText())
+
+	_, err = client.UnshareCalendar(ownerCtx, &api.UnshareCalendarRequest{Id: calendarID, Userid: userid})
+	require.NotNil(t, err, "the last owner can't leave calendar")
+	_, err = client.UnshareCalendar(ownerCtx, &api.UnshareCalendarRequest{Id: calendarID, Userid: viewer})
+	require.Nil(t, err)
+	_, err = client.GetCalendarEvents(viewerCtx, &api.GetCalendarEventsRequest{Id: calendarID, Start: "2020-07-07", End: "2020-07-08"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerListEvents(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	for _, dt := range []string{"2020-07-07 08:00:00", "2020-07-07 10:00:00", "2020-07-08 08:00:00", "2020-07-09 08:00:00"} {
+		_, err := calendar.MakeEvent(context.Background(), title, dt, text, userid, "1h", "", "", "", "", "", "")
+		require.Nil(t, err)
+	}
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+
+	req := &api.ListEventsRequest{Start: "2020-07-07", End: "2020-07-09", Sort: "-datetime", PageSize: 2}
+	first, err := client.ListEvents(ctx, req)
+	require.Nil(t, err)
+	require.Len(t, first.GetEvents().GetEvent(), 2)
+	require.NotEmpty(t, first.GetNextCursor())
+
+	req.Cursor = first.GetNextCursor()
+	second, err := client.ListEvents(ctx, req)
+	require.Nil(t, err)
+	require.Len(t, second.GetEvents().GetEvent(), 1)
+	require.Empty(t, second.GetNextCursor())
+
+	req.Sort = "title"
+	_, err = client.ListEvents(ctx, req)
+	require.Equal(t, codes.Aborted, status.Code(err), "cursor of another sort order")
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerSearchEvents(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	retro, err := calendar.MakeEvent(context.Background(), "Sprint retro", "2020-03-10 10:00:00", "march retrospective", userid, "1h", "", "", "", "", "", "")
+	require.Nil(t, err)
+	_, err = calendar.MakeEvent(context.Background(), "Planning", "2020-03-11 10:00:00", "sprint planning", userid, "1h", "", "", "", "", "", "")
+	require.Nil(t, err)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+
+	r, err := client.SearchEvents(ctx, &api.SearchEventsRequest{Query: "that retro from March"})
+	require.Nil(t, err)
+	require.Len(t, r.GetResults(), 1)
+	require.Equal(t, retro, r.GetResults()[0].GetEvent().GetId())
+	require.Greater(t, r.GetResults()[0].GetRank(), 0.0)
+
+	r, err = client.SearchEvents(ctx, &api.SearchEventsRequest{Query: "sprint", Limit: 1})
+	require.Nil(t, err)
+	require.Len(t, r.GetResults(), 1)
+	require.Equal(t, retro, r.GetResults()[0].GetEvent().GetId(), "title match goes first")
+
+	_, err = client.SearchEvents(ctx, &api.SearchEventsRequest{Query: " ? "})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerWatchEvents(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	feed := changefeed.NewFeed(0, 0)
+	calendar := usecases.NewCalendar(repo, nil, feed, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	_, err = calendar.MakeEvent(context.Background(), title, dt, text, userid, dur, "", "", "", "", "", "")
+	require.Nil(t, err)
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid)))
+	// resuming after the first change doesn't depend on the moment of subscription.
+	stream, err := client.WatchEvents(ctx, &api.WatchEventsRequest{AfterRevision: 1})
+	require.Nil(t, err)
+	created, err := calendar.MakeEvent(context.Background(), title, "2020-07-08 12:12:12", text, userid, dur, "", "", "", "", "", "")
+	require.Nil(t, err)
+	change, err := stream.Recv()
+	require.Nil(t, err)
+	require.Equal(t, uint64(2), change.GetRevision())
+	require.Equal(t, string(entities.ChangeCreated), change.GetKind())
+	require.Equal(t, created, change.GetEvent().GetId())
+	revision := change.GetRevision()
+
+	_, err = calendar.DeleteEvent(context.Background(), userid, created, "")
+	require.Nil(t, err)
+	change, err = stream.Recv()
+	require.Nil(t, err)
+	require.Equal(t, string(entities.ChangeDeleted), change.GetKind())
+	cancel()
+
+	resumed, err := client.WatchEvents(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid)), &api.WatchEventsRequest{AfterRevision: revision})
+	require.Nil(t, err)
+	change, err = resumed.Recv()
+	require.Nil(t, err)
+	require.Equal(t, revision+1, change.GetRevision())
+	require.Equal(t, string(entities.ChangeDeleted), change.GetKind())
+
+	gone, err := client.WatchEvents(metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid)), &api.WatchEventsRequest{AfterRevision: revision + 100})
+	require.Nil(t, err)
+	_, err = gone.Recv()
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+
+	feed.Close()
+	_, err = resumed.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerReminders(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
+	server := NewGRPCServer(wg, logger, calendar, auth.NewHeaderAuthenticator(), nil)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := api.NewCalendarServiceClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-user-id", userid))
+	_, err = client.AddEvent(ctx, &api.AddEventRequest{Title: title, Text: text, Datetime: "2020-07-07 10:00:00", Duration: "1h", Timenotify: "5m",
+		Reminders: []*api.Reminder{{Offset: &duration.Duration{Seconds: 600}}, {Offset: &duration.Duration{Seconds: 3600}, Channel: "email"}}})
+	require.Nil(t, err)
+
+	r, err := client.GetDateEvent(ctx, &api.GetDateEventRequest{Date: "2020-07-07"})
+	require.Nil(t, err)
+	require.Len(t, r.GetEvents().GetEvent(), 1)
+	event := r.GetEvents().GetEvent()[0]
+	require.Equal(t, int64(time.Hour.Seconds()), event.GetTimenotify().GetSeconds(), "timenotify is the earliest reminder")
+	require.Len(t, event.GetReminders(), 2)
+	require.Equal(t, "email", event.GetReminders()[0].GetChannel())
+	require.Equal(t, int64((10 * time.Minute).Seconds()), event.GetReminders()[1].GetOffset().GetSeconds())
+
+	_, err = client.AddEvent(ctx, &api.AddEventRequest{Title: title, Text: text, Datetime: "2020-07-08 10:00:00", Duration: "1h",
+		Reminders: []*api.Reminder{{Offset: &duration.Duration{Seconds: 3600}, Channel: "pigeon"}}})
+	require.NotNil(t, err, "unknown channel")
+
+	server.StopServe()
+	wg.Wait()
+}
+
+func TestGRPCServerHealth(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	checks := health.NewHealth(logger)
+	var dbErr error
+	checks.AddReadiness("postgres", health.CheckerFunc(func(ctx context.Context) error { return dbErr }))
+	server := NewGRPCServer(wg, logger, usecases.NewCalendar(repo, nil, nil, 0, logger), auth.NewHeaderAuthenticator(), checks)
+	listener := bufconn.Listen(buffer)
+	defer listener.Close()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithContextDialer(func(ctx context.Context, s string) (conn net.Conn, err error) {
+		return listener.Dial()
+	}), grpc.WithInsecure())
+	require.Nil(t, err)
+	client := healthpb.NewHealthClient(conn)
+
+	wg.Add(1)
+	go server.Serve(listener)
+
+	// probes don't authenticate
+	ctx := context.Background()
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.Nil(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	dbErr = errors.New("connection refused")
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "CalendarService"})
+	require.Nil(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.Watch(watchCtx, &healthpb.HealthCheckRequest{})
+	require.Nil(t, err)
+	resp, err = stream.Recv()
+	require.Nil(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus(), "watch sends status at once")
+	cancel()
+
 	server.StopServe()
 	wg.Wait()
 }
@@ -0,0 +1,68 @@
+package grpcserver
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// metadataCarrier adapts grpc metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (cs *GRPCServer) tracingUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	newCtx, span := startServerSpan(ctx, info.FullMethod)
+	defer func() { endServerSpan(span, err) }()
+	return handler(newCtx, req)
+}
+
+func (cs *GRPCServer) tracingStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	newCtx, span := startServerSpan(stream.Context(), info.FullMethod)
+	defer func() { endServerSpan(span, err) }()
+	return handler(srv, &grpc_middleware.WrappedServerStream{ServerStream: stream, WrappedContext: newCtx})
+}
+
+// startServerSpan continues trace from incoming metadata, span is named by full rpc method.
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Extract(ctx, metadataCarrier(md))
+	return tracing.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemKey.String("grpc"), semconv.RPCMethodKey.String(method)))
+}
+
+func endServerSpan(span trace.Span, err error) {
+	s, _ := status.FromError(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", s.Code().String()))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+	}
+	span.End()
+}
@@ -2,18 +2,30 @@
 package httpserver
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	api "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/api/httpapi"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/data/controllers/icalendar"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/domain/usecases"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
 )
 
 const (
 	ErrParseParams = "can't parse request params"
+	maxImportSize  = 10 << 20
+	// watchPing is period of comments keeping idle stream of changes alive through proxies.
+	watchPing = 15 * time.Second
 )
 
 var _ api.Handler = (*APIHandler)(nil)
@@ -82,14 +94,22 @@ func (handler APIHandler) AddEvent(res http.ResponseWriter, req *http.Request) {
 	text := handler.getParam(req, "text")
 	datetime := handler.getParam(req, "datetime")
 	duration := handler.getParam(req, "duration")
-	timetonotify := handler.getParam(req, "timetonotify")
+	reminders := handler.getReminders(req)
+	rrule := handler.getParam(req, "rrule")
+	exdate := handler.getParam(req, "exdate")
+	timezone := handler.getParam(req, "timezone")
+	if timezone == "" {
+		timezone = util.GetTimeZone(ctx)
+	}
+	calendarid := handler.getParam(req, "calendar")
 
-	id, err := handler.calendar.MakeEvent(ctx, title, datetime, text, userid, duration, timetonotify)
+	id, err := handler.calendar.MakeEvent(ctx, title, datetime, text, userid, duration, reminders, rrule, exdate, timezone, calendarid, req.Header.Get(util.IdempotencyHeaderKey))
 	if err != nil {
-		code = http.StatusBadRequest
+		code = handler.errorCode(err)
 		resp.Error = handler.error(ctx, err)
 		return
 	}
+	res.Header().Set("ETag", etag(entities.InitialVersion))
 	resp.ID = id
 }
 
@@ -113,7 +133,7 @@ func (handler APIHandler) GetDateEvents(res http.ResponseWriter, req *http.Reque
 
 	date := handler.getParam(req, "date")
 
-	events, err := handler.calendar.GetDateEvents(ctx, date, userid)
+	events, err := handler.calendar.GetDateEvents(ctx, date, userid, util.GetTimeZone(ctx))
 	if err != nil {
 		code = http.StatusBadRequest
 		resp.Error = handler.error(ctx, err)
@@ -141,7 +161,7 @@ func (handler APIHandler) GetWeekEvents(res http.ResponseWriter, req *http.Reque
 	}
 	date := handler.getParam(req, "date")
 
-	events, err := handler.calendar.GetWeekEvents(ctx, date, userid)
+	events, err := handler.calendar.GetWeekEvents(ctx, date, userid, util.GetTimeZone(ctx))
 	if err != nil {
 		code = http.StatusBadRequest
 		resp.Error = handler.error(ctx, err)
@@ -170,7 +190,7 @@ func (handler APIHandler) GetMonthEvents(res http.ResponseWriter, req *http.Requ
 
 	date := handler.getParam(req, "date")
 
-	events, err := handler.calendar.GetMonthEvents(ctx, date, userid)
+	events, err := handler.calendar.GetMonthEvents(ctx, date, userid, util.GetTimeZone(ctx))
 	if err != nil {
 		code = http.StatusBadRequest
 		resp.Error = handler.error(ctx, err)
@@ -202,14 +222,25 @@ func (handler APIHandler) UpdateEvent(res http.ResponseWriter, req *http.Request
 	text := handler.getParam(req, "text")
 	datetime := handler.getParam(req, "datetime")
 	duration := handler.getParam(req, "duration")
-	timetonotify := handler.getParam(req, "timetonotify")
+	reminders := handler.getReminders(req)
+	rrule := handler.getParam(req, "rrule")
+	exdate := handler.getParam(req, "exdate")
+	timezone := handler.getParam(req, "timezone")
 
-	id, err := handler.calendar.UpdateEvent(ctx, userid, eventid, title, datetime, text, duration, timetonotify)
+	expectedVersion, err := ifMatch(req)
 	if err != nil {
-		code = http.StatusBadRequest
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+
+	id, version, err := handler.calendar.UpdateEvent(ctx, userid, eventid, title, datetime, text, duration, reminders, rrule, exdate, timezone, expectedVersion, req.Header.Get(util.IdempotencyHeaderKey))
+	if err != nil {
+		code = handler.errorCode(err)
 		resp.Error = handler.error(ctx, err)
 		return
 	}
+	res.Header().Set("ETag", etag(version))
 	resp.ID = id
 }
 
@@ -233,18 +264,611 @@ func (handler APIHandler) DeleteEvent(res http.ResponseWriter, req *http.Request
 
 	eventid := handler.getParam(req, "id")
 
-	id, err := handler.calendar.DeleteEvent(ctx, userid, eventid)
+	expectedVersion, err := ifMatch(req)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+
+	id, err := handler.calendar.DeleteEvent(ctx, userid, eventid, expectedVersion)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) ExportEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		handler.sendResponse(api.NewGetResponse(), http.StatusBadRequest, res)
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	start := handler.getParam(req, "start")
+	end := handler.getParam(req, "end")
+
+	events, err := handler.calendar.ExportEvents(ctx, start, end, userid, util.GetTimeZone(ctx))
+	if err != nil {
+		resp := api.NewGetResponse()
+		resp.Error = handler.error(ctx, err)
+		handler.sendResponse(resp, http.StatusBadRequest, res)
+		return
+	}
+
+	ics := &bytes.Buffer{}
+	if err := icalendar.Encode(ics, events); err != nil {
+		resp := api.NewGetResponse()
+		resp.Error = handler.error(ctx, err)
+		handler.sendResponse(resp, http.StatusInternalServerError, res)
+		return
+	}
+	res.Header().Set("Content-Type", icalendar.ContentType)
+	res.Header().Set("Content-Disposition", `attachment; filename="events.ics"`)
+	res.WriteHeader(http.StatusOK)
+	res.Write(ics.Bytes())
+}
+
+// ImportEvents accepts .ics file in multipart form field "file" or as request body.
+func (handler APIHandler) ImportEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewImportResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+
+	var ics io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := req.FormFile("file")
+		if err != nil {
+			code = http.StatusBadRequest
+			resp.Error = handler.info(ctx, ErrParseParams+": %v", err)
+			return
+		}
+		defer file.Close()
+		ics = file
+	}
+
+	//events are imported to shared calendar from query param or to personal one
+	calendarid := req.URL.Query().Get("calendar")
+
+	vevents, err := icalendar.Decode(io.LimitReader(ics, maxImportSize))
+	if err != nil {
+		code = http.StatusBadRequest
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	for _, ve := range vevents {
+		result := api.ImportResult{UID: ve.UID}
+		err := ve.Err
+		if err == nil {
+			result.ID, err = handler.calendar.MakeEvent(ctx, ve.Title, ve.DateTime, ve.Text, userid, ve.Duration, ve.Reminders, ve.RRule, ve.ExDate, ve.TimeZone, calendarid, "")
+		}
+		if err != nil {
+			result.Error = handler.error(ctx, err)
+			resp.Failed++
+		} else {
+			resp.Imported++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+}
+
+// FreeBusy accepts users in repeated "user" param in format id[,09:00-18:00[,time zone]].
+func (handler APIHandler) FreeBusy(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	resp := api.NewFreeBusyResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	start := handler.getParam(req, "start")
+	end := handler.getParam(req, "end")
+	duration := handler.getParam(req, "duration")
+	slots := handler.getParam(req, "slots")
+	users := make([]usecases.FreeBusyUser, 0, len(req.Form["user"]))
+	for _, param := range req.Form["user"] {
+		parts := strings.SplitN(param, ",", 3)
+		user := usecases.FreeBusyUser{UserID: parts[0]}
+		if len(parts) > 1 {
+			user.WorkingHours = parts[1]
+		}
+		if len(parts) > 2 {
+			user.TimeZone = parts[2]
+		}
+		users = append(users, user)
+	}
+
+	freeBusy, err := handler.calendar.FreeBusy(ctx, start, end, duration, slots, util.GetTimeZone(ctx), users)
+	if err != nil {
+		code = http.StatusBadRequest
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Users = freeBusy.Users
+	resp.Slots = freeBusy.Slots
+}
+
+// InviteAttendees accepts invited users in repeated or comma separated "attendee" param.
+func (handler APIHandler) InviteAttendees(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewInvitationResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	eventid := handler.getParam(req, "id")
+	var attendees []string
+	for _, param := range req.Form["attendee"] {
+		attendees = append(attendees, strings.Split(param, ",")...)
+	}
+
+	id, err := handler.calendar.InviteAttendees(ctx, userid, eventid, attendees)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) RespondInvitation(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewInvitationResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	eventid := handler.getParam(req, "id")
+	status := handler.getParam(req, "status")
+
+	id, err := handler.calendar.RespondInvitation(ctx, userid, eventid, status)
+	if err != nil {
+		code = http.StatusBadRequest
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) GetInvitations(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewGetResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	status := handler.getParam(req, "status")
+
+	events, err := handler.calendar.GetInvitations(ctx, userid, status, util.GetTimeZone(ctx))
+	if err != nil {
+		code = http.StatusBadRequest
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Events = events
+}
+
+func (handler APIHandler) AddCalendar(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewCalendarResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	title := handler.getParam(req, "title")
+
+	id, err := handler.calendar.MakeCalendar(ctx, userid, title)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) GetCalendars(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewCalendarsResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+
+	calendars, err := handler.calendar.GetCalendars(ctx, userid)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Calendars = calendars
+}
+
+// ShareCalendar gives role to the user, role is one of owner, editor, viewer, freebusy.
+func (handler APIHandler) ShareCalendar(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewCalendarResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	calendarid := handler.getParam(req, "id")
+	member := handler.getParam(req, "user")
+	role := handler.getParam(req, "role")
+
+	id, err := handler.calendar.ShareCalendar(ctx, userid, calendarid, member, role)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) UnshareCalendar(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewCalendarResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	calendarid := handler.getParam(req, "id")
+	member := handler.getParam(req, "user")
+
+	id, err := handler.calendar.UnshareCalendar(ctx, userid, calendarid, member)
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.ID = id
+}
+
+func (handler APIHandler) DeleteCalendar(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewCalendarResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
 	if err != nil {
 		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	calendarid := handler.getParam(req, "id")
+
+	id, err := handler.calendar.DeleteCalendar(ctx, userid, calendarid)
+	if err != nil {
+		code = handler.errorCode(err)
 		resp.Error = handler.error(ctx, err)
 		return
 	}
 	resp.ID = id
 }
 
+func (handler APIHandler) GetCalendarEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewGetResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	calendarid := handler.getParam(req, "id")
+	start := handler.getParam(req, "start")
+	end := handler.getParam(req, "end")
+
+	events, err := handler.calendar.GetCalendarEvents(ctx, userid, calendarid, start, end, util.GetTimeZone(ctx))
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Events = events
+}
+
+// ListEvents lists events page by page, "cursor" param is next_cursor of the previous page.
+// Recurring event is listed once as the whole series.
+func (handler APIHandler) ListEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewListResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	start := handler.getParam(req, "start")
+	end := handler.getParam(req, "end")
+	search := handler.getParam(req, "search")
+	sort := handler.getParam(req, "sort")
+	limit := handler.getParam(req, "limit")
+	cursor := handler.getParam(req, "cursor")
+
+	page, err := handler.calendar.ListEvents(ctx, userid, start, end, search, sort, limit, cursor, util.GetTimeZone(ctx))
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Events = page.Events
+	resp.NextCursor = page.Next
+}
+
+// SearchEvents finds events by words of "q" param.
+func (handler APIHandler) SearchEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewSearchResponse()
+	code := http.StatusOK
+	// defer marshal answer and return response with code.
+	defer func() {
+		handler.sendResponse(resp, code, res)
+	}()
+	// parse all request params.
+	err := req.ParseForm()
+	if err != nil {
+		code = http.StatusBadRequest
+		handler.info(ctx, ErrParseParams)
+		return
+	}
+	query := handler.getParam(req, "q")
+	limit := handler.getParam(req, "limit")
+
+	hits, err := handler.calendar.SearchEvents(ctx, userid, query, limit, util.GetTimeZone(ctx))
+	if err != nil {
+		code = handler.errorCode(err)
+		resp.Error = handler.error(ctx, err)
+		return
+	}
+	resp.Results = hits
+}
+
+// WatchEvents streams changes of events as server-sent events, event id is revision of change.
+// Client resumes from the last revision by "after" param or Last-Event-ID header, 410 means it must reload events.
+func (handler APIHandler) WatchEvents(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	userid := util.GetUserID(ctx)
+
+	resp := api.NewWatchEvent()
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		resp.Error = handler.info(ctx, "streaming is not supported")
+		handler.sendResponse(resp, http.StatusInternalServerError, res)
+		return
+	}
+	err := req.ParseForm()
+	if err != nil {
+		resp.Error = handler.info(ctx, ErrParseParams)
+		handler.sendResponse(resp, http.StatusBadRequest, res)
+		return
+	}
+	revision := handler.getParam(req, "after")
+	if revision == "" {
+		revision = req.Header.Get("Last-Event-ID")
+	}
+
+	sub, err := handler.calendar.WatchEvents(ctx, userid, revision, util.GetTimeZone(ctx))
+	if err != nil {
+		resp.Error = handler.error(ctx, err)
+		handler.sendResponse(resp, handler.errorCode(err), res)
+		return
+	}
+	defer sub.Cancel()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	for _, change := range sub.Backlog {
+		if err := handler.sendChange(res, change); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(watchPing)
+	defer ping.Stop()
+	for {
+		select {
+		case change, ok := <-sub.Changes:
+			if !ok {
+				// client reconnects and resumes from the last event id.
+				return
+			}
+			if err := handler.sendChange(res, change); err != nil {
+				return
+			}
+		case <-ping.C:
+			if _, err := io.WriteString(res, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (handler APIHandler) sendChange(res http.ResponseWriter, change entities.Change) error {
+	data, err := (&api.WatchEvent{Revision: change.Revision, Kind: string(change.Kind), Event: change.Event}).MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", change.Revision, change.Kind, data)
+	return err
+}
+
 func (handler APIHandler) error(ctx context.Context, error error) *api.ErrorResponse {
 	handler.logger.Error(ctx, error)
-	return &api.ErrorResponse{Message: fmt.Sprint(error.Error())}
+	return &api.ErrorResponse{Message: fmt.Sprint(error.Error()), Conflicts: entities.ConflictingEventIDs(error)}
+}
+
+// errorCode returns http status code of use case error.
+func (handler APIHandler) errorCode(err error) int {
+	switch {
+	case errors.Is(err, entities.ErrDateBusy):
+		return http.StatusConflict
+	case errors.Is(err, entities.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, entities.ErrRevisionGone):
+		return http.StatusGone
+	case errors.Is(err, entities.ErrIdempotencyReused), errors.Is(err, entities.ErrIdempotencyPending):
+		return http.StatusConflict
+	case errors.Is(err, entities.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusBadRequest
+}
+
+// etag returns strong entity tag of event version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch returns comma separated expected versions of event from list of entity tags of If-Match header (RFC 7232),
+// empty versions for missing header or * means any version. Weak tags never match by strong comparison, so header
+// with weak tags only fails with ErrVersionConflict.
+func ifMatch(req *http.Request) (string, error) {
+	header := strings.TrimSpace(strings.Join(req.Header.Values("If-Match"), ","))
+	if header == "" || header == "*" {
+		return "", nil
+	}
+	versions := []string{}
+	weak := false
+	for rest := strings.TrimLeft(header, " \t,"); rest != ""; rest = strings.TrimLeft(rest, " \t,") {
+		tag := strings.TrimPrefix(rest, "W/")
+		isWeak := len(tag) < len(rest)
+		if !strings.HasPrefix(tag, `"`) {
+			return "", errors.Wrapf(entities.ErrVersionFormat, "malformed entity tag in If-Match: %v", header)
+		}
+		end := strings.IndexByte(tag[1:], '"')
+		if end < 0 || strings.Contains(tag[1:end+1], ",") {
+			return "", errors.Wrapf(entities.ErrVersionFormat, "malformed entity tag in If-Match: %v", header)
+		}
+		opaque := tag[1 : end+1]
+		rest = strings.TrimLeft(tag[end+2:], " \t")
+		if rest != "" && rest[0] != ',' {
+			return "", errors.Wrapf(entities.ErrVersionFormat, "malformed entity tag in If-Match: %v", header)
+		}
+		if isWeak {
+			weak = true
+			continue
+		}
+		versions = append(versions, opaque)
+	}
+	if len(versions) == 0 && weak {
+		return "", errors.Wrapf(entities.ErrVersionConflict, "weak entity tags don't match: %v", header)
+	}
+	if len(versions) == 0 {
+		return "", errors.Wrapf(entities.ErrVersionFormat, "no entity tags in If-Match: %v", header)
+	}
+	return strings.Join(versions, ","), nil
 }
 
 func (handler APIHandler) info(ctx context.Context, info string, args ...interface{}) *api.ErrorResponse {
@@ -260,6 +884,20 @@ func (handler APIHandler) getParam(req *http.Request, name string) string {
 	return params[0]
 }
 
+// getReminders returns repeated reminder params as list, legacy timetonotify param is used if reminders aren't set.
+func (handler APIHandler) getReminders(req *http.Request) string {
+	reminders := make([]string, 0, len(req.Form["reminder"]))
+	for _, r := range req.Form["reminder"] {
+		if r != "" {
+			reminders = append(reminders, r)
+		}
+	}
+	if len(reminders) == 0 {
+		return handler.getParam(req, "timetonotify")
+	}
+	return strings.Join(reminders, ",")
+}
+
 func (handler APIHandler) sendResponse(resp api.Response, code int, res http.ResponseWriter) {
 	msg, err := resp.MarshalJSON()
 	if err != nil {
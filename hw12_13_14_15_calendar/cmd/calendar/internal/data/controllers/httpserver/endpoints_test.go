@@ -1,6 +1,7 @@
 package httpserver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/require"
@@ -13,6 +14,7 @@ import (
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/api/httpapi"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/internal/domain/usecases"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
 )
@@ -34,7 +36,7 @@ func TestHTTPEndpoints(t *testing.T) {
 	require.Nil(t, err)
 	repo := mocks.NewMockRepo(testEvent)
 	logger := mocks.NewMockLogger()
-	calendar := usecases.NewCalendar(repo, nil, logger)
+	calendar := usecases.NewCalendar(repo, nil, nil, 0, logger)
 	handler := NewAPIHandler(calendar, logger)
 	w := httptest.NewRecorder()
 
@@ -319,3 +321,63 @@ func TestHTTPEndpoints(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTPEventVersion(t *testing.T) {
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	handler := NewAPIHandler(usecases.NewCalendar(repo, nil, nil, 0, logger), logger)
+	ctx := util.SetUserID(context.Background(), userid)
+
+	w := httptest.NewRecorder()
+	handler.AddEvent(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:80/?title=%v&text=%v&duration=%v&datetime=%v", title, text, duration, url.QueryEscape(dt)), nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `"1"`, w.Header().Get("ETag"))
+	added := httpapi.NewAddResponse()
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), added))
+
+	request := func(method, ifMatch string) *http.Request {
+		r := httptest.NewRequest(method, fmt.Sprintf("http://127.0.0.1:80/?id=%v&title=renamed", added.ID), nil).WithContext(ctx)
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		return r
+	}
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, `"1"`))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, `"1"`))
+	require.Equal(t, http.StatusPreconditionFailed, w.Code, "stale update is rejected")
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, `"v2"`))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, "*"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `"3"`, w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, `W/"3"`))
+	require.Equal(t, http.StatusPreconditionFailed, w.Code, "weak tag doesn't match by strong comparison")
+	w = httptest.NewRecorder()
+	handler.UpdateEvent(w, request(http.MethodPatch, `"1", "3"`))
+	require.Equal(t, http.StatusOK, w.Code, "any tag of list matches")
+	require.Equal(t, `"4"`, w.Header().Get("ETag"))
+	for _, malformed := range []string{`"4`, `4`, `"4" "5"`, `"4,5"`, `W/`} {
+		w = httptest.NewRecorder()
+		handler.UpdateEvent(w, request(http.MethodPatch, malformed))
+		require.Equal(t, http.StatusBadRequest, w.Code, malformed)
+	}
+
+	w = httptest.NewRecorder()
+	handler.DeleteEvent(w, request(http.MethodDelete, `"2"`))
+	require.Equal(t, http.StatusPreconditionFailed, w.Code, "stale delete is rejected")
+	w = httptest.NewRecorder()
+	handler.DeleteEvent(w, request(http.MethodDelete, `W/"4", "1"`))
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = httptest.NewRecorder()
+	handler.DeleteEvent(w, request(http.MethodDelete, `"1",W/"2" , "4"`))
+	require.Equal(t, http.StatusOK, w.Code)
+}
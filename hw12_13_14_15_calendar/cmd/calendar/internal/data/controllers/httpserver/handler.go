@@ -6,46 +6,78 @@ import (
 	"strconv"
 	"time"
 
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
 	api "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar/api/httpapi"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/auth"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 // HTTPHandler preparing http.Handler with all middlewares. I extract this method for test whole the server. What another way can I test whole server?
 type HTTPHandler struct {
-	logger     mainusecase.Logger
-	apiHandler api.Handler
+	logger        mainusecase.Logger
+	apiHandler    api.Handler
+	authenticator auth.Authenticator
+	// routes is used to find route of request for metrics.
+	routes *http.ServeMux
 }
 
-func GetHandler(logger mainusecase.Logger, apiHandler api.Handler) http.Handler {
+func GetHandler(logger mainusecase.Logger, apiHandler api.Handler, authenticator auth.Authenticator) http.Handler {
 	h := &HTTPHandler{
-		logger:     logger,
-		apiHandler: apiHandler,
+		logger:        logger,
+		apiHandler:    apiHandler,
+		authenticator: authenticator,
 	}
 	authMux := http.NewServeMux()
+	h.routes = authMux
 	authMux.HandleFunc("/", h.get(h.apiHandler.Index))
 	authMux.HandleFunc("/events/add", h.post(h.apiHandler.AddEvent))
 	authMux.HandleFunc("/events/date", h.get(h.apiHandler.GetDateEvents))
 	authMux.HandleFunc("/events/week", h.get(h.apiHandler.GetWeekEvents))
 	authMux.HandleFunc("/events/month", h.get(h.apiHandler.GetMonthEvents))
+	authMux.HandleFunc("/events/list", h.get(h.apiHandler.ListEvents))
+	authMux.HandleFunc("/events/search", h.get(h.apiHandler.SearchEvents))
+	authMux.HandleFunc("/events/watch", h.get(h.apiHandler.WatchEvents))
 	authMux.HandleFunc("/events/update", h.patch(h.apiHandler.UpdateEvent))
 	authMux.HandleFunc("/events/delete", h.delete(h.apiHandler.DeleteEvent))
-
-	authHandler := h.authMiddleware(authMux)
+	authMux.HandleFunc("/events/export.ics", h.get(h.apiHandler.ExportEvents))
+	authMux.HandleFunc("/events/import", h.post(h.apiHandler.ImportEvents))
+	authMux.HandleFunc("/events/freebusy", h.get(h.apiHandler.FreeBusy))
+	authMux.HandleFunc("/events/invite", h.post(h.apiHandler.InviteAttendees))
+	authMux.HandleFunc("/events/respond", h.post(h.apiHandler.RespondInvitation))
+	authMux.HandleFunc("/events/invitations", h.get(h.apiHandler.GetInvitations))
+	authMux.HandleFunc("/calendars", h.get(h.apiHandler.GetCalendars))
+	authMux.HandleFunc("/calendars/add", h.post(h.apiHandler.AddCalendar))
+	authMux.HandleFunc("/calendars/share", h.post(h.apiHandler.ShareCalendar))
+	authMux.HandleFunc("/calendars/unshare", h.post(h.apiHandler.UnshareCalendar))
+	authMux.HandleFunc("/calendars/delete", h.delete(h.apiHandler.DeleteCalendar))
+	authMux.HandleFunc("/calendars/events", h.get(h.apiHandler.GetCalendarEvents))
+
+	authHandler := h.timeZoneMiddleware(authMux)
+	authHandler = h.authMiddleware(authHandler)
 
 	siteMux := http.NewServeMux()
 	siteMux.Handle("/", authHandler)
 	siteMux.HandleFunc("/favicon.ico", h.apiHandler.Favicon)
 
 	siteHandler := h.accessLogMiddleware(siteMux)
+	siteHandler = h.tracingMiddleware(siteHandler)
 	siteHandler = h.panicMiddleware(siteHandler)
 	return siteHandler
 }
 
 func (s *HTTPHandler) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userid := r.Header.Get("x-user-id")
-		if len(userid) == 0 {
+		userid, err := s.authenticator.Authenticate(r.Header.Get)
+		if err != nil {
+			s.logger.Warn(r.Context(), "authentication failed: %v", err)
 			s.httpWarn(r.Context(), w, `not authorized user`, http.StatusUnauthorized)
 			return
 		}
@@ -54,6 +86,19 @@ func (s *HTTPHandler) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// timeZoneMiddleware puts requester time zone from x-timezone header to context, UTC is used without header.
+func (s *HTTPHandler) timeZoneMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeZone := r.Header.Get(util.TimeZoneHeaderKey)
+		if _, err := entities.LoadLocation(timeZone); err != nil {
+			s.httpWarn(r.Context(), w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx := util.SetTimeZone(r.Context(), timeZone)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (s *HTTPHandler) panicMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -77,6 +122,40 @@ func (s *HTTPHandler) accessLogMiddleware(next http.Handler) http.Handler {
 		ri.Latency = time.Since(start)
 		ri.Code = strconv.Itoa(rw.status)
 		s.logRequest(ctx, ri)
+		code := rw.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		_, route := s.routes.Handler(r)
+		metrics.ObserveHTTP(r.Method, route, code, ri.Latency)
+	})
+}
+
+// tracingMiddleware continues trace from traceparent header or starts new one, span is named by route of request.
+func (s *HTTPHandler) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := s.routes.Handler(r)
+		ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(r.URL.Path),
+			))
+		defer span.End()
+		rw := &WrapResponseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		code := rw.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(code))
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
 	})
 }
 
@@ -129,3 +208,10 @@ func (w *WrapResponseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 	w.status = status
 }
+
+// Flush sends buffered data to client, it is needed for streaming responses.
+func (w *WrapResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/auth"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
 )
 
@@ -20,7 +21,7 @@ const (
 func TestHTTPHandler(t *testing.T) {
 	apiHandler := mocks.NewMockHandler()
 	logger := mocks.NewMockLogger()
-	httpHandler := GetHandler(logger, apiHandler)
+	httpHandler := GetHandler(logger, apiHandler, auth.NewHeaderAuthenticator())
 	testServer := httptest.NewServer(httpHandler)
 	defer testServer.Close()
 
@@ -57,6 +58,63 @@ func TestHTTPHandler(t *testing.T) {
 		{"getmonth: good ", http.MethodGet, "events/month", true, "GetMonthEvents", 200, nil},
 		{"getmonth: unauthorized", http.MethodGet, "events/month", false, ErrUnAuthorize, 401, nil},
 		{"getmonth: not supported method", http.MethodPost, "events/month", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+
+		{"export: good ", http.MethodGet, "events/export.ics", true, "ExportEvents", 200, nil},
+		{"export: unauthorized", http.MethodGet, "events/export.ics", false, ErrUnAuthorize, 401, nil},
+		{"export: not supported method", http.MethodPost, "events/export.ics", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+
+		{"import: good ", http.MethodPost, "events/import", true, "ImportEvents", 200, nil},
+		{"import: unauthorized", http.MethodPost, "events/import", false, ErrUnAuthorize, 401, nil},
+		{"import: not supported method", http.MethodGet, "events/import", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"freebusy: good ", http.MethodGet, "events/freebusy", true, "FreeBusy", 200, nil},
+		{"freebusy: unauthorized", http.MethodGet, "events/freebusy", false, ErrUnAuthorize, 401, nil},
+		{"freebusy: not supported method", http.MethodPost, "events/freebusy", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+
+		{"invite: good ", http.MethodPost, "events/invite", true, "InviteAttendees", 200, nil},
+		{"invite: unauthorized", http.MethodPost, "events/invite", false, ErrUnAuthorize, 401, nil},
+		{"invite: not supported method", http.MethodGet, "events/invite", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"respond: good ", http.MethodPost, "events/respond", true, "RespondInvitation", 200, nil},
+		{"respond: unauthorized", http.MethodPost, "events/respond", false, ErrUnAuthorize, 401, nil},
+		{"respond: not supported method", http.MethodGet, "events/respond", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"invitations: good ", http.MethodGet, "events/invitations", true, "GetInvitations", 200, nil},
+		{"invitations: unauthorized", http.MethodGet, "events/invitations", false, ErrUnAuthorize, 401, nil},
+		{"invitations: not supported method", http.MethodPost, "events/invitations", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+
+		{"calendars: good ", http.MethodGet, "calendars", true, "GetCalendars", 200, nil},
+		{"calendars: unauthorized", http.MethodGet, "calendars", false, ErrUnAuthorize, 401, nil},
+		{"calendars: not supported method", http.MethodPost, "calendars", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+
+		{"add calendar: good ", http.MethodPost, "calendars/add", true, "AddCalendar", 200, nil},
+		{"add calendar: unauthorized", http.MethodPost, "calendars/add", false, ErrUnAuthorize, 401, nil},
+		{"add calendar: not supported method", http.MethodGet, "calendars/add", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"share calendar: good ", http.MethodPost, "calendars/share", true, "ShareCalendar", 200, nil},
+		{"share calendar: unauthorized", http.MethodPost, "calendars/share", false, ErrUnAuthorize, 401, nil},
+		{"share calendar: not supported method", http.MethodGet, "calendars/share", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"unshare calendar: good ", http.MethodPost, "calendars/unshare", true, "UnshareCalendar", 200, nil},
+		{"unshare calendar: unauthorized", http.MethodPost, "calendars/unshare", false, ErrUnAuthorize, 401, nil},
+		{"unshare calendar: not supported method", http.MethodGet, "calendars/unshare", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"delete calendar: good ", http.MethodDelete, "calendars/delete", true, "DeleteCalendar", 200, nil},
+		{"delete calendar: unauthorized", http.MethodDelete, "calendars/delete", false, ErrUnAuthorize, 401, nil},
+		{"delete calendar: not supported method", http.MethodGet, "calendars/delete", true, ErrNotSupportedMethod + "GET\n", 405, nil},
+
+		{"calendar events: good ", http.MethodGet, "calendars/events", true, "GetCalendarEvents", 200, nil},
+		{"calendar events: unauthorized", http.MethodGet, "calendars/events", false, ErrUnAuthorize, 401, nil},
+		{"calendar events: not supported method", http.MethodPost, "calendars/events", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+		{"list events: good ", http.MethodGet, "events/list", true, "ListEvents", 200, nil},
+		{"list events: unauthorized", http.MethodGet, "events/list", false, ErrUnAuthorize, 401, nil},
+		{"list events: not supported method", http.MethodPost, "events/list", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+		{"search events: good ", http.MethodGet, "events/search", true, "SearchEvents", 200, nil},
+		{"search events: unauthorized", http.MethodGet, "events/search", false, ErrUnAuthorize, 401, nil},
+		{"search events: not supported method", http.MethodPost, "events/search", true, ErrNotSupportedMethod + "POST\n", 405, nil},
+		{"watch events: good ", http.MethodGet, "events/watch", true, "WatchEvents", 200, nil},
+		{"watch events: unauthorized", http.MethodGet, "events/watch", false, ErrUnAuthorize, 401, nil},
+		{"watch events: not supported method", http.MethodPost, "events/watch", true, ErrNotSupportedMethod + "POST\n", 405, nil},
 	}
 	for _, tCase := range testCases {
 		t.Run(tCase.name, func(t *testing.T) {
@@ -73,5 +131,16 @@ func TestHTTPHandler(t *testing.T) {
 			require.Equal(t, tCase.expCode, resp.StatusCode)
 		})
 	}
+	t.Run("getdate: bad time zone", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, testServer.URL+"/events/date", nil)
+		require.Nil(t, err)
+		req.Header.Add("x-user-id", "123123123")
+		req.Header.Add("x-timezone", "Mars/Olympus")
+
+		resp, err := client.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
 
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
 }
@@ -0,0 +1,482 @@
+// Package icalendar converts calendar events from/to RFC 5545 iCalendar documents.
+package icalendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const (
+	ContentType    = "text/calendar; charset=utf-8"
+	ProdID         = "-//shipa988//calendar//EN"
+	layoutDateTime = "20060102T150405"
+	layoutUTC      = "20060102T150405Z"
+	layoutDate     = "20060102"
+	maxLineLen     = 75
+	// channelProperty keeps delivery channel of reminder in VALARM.
+	channelProperty = "X-CALENDAR-CHANNEL"
+)
+
+var (
+	ErrNotCalendar = errors.New("document is not iCalendar VCALENDAR object")
+	ErrBadProperty = errors.New("not correct iCalendar property")
+
+	durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+// VEvent is parsed VEVENT component with fields in format of calendar use cases.
+type VEvent struct {
+	UID      string
+	Title    string
+	DateTime string
+	Duration string
+	Text     string
+	// Reminders is OFFSET[:CHANNEL] list of VALARM components, it is empty if VEVENT has no alarms.
+	Reminders string
+	RRule     string
+	ExDate    string
+	// TimeZone is TZID of DTSTART, DateTime and ExDate are local times of this zone.
+	TimeZone string
+	// Err is not nil if VEVENT can't be converted to event.
+	Err error
+}
+
+// Encode renders events as VCALENDAR document.
+func Encode(w io.Writer, events []*entities.Event) error {
+	bw := bufio.NewWriter(w)
+	write := func(name, value string) {
+		writeLine(bw, name+":"+value)
+	}
+	stamp := time.Now().UTC().Format(layoutUTC)
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", ProdID)
+	write("CALSCALE", "GREGORIAN")
+	for _, event := range events {
+		loc := event.Location()
+		zone := ""
+		if loc != time.UTC {
+			zone = ";TZID=" + loc.String()
+		}
+		write("BEGIN", "VEVENT")
+		write("UID", event.ID)
+		write("DTSTAMP", stamp)
+		writeLine(bw, "DTSTART"+zone+":"+formatDateTime(event.DateTime, loc))
+		write("DURATION", formatDuration(event.Duration))
+		write("SUMMARY", escapeText(event.Title))
+		write("DESCRIPTION", escapeText(event.Text))
+		if event.IsRecurring() {
+			write("RRULE", event.Recurrence.RRule())
+			if len(event.Recurrence.ExDates) > 0 {
+				exdates := make([]string, 0, len(event.Recurrence.ExDates))
+				for _, ex := range event.Recurrence.ExDates {
+					exdates = append(exdates, formatDateTime(ex, loc))
+				}
+				writeLine(bw, "EXDATE"+zone+":"+strings.Join(exdates, ","))
+			}
+		}
+		for _, r := range event.Reminders {
+			write("BEGIN", "VALARM")
+			write("ACTION", "DISPLAY")
+			write("DESCRIPTION", escapeText(event.Title))
+			write("TRIGGER", formatDuration(-r.Offset))
+			if r.Channel != entities.ChannelDefault {
+				write(channelProperty, string(r.Channel))
+			}
+			write("END", "VALARM")
+		}
+		write("END", "VEVENT")
+	}
+	write("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// Decode parses VCALENDAR document and returns all its VEVENT components.
+func Decode(r io.Reader) ([]VEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrNotCalendar
+	}
+
+	var events []VEvent
+	var stack []string
+	var current []property
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			if len(stack) > 1 && stack[1] == "VEVENT" {
+				//broken line inside VEVENT makes only this VEVENT broken
+				current = append(current, property{name: "X-BROKEN", value: line})
+				continue
+			}
+			return nil, err
+		}
+		switch p.name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(p.value))
+			if len(stack) == 2 && stack[1] == "VEVENT" {
+				current = nil
+			}
+			if len(stack) == 3 && stack[1] == "VEVENT" && stack[2] == "VALARM" {
+				//marks start of properties of the next alarm
+				current = append(current, property{name: "VALARM-BEGIN"})
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, errors.Wrapf(ErrNotCalendar, "unexpected END:%v", p.value)
+			}
+			if len(stack) == 2 && stack[1] == "VEVENT" {
+				events = append(events, toVEvent(current))
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if len(stack) < 2 || stack[1] != "VEVENT" {
+			continue
+		}
+		if len(stack) == 3 && stack[2] == "VALARM" {
+			p.name = "VALARM-" + p.name
+		} else if len(stack) > 2 {
+			continue
+		}
+		current = append(current, p)
+	}
+	if len(stack) != 0 {
+		return nil, errors.Wrap(ErrNotCalendar, "document is not closed by END:VCALENDAR")
+	}
+	return events, nil
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// alarm is VALARM component of VEVENT.
+type alarm struct {
+	trigger property
+	channel entities.Channel
+}
+
+func toVEvent(props []property) VEvent {
+	e := VEvent{}
+	var start, end time.Time
+	var isDate bool
+	var duration *time.Duration
+	var alarms []alarm
+	var exdates []time.Time
+	loc := time.UTC
+	fail := func(err error) VEvent {
+		e.Err = err
+		return e
+	}
+
+	for _, p := range props {
+		switch p.name {
+		case "X-BROKEN":
+			return fail(errors.Wrapf(ErrBadProperty, "can't parse line: %v", p.value))
+		case "UID":
+			e.UID = p.value
+		case "SUMMARY":
+			e.Title = unescapeText(p.value)
+		case "DESCRIPTION":
+			e.Text = unescapeText(p.value)
+		case "DTSTART":
+			dt, date, err := parseDateTime(p)
+			if err != nil {
+				return fail(err)
+			}
+			start, isDate = dt, date
+			if tzid, ok := p.params["TZID"]; ok && !date {
+				//parseDateTime has already checked the zone
+				loc, _ = time.LoadLocation(tzid)
+				e.TimeZone = loc.String()
+			}
+		case "DTEND":
+			dt, _, err := parseDateTime(p)
+			if err != nil {
+				return fail(err)
+			}
+			end = dt
+		case "DURATION":
+			d, err := parseDuration(p.value)
+			if err != nil {
+				return fail(err)
+			}
+			duration = &d
+		case "RRULE":
+			e.RRule = p.value
+		case "EXDATE":
+			for _, v := range strings.Split(p.value, ",") {
+				dt, _, err := parseDateTime(property{name: p.name, params: p.params, value: v})
+				if err != nil {
+					return fail(err)
+				}
+				exdates = append(exdates, dt)
+			}
+		case "VALARM-BEGIN":
+			alarms = append(alarms, alarm{})
+		case "VALARM-TRIGGER":
+			if len(alarms) > 0 {
+				alarms[len(alarms)-1].trigger = p
+			}
+		case "VALARM-ACTION":
+			if len(alarms) > 0 && strings.EqualFold(p.value, "EMAIL") && alarms[len(alarms)-1].channel == "" {
+				alarms[len(alarms)-1].channel = entities.ChannelEmail
+			}
+		case "VALARM-" + channelProperty:
+			if len(alarms) > 0 {
+				alarms[len(alarms)-1].channel = entities.Channel(strings.ToLower(p.value))
+			}
+		}
+	}
+	if start.IsZero() {
+		return fail(errors.Wrap(ErrBadProperty, "DTSTART is necessary"))
+	}
+
+	d := time.Duration(0)
+	switch {
+	case duration != nil:
+		d = *duration
+	case !end.IsZero():
+		d = end.Sub(start)
+	case isDate:
+		d = 24 * time.Hour
+	}
+
+	reminders := make([]string, 0, len(alarms))
+	for _, a := range alarms {
+		if a.trigger.name == "" {
+			continue
+		}
+		offset, err := parseTrigger(a.trigger, start, d)
+		if err != nil {
+			return fail(err)
+		}
+		r := entities.Reminder{Offset: offset, Channel: a.channel}
+		reminders = append(reminders, r.String())
+	}
+	e.Reminders = strings.Join(reminders, ",")
+
+	e.DateTime = start.In(loc).Format(entities.LayoutISO)
+	e.Duration = d.String()
+	exdateValues := make([]string, 0, len(exdates))
+	for _, ex := range exdates {
+		exdateValues = append(exdateValues, ex.In(loc).Format(entities.LayoutISO))
+	}
+	e.ExDate = strings.Join(exdateValues, ",")
+	return e
+}
+
+// parseTrigger returns time before event start to notify.
+func parseTrigger(p property, start time.Time, duration time.Duration) (time.Duration, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE-TIME") {
+		dt, _, err := parseDateTime(p)
+		if err != nil {
+			return 0, err
+		}
+		return start.Sub(dt), nil
+	}
+	offset, err := parseDuration(p.value)
+	if err != nil {
+		return 0, err
+	}
+	if strings.EqualFold(p.params["RELATED"], "END") {
+		offset += duration
+	}
+	return -offset, nil
+}
+
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+	//property value starts after the first colon which is not quoted in parameters
+	quoted := false
+	split := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			split = i
+			break
+		}
+	}
+	if split < 1 {
+		return p, errors.Wrapf(ErrBadProperty, "can't parse line: %v", line)
+	}
+	p.value = line[split+1:]
+	parts := strings.Split(line[:split], ";")
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return p, errors.Wrapf(ErrBadProperty, "can't parse parameter %v in line: %v", param, line)
+		}
+		p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return p, nil
+}
+
+// parseDateTime returns time of property in UTC and flag of DATE value type.
+func parseDateTime(p property) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(value) == len(layoutDate) {
+		dt, err := time.Parse(layoutDate, value)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(ErrBadProperty, "can't parse %v date: %v", p.name, value)
+		}
+		return dt, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		dt, err := time.Parse(layoutUTC, value)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(ErrBadProperty, "can't parse %v: %v", p.name, value)
+		}
+		return dt, false, nil
+	}
+	loc := time.UTC
+	if tzid, ok := p.params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(ErrBadProperty, "unknown time zone %v of %v", tzid, p.name)
+		}
+		loc = l
+	}
+	dt, err := time.ParseInLocation(layoutDateTime, value, loc)
+	if err != nil {
+		return time.Time{}, false, errors.Wrapf(ErrBadProperty, "can't parse %v: %v", p.name, value)
+	}
+	return dt.UTC(), false, nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, errors.Wrapf(ErrBadProperty, "can't parse duration: %v", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	d := time.Duration(0)
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, errors.Wrapf(ErrBadProperty, "can't parse duration: %v", value)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+
+	b := strings.Builder{}
+	b.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if h > 0 || m > 0 || s > 0 || days == 0 {
+		b.WriteString("T")
+		if h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+		}
+		if m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+		}
+		if s > 0 || (h == 0 && m == 0) {
+			fmt.Fprintf(&b, "%dS", s)
+		}
+	}
+	return b.String()
+}
+
+// formatDateTime formats time as UTC or as local time of loc which is referenced by TZID parameter.
+func formatDateTime(dt time.Time, loc *time.Location) string {
+	if loc == time.UTC {
+		return dt.UTC().Format(layoutUTC)
+	}
+	return dt.In(loc).Format(layoutDateTime)
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// writeLine writes content line folded by 75 octets as RFC 5545 requires.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineLen
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		//continuation line starts with space
+		limit = maxLineLen - 1
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// unfold joins folded content lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "can't read iCalendar document")
+	}
+	return lines, nil
+}
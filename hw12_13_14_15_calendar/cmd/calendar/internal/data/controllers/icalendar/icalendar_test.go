@@ -0,0 +1,88 @@
+package icalendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const userid = "00112233-4455-6677-8899-aabbccddeeff"
+
+func TestRoundTrip(t *testing.T) {
+	single, err := entities.NewEvent("stand-up; daily, short", "2020-07-07 12:12:12", "26h3m4s", "line1\nline2 \\ with a very long description which must be folded by encoder into several lines", userid, "15m,1h:email")
+	require.Nil(t, err)
+	single.ID = "11112233-4455-6677-8899-aabbccddeeff"
+	recurring, err := entities.NewEvent("retro", "2020-07-06 10:00:00", "1h", "text", userid, "")
+	require.Nil(t, err)
+	recurring.ID = "22112233-4455-6677-8899-aabbccddeeff"
+	require.Nil(t, recurring.SetTimeZone("Europe/Berlin"))
+	require.Nil(t, recurring.SetRecurrence("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20201231T000000Z", "2020-07-10 10:00:00,2020-07-20 10:00:00"))
+
+	ics := &bytes.Buffer{}
+	require.Nil(t, Encode(ics, []*entities.Event{single, recurring}))
+	for _, line := range strings.Split(ics.String(), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLineLen, "line must be folded")
+	}
+
+	vevents, err := Decode(ics)
+	require.Nil(t, err)
+	require.Len(t, vevents, 2)
+	for i, expected := range []*entities.Event{single, recurring} {
+		ve := vevents[i]
+		require.Nil(t, ve.Err)
+		require.Equal(t, expected.ID, ve.UID)
+		actual, err := entities.NewEvent(ve.Title, ve.DateTime, ve.Duration, ve.Text, userid, ve.Reminders)
+		require.Nil(t, err)
+		require.Nil(t, actual.SetTimeZone(ve.TimeZone))
+		require.Nil(t, actual.SetRecurrence(ve.RRule, ve.ExDate))
+		actual.ID = ve.UID
+		require.Equal(t, expected, actual)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("foreign calendar", func(t *testing.T) {
+		ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Google Inc//Google Calendar 70.9054//EN\r\n" +
+			"BEGIN:VTIMEZONE\r\nTZID:Europe/Moscow\r\nBEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n" +
+			"BEGIN:VEVENT\r\nDTSTART;TZID=Europe/Moscow:20200707T150000\r\nDTEND;TZID=Europe/Moscow:20200707T163000\r\n" +
+			"UID:abc@google.com\r\nSUMMARY:Meet\r\nDESCRIPTION:Des\r\n cription\r\n" +
+			"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;RELATED=END:-PT2H\r\nEND:VALARM\r\nEND:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\nUID:all-day\r\nDTSTART;VALUE=DATE:20200708\r\nSUMMARY:Holiday\r\nEND:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\nUID:broken\r\nSUMMARY:no start\r\nEND:VEVENT\r\n" +
+			"END:VCALENDAR\r\n"
+
+		vevents, err := Decode(strings.NewReader(ics))
+
+		require.Nil(t, err)
+		require.Len(t, vevents, 3)
+		require.Equal(t, VEvent{UID: "abc@google.com", Title: "Meet", Text: "Description", DateTime: "2020-07-07 15:00:00", TimeZone: "Europe/Moscow",
+			Duration: (90 * time.Minute).String(), Reminders: (30 * time.Minute).String()}, vevents[0])
+		require.Equal(t, "2020-07-08 00:00:00", vevents[1].DateTime)
+		require.Equal(t, (24 * time.Hour).String(), vevents[1].Duration)
+		require.True(t, errors.Is(vevents[2].Err, ErrBadProperty))
+	})
+	t.Run("several alarms", func(t *testing.T) {
+		ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+			"BEGIN:VEVENT\r\nUID:alarms\r\nDTSTART:20200707T150000Z\r\nDURATION:PT1H\r\nSUMMARY:Meet\r\n" +
+			"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT10M\r\nEND:VALARM\r\n" +
+			"BEGIN:VALARM\r\nACTION:EMAIL\r\nTRIGGER:-P1D\r\nEND:VALARM\r\n" +
+			"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT1H\r\nX-CALENDAR-CHANNEL:webhook\r\nEND:VALARM\r\n" +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+		vevents, err := Decode(strings.NewReader(ics))
+
+		require.Nil(t, err)
+		require.Len(t, vevents, 1)
+		require.Equal(t, "10m0s,24h0m0s:email,1h0m0s:webhook", vevents[0].Reminders)
+	})
+	t.Run("not calendar", func(t *testing.T) {
+		_, err := Decode(strings.NewReader("hello"))
+		require.True(t, errors.Is(err, ErrNotCalendar))
+	})
+}
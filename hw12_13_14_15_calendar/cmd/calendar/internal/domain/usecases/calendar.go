@@ -7,10 +7,50 @@ import (
 )
 
 type Calendar interface {
-	MakeEvent(ctx context.Context, title, dateTimeEvent, text, userID, duration, timeNotify string) (id string, err error)
-	UpdateEvent(ctx context.Context, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newTimeNotify string) (id string, err error)
-	DeleteEvent(ctx context.Context, userID, eventID string) (id string, err error)
-	GetDateEvents(ctx context.Context, date, userID string) ([]*entities.Event, error)
-	GetWeekEvents(ctx context.Context, date, userID string) ([]*entities.Event, error)
-	GetMonthEvents(ctx context.Context, date, userID string) ([]*entities.Event, error)
+	// MakeEvent makes event in shared calendar, empty calendarID means personal calendar of the user.
+	// Repeated request with the same non-empty idempotencyKey returns id of the first one without making event again.
+	MakeEvent(ctx context.Context, title, dateTimeEvent, text, userID, duration, reminders, rrule, exdate, timeZone, calendarID, idempotencyKey string) (id string, err error)
+	// UpdateEvent, DeleteEvent and InviteAttendees require editor role in shared calendar of the event.
+	// UpdateEvent and DeleteEvent fail with ErrVersionConflict if expectedVersion, comma separated list of versions,
+	// isn't empty and event has none of them.
+	// UpdateEvent returns the new version of event and handles idempotencyKey as MakeEvent does.
+	UpdateEvent(ctx context.Context, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newReminders, newRRule, newExDate, newTimeZone, expectedVersion, idempotencyKey string) (id string, version int64, err error)
+	DeleteEvent(ctx context.Context, userID, eventID, expectedVersion string) (id string, err error)
+	// GetDateEvents, GetWeekEvents, GetMonthEvents and ExportEvents take dates in the user time zone and return events rendered in it.
+	GetDateEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error)
+	GetWeekEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error)
+	GetMonthEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error)
+	ExportEvents(ctx context.Context, dateStart, dateEnd, userID, timeZone string) ([]*entities.Event, error)
+	// FreeBusy returns busy time of users and the earliest slots of duration in [dateStart,dateEnd) when all of them are free.
+	FreeBusy(ctx context.Context, dateStart, dateEnd, duration, slots, timeZone string, users []FreeBusyUser) (*entities.FreeBusy, error)
+	// InviteAttendees invites users to the event, accepted events are shown in listings of attendees.
+	InviteAttendees(ctx context.Context, userID, eventID string, attendeeIDs []string) (id string, err error)
+	RespondInvitation(ctx context.Context, userID, eventID, status string) (id string, err error)
+	// GetInvitations returns events which user is invited to, filtered by status if it isn't empty.
+	GetInvitations(ctx context.Context, userID, status, timeZone string) ([]*entities.Event, error)
+	// MakeCalendar makes shared calendar owned by the user.
+	MakeCalendar(ctx context.Context, userID, title string) (id string, err error)
+	// GetCalendars returns calendars which the user has any role in.
+	GetCalendars(ctx context.Context, userID string) ([]*entities.Calendar, error)
+	// ShareCalendar, UnshareCalendar and DeleteCalendar require owner role.
+	ShareCalendar(ctx context.Context, userID, calendarID, memberID, role string) (id string, err error)
+	UnshareCalendar(ctx context.Context, userID, calendarID, memberID string) (id string, err error)
+	DeleteCalendar(ctx context.Context, userID, calendarID string) (id string, err error)
+	// GetCalendarEvents returns events of shared calendar for period of dates in the user time zone, free-busy-only role sees only busy time.
+	GetCalendarEvents(ctx context.Context, userID, calendarID, dateStart, dateEnd, timeZone string) ([]*entities.Event, error)
+	// ListEvents returns page of user events filtered by period and search text, cursor of the page continues listing.
+	// Recurring events are listed as whole series without expanding occurrences.
+	ListEvents(ctx context.Context, userID, dateStart, dateEnd, search, sort, pageSize, cursor, timeZone string) (*entities.EventPage, error)
+	// SearchEvents returns user events ranked by relevance of their title and text to query.
+	SearchEvents(ctx context.Context, userID, query, limit, timeZone string) ([]*entities.SearchHit, error)
+	// WatchEvents subscribes user to created, updated and deleted events after revision.
+	WatchEvents(ctx context.Context, userID, revision, timeZone string) (*entities.Subscription, error)
+}
+
+// FreeBusyUser is user whose free time is looked for, working hours are in format 09:00-18:00 of the user time zone.
+// Empty working hours mean the whole day, empty time zone means time zone of the requester.
+type FreeBusyUser struct {
+	UserID       string
+	WorkingHours string
+	TimeZone     string
 }
@@ -3,6 +3,9 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -20,124 +23,875 @@ const (
 	ErrGetDateEvents  = "can't get events for date %v from calendar"
 	ErrGetWeekEvents  = "can't get events for week starts from %v from calendar"
 	ErrGetMonthEvents = "can't get events for month starts from %v from calendar"
+	ErrExportEvents   = "can't export events for period %v-%v from calendar"
+	ErrFreeBusy       = "can't find free time of users for period %v-%v in calendar"
+	ErrInvite         = "can't invite users to event %v in calendar"
+	ErrRespond        = "can't respond to invitation to event %v in calendar"
+	ErrGetInvitations = "can't get invitations from calendar"
+	ErrMakeCalendar   = "can't make calendar"
+	ErrGetCalendars   = "can't get calendars"
+	ErrShare          = "can't share calendar %v"
+	ErrUnshare        = "can't unshare calendar %v"
+	ErrDeleteCalendar = "can't delete calendar %v"
+	ErrCalendarEvents = "can't get events of calendar %v for period %v-%v"
+	ErrListEvents     = "can't list events for period %v-%v from calendar"
+	ErrSearchEvents   = "can't search events by query %v in calendar"
+	ErrWatchEvents    = "can't watch events after revision %v in calendar"
+	ErrIdempotency    = "can't handle request with idempotency key %v"
 )
 
+const (
+	// DefaultIdempotencyTTL is how long response of request with idempotency key is replayed.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// idempotencyPendingTTL keeps key reserved while its request is handled, so key of crashed request is freed.
+	idempotencyPendingTTL = time.Minute
+)
+
+const (
+	DefaultFreeSlots = 5
+	MaxFreeSlots     = 100
+)
+
+var ErrNoFeed = errors.New("watching of events is disabled")
+
+// maxTime is end of unbounded period.
+var maxTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
 type CalendarInteractor struct {
-	events entities.EventRepo
-	alerts entities.NotifyQueue
-	logger usecases.Logger
+	events         entities.EventRepo
+	alerts         entities.NotifyQueue
+	changes        entities.ChangeFeed
+	idempotencyTTL time.Duration
+	logger         usecases.Logger
 }
 
-func NewCalendar(eRepo entities.EventRepo, nRepo entities.NotifyQueue, logger usecases.Logger) *CalendarInteractor {
+// NewCalendar makes calendar which publishes changes of events to feed after every write, nil feed disables publishing.
+// Responses of requests with idempotency key are replayed for idempotencyTTL, zero means DefaultIdempotencyTTL.
+func NewCalendar(eRepo entities.EventRepo, nRepo entities.NotifyQueue, feed entities.ChangeFeed, idempotencyTTL time.Duration, logger usecases.Logger) *CalendarInteractor {
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = DefaultIdempotencyTTL
+	}
 	return &CalendarInteractor{
-		events: eRepo,
-		alerts: nRepo,
-		logger: logger,
+		events:         eRepo,
+		alerts:         nRepo,
+		changes:        feed,
+		idempotencyTTL: idempotencyTTL,
+		logger:         logger,
 	}
 }
 
-func (c CalendarInteractor) MakeEvent(ctx context.Context, title, dateTimeEvent, text, userID, duration, timeNotify string) (string, error) {
-	event, err := entities.NewEvent(title, dateTimeEvent, duration, text, userID, timeNotify)
+// MakeEvent makes event planned at dateTimeEvent local time of timeZone in shared calendar or in personal calendar if calendarID is empty.
+func (c CalendarInteractor) MakeEvent(ctx context.Context, title, dateTimeEvent, text, userID, duration, reminders, rrule, exdate, timeZone, calendarID, idempotencyKey string) (string, error) {
+	return c.idempotent(ctx, userID, idempotencyKey, entities.Fingerprint("MakeEvent", title, dateTimeEvent, text, duration, reminders, rrule, exdate, timeZone, calendarID),
+		func() (string, error) {
+			return c.makeEvent(ctx, title, dateTimeEvent, text, userID, duration, reminders, rrule, exdate, timeZone, calendarID)
+		})
+}
+
+func (c CalendarInteractor) makeEvent(ctx context.Context, title, dateTimeEvent, text, userID, duration, reminders, rrule, exdate, timeZone, calendarID string) (string, error) {
+	event, err := entities.NewEvent(title, dateTimeEvent, duration, text, userID, reminders)
 	if err != nil {
 		return "", errors.Wrap(err, ErrMake)
 	}
+	if calendarID != "" {
+		if _, err := c.calendarAccess(ctx, userID, calendarID, entities.RoleEditor); err != nil {
+			return "", errors.Wrap(err, ErrMake)
+		}
+		event.CalendarID = calendarID
+	}
+	if err := event.SetTimeZone(timeZone); err != nil {
+		return "", errors.Wrap(err, ErrMake)
+	}
+	if err := event.SetRecurrence(rrule, exdate); err != nil {
+		return "", errors.Wrap(err, ErrMake)
+	}
+	if err := c.checkBusy(ctx, *event, ""); err != nil {
+		return "", errors.Wrap(err, ErrMake)
+	}
 	id, err := c.events.Add(ctx, *event)
 	if err != nil {
 		return "", errors.Wrap(err, ErrMake)
 	}
 	c.logger.Info(ctx, "Event id: %v added to calendar", id)
+	event.ID = id
+	c.publish(ctx, entities.ChangeCreated, event, nil)
 	return id, nil
 }
 
-func (c CalendarInteractor) UpdateEvent(ctx context.Context, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newTimeNotify string) (string, error) {
+// UpdateEvent returns the new version of event, response of request with idempotency key is the new version.
+func (c CalendarInteractor) UpdateEvent(ctx context.Context, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newReminders, newRRule, newExDate, newTimeZone, expectedVersion, idempotencyKey string) (string, int64, error) {
+	response, err := c.idempotent(ctx, userID, idempotencyKey, entities.Fingerprint("UpdateEvent", eventID, newTitle, newDateTimeEvent, newText, newDuration, newReminders, newRRule, newExDate, newTimeZone, expectedVersion),
+		func() (string, error) {
+			version, err := c.updateEvent(ctx, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newReminders, newRRule, newExDate, newTimeZone, expectedVersion)
+			return strconv.FormatInt(version, 10), err
+		})
+	if err != nil {
+		return "", 0, err
+	}
+	version, err := strconv.ParseInt(response, 10, 64)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, ErrIdempotency, idempotencyKey)
+	}
+	return eventID, version, nil
+}
+
+func (c CalendarInteractor) updateEvent(ctx context.Context, userID, eventID, newTitle, newDateTimeEvent, newText, newDuration, newReminders, newRRule, newExDate, newTimeZone, expectedVersion string) (int64, error) {
 	if eventID == "" {
-		return "", errors.Wrap(fmt.Errorf(entities.ErrNoField, "id"), ErrMake)
+		return 0, errors.Wrap(fmt.Errorf(entities.ErrNoField, "id"), ErrMake)
 	}
 	if userID == "" {
-		return "", errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
+		return 0, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
+	}
+	versions, err := entities.ParseVersions(expectedVersion)
+	if err != nil {
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
 	}
 
-	e, err := c.events.GetByID(ctx, userID, eventID)
+	e, err := c.eventAccess(ctx, userID, eventID, entities.RoleEditor)
 	if err != nil {
-		return "", errors.Wrapf(err, ErrUpdate, eventID)
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
+	}
+	if _, err := e.MatchVersion(versions); err != nil {
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
 	}
 
-	upde, err := e.Update(newTitle, newDateTimeEvent, newDuration, newText, newTimeNotify)
+	upde, err := e.Update(newTitle, newDateTimeEvent, newDuration, newText, newReminders, newRRule, newExDate, newTimeZone)
 	if err != nil {
-		return "", errors.Wrapf(err, ErrUpdate, eventID)
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
+	}
+	if err := c.checkBusy(ctx, upde, eventID); err != nil {
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
 	}
-	if err := c.events.UpdateByID(ctx, userID, eventID, upde); err != nil {
-		return "", errors.Wrapf(err, ErrUpdate, eventID)
+	//editor of shared calendar changes event of another user, repository rejects the update if event is changed after it is read
+	if err := c.events.UpdateByID(ctx, e.UserID, eventID, upde); err != nil {
+		return 0, errors.Wrapf(err, ErrUpdate, eventID)
 	}
 	c.logger.Info(ctx, "Event id: %v updated in calendar", eventID)
-	return eventID, nil
+	c.publishByID(ctx, entities.ChangeUpdated, eventID)
+	return upde.Version + 1, nil
 }
 
-func (c CalendarInteractor) DeleteEvent(ctx context.Context, userID, eventID string) (string, error) {
+// idempotent runs operation once per idempotency key of the user and replays its response to repeated requests,
+// empty key runs operation without idempotency. Key is released if operation fails, so failed request can be retried.
+// Reservation of the key is renewed while operation runs, so long operation isn't started twice.
+func (c CalendarInteractor) idempotent(ctx context.Context, userID, key, fingerprint string, operation func() (string, error)) (string, error) {
+	if key == "" {
+		return operation()
+	}
+	if err := entities.ValidateIdempotencyKey(key); err != nil {
+		return "", errors.Wrapf(err, ErrIdempotency, key)
+	}
+	now := time.Now()
+	existing, err := c.events.ReserveIdempotency(ctx, entities.Idempotency{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(idempotencyPendingTTL),
+	}, now)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrIdempotency, key)
+	}
+	if existing != nil {
+		switch {
+		case existing.Fingerprint != fingerprint:
+			return "", errors.Wrapf(entities.ErrIdempotencyReused, ErrIdempotency, key)
+		case existing.InProgress():
+			return "", errors.Wrapf(entities.ErrIdempotencyPending, ErrIdempotency, key)
+		}
+		c.logger.Info(ctx, "response of idempotency key %v is replayed", key)
+		return existing.Response, nil
+	}
+
+	stopRenew := c.renewIdempotency(ctx, userID, key)
+	response, err := operation()
+	stopRenew()
+	if err != nil {
+		if rerr := c.events.ReleaseIdempotency(ctx, userID, key); rerr != nil {
+			c.logger.Error(ctx, rerr)
+		}
+		return "", err
+	}
+	//operation is done but its response isn't saved, so the key isn't released and caller can't take it for success
+	if err := c.events.CompleteIdempotency(ctx, userID, key, response, time.Now().Add(c.idempotencyTTL)); err != nil {
+		return "", errors.Wrapf(err, ErrIdempotency, key)
+	}
+	return response, nil
+}
+
+// renewIdempotency extends reservation of the key until returned stop is called.
+func (c CalendarInteractor) renewIdempotency(ctx context.Context, userID, key string) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		tick := time.NewTicker(idempotencyPendingTTL / 3)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				if err := c.events.RenewIdempotency(ctx, userID, key, time.Now().Add(idempotencyPendingTTL)); err != nil {
+					c.logger.Error(ctx, errors.Wrapf(err, ErrIdempotency, key))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func (c CalendarInteractor) DeleteEvent(ctx context.Context, userID, eventID, expectedVersion string) (string, error) {
 	if eventID == "" {
 		return "", errors.Wrap(fmt.Errorf(entities.ErrNoField, "id"), ErrMake)
 	}
 	if userID == "" {
 		return "", errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
 	}
+	versions, err := entities.ParseVersions(expectedVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrDelete, eventID)
+	}
 
-	if err := c.events.DeleteByUserID(ctx, userID, eventID); err != nil {
+	e, err := c.eventAccess(ctx, userID, eventID, entities.RoleEditor)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrDelete, eventID)
+	}
+	//repository rejects the delete if matched version is changed after event is read
+	version, err := e.MatchVersion(versions)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrDelete, eventID)
+	}
+	if err := c.events.DeleteByUserID(ctx, e.UserID, eventID, version); err != nil {
 		return "", errors.Wrapf(err, ErrDelete, eventID)
 	}
 	c.logger.Info(ctx, "Event id: %v deleted from calendar", eventID)
+	c.publish(ctx, entities.ChangeDeleted, e, nil)
 	return eventID, nil
 }
 
-func (c CalendarInteractor) GetDateEvents(ctx context.Context, date, userID string) ([]*entities.Event, error) {
+func (c CalendarInteractor) GetDateEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error) {
+	events, err := c.getPeriodEvents(ctx, date, userID, timeZone, 0, 0, 1)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetDateEvents, date)
+	}
+	return events, nil
+}
+
+func (c CalendarInteractor) GetWeekEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error) {
+	events, err := c.getPeriodEvents(ctx, date, userID, timeZone, 0, 0, 7)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetWeekEvents, date)
+	}
+	return events, nil
+}
+
+func (c CalendarInteractor) GetMonthEvents(ctx context.Context, date, userID, timeZone string) ([]*entities.Event, error) {
+	events, err := c.getPeriodEvents(ctx, date, userID, timeZone, 0, 1, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetMonthEvents, date)
+	}
+	return events, nil
+}
+
+// getPeriodEvents returns events of period started at the local midnight of date and lasted years, months, days of local calendar.
+func (c CalendarInteractor) getPeriodEvents(ctx context.Context, date, userID, timeZone string, years, months, days int) ([]*entities.Event, error) {
 	if date == "" {
-		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "date"), ErrMake)
+		return nil, fmt.Errorf(entities.ErrNoField, "date")
 	}
 	if userID == "" {
-		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
+		return nil, fmt.Errorf(entities.ErrNoField, "userid")
+	}
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
 	}
-	dt, err := time.Parse(entities.LayoutDateISO, date)
+	start, err := time.ParseInLocation(entities.LayoutDateISO, date, loc)
 	if err != nil {
 		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", date)
 	}
-	events, err := c.events.GetByDate(ctx, userID, dt)
+	//AddDate normalizes local date, so DST transition makes the day 23 or 25 hours long
+	end := start.AddDate(years, months, days)
+	events, err := c.events.GetForPeriodByUserID(ctx, userID, start.UTC(), end.UTC())
 	if err != nil {
-		return nil, errors.Wrapf(err, ErrGetDateEvents, date)
+		return nil, err
 	}
-	return events, nil
+	return localizeEvents(expandEvents(events, start, end), loc), nil
 }
 
-func (c CalendarInteractor) GetWeekEvents(ctx context.Context, date, userID string) ([]*entities.Event, error) {
-	if date == "" {
-		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "date"), ErrMake)
+// ExportEvents returns events having occurrences in [dateStart,dateEnd), recurring events are returned as whole series.
+func (c CalendarInteractor) ExportEvents(ctx context.Context, dateStart, dateEnd, userID, timeZone string) ([]*entities.Event, error) {
+	if dateStart == "" {
+		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "start"), ErrMake)
+	}
+	if dateEnd == "" {
+		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "end"), ErrMake)
 	}
 	if userID == "" {
 		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
 	}
-	dt, err := time.Parse(entities.LayoutDateISO, date)
+	loc, err := entities.LoadLocation(timeZone)
 	if err != nil {
-		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", date)
+		return nil, errors.Wrapf(err, ErrExportEvents, dateStart, dateEnd)
 	}
-	events, err := c.events.GetForPeriodByUserID(ctx, userID, dt, dt.AddDate(0, 0, 7))
+	start, err := time.ParseInLocation(entities.LayoutDateISO, dateStart, loc)
 	if err != nil {
-		return nil, errors.Wrapf(err, ErrGetWeekEvents, date)
+		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", dateStart)
 	}
-	return events, nil
+	end, err := time.ParseInLocation(entities.LayoutDateISO, dateEnd, loc)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", dateEnd)
+	}
+	events, err := c.events.GetForPeriodByUserID(ctx, userID, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrExportEvents, dateStart, dateEnd)
+	}
+	series := make([]*entities.Event, 0, len(events))
+	for _, event := range events {
+		if len(event.Occurrences(start, end)) == 0 {
+			continue
+		}
+		series = append(series, event)
+	}
+	return series, nil
 }
 
-func (c CalendarInteractor) GetMonthEvents(ctx context.Context, date, userID string) ([]*entities.Event, error) {
-	if date == "" {
-		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "date"), ErrMake)
+func (c CalendarInteractor) FreeBusy(ctx context.Context, dateStart, dateEnd, duration, slots, timeZone string, users []FreeBusyUser) (*entities.FreeBusy, error) {
+	if len(users) == 0 {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "users"), ErrFreeBusy, dateStart, dateEnd)
+	}
+	if duration == "" {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "duration"), ErrFreeBusy, dateStart, dateEnd)
+	}
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrFreeBusy, dateStart, dateEnd)
+	}
+	start, err := time.ParseInLocation(entities.LayoutISO, dateStart, loc)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrDatetimeFormat, "error parse dateTime:  %v", dateStart)
+	}
+	end, err := time.ParseInLocation(entities.LayoutISO, dateEnd, loc)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrDatetimeFormat, "error parse dateTime:  %v", dateEnd)
+	}
+	if !end.After(start) {
+		return nil, errors.Wrapf(entities.ErrPeriod, ErrFreeBusy, dateStart, dateEnd)
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return nil, errors.Wrapf(entities.ErrDurationFormat, "error parse duration: %v", duration)
+	}
+	count := DefaultFreeSlots
+	if slots != "" {
+		count, err = strconv.Atoi(slots)
+		if err != nil || count < 1 {
+			return nil, errors.Wrapf(entities.ErrSlotsFormat, "error parse slots: %v", slots)
+		}
+		if count > MaxFreeSlots {
+			count = MaxFreeSlots
+		}
+	}
+
+	userIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.UserID == "" {
+			return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrFreeBusy, dateStart, dateEnd)
+		}
+		userIDs = append(userIDs, user.UserID)
+	}
+	events, err := c.events.GetOverlapping(ctx, userIDs, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrFreeBusy, dateStart, dateEnd)
+	}
+	//event makes busy its owner and accepted attendees
+	userEvents := make(map[string][]*entities.Event, len(users))
+	for _, event := range events {
+		for _, userID := range event.Recipients() {
+			userEvents[userID] = append(userEvents[userID], event)
+		}
+	}
+
+	result := &entities.FreeBusy{Users: make([]entities.UserBusy, 0, len(users))}
+	var blocked []entities.Interval
+	for _, user := range users {
+		userZone := user.TimeZone
+		if userZone == "" {
+			userZone = timeZone
+		}
+		wh, err := entities.ParseWorkingHours(user.WorkingHours, userZone)
+		if err != nil {
+			return nil, errors.Wrapf(err, ErrFreeBusy, dateStart, dateEnd)
+		}
+		busy := entities.BusyIntervals(userEvents[user.UserID], start, end)
+		blocked = append(blocked, busy...)
+		if wh != nil {
+			blocked = append(blocked, wh.OffHours(start, end)...)
+		}
+		result.Users = append(result.Users, entities.UserBusy{UserID: user.UserID, Busy: localizeIntervals(busy, loc)})
+	}
+	result.Slots = localizeIntervals(entities.FreeSlots(blocked, start, end, d, count), loc)
+	return result, nil
+}
+
+func (c CalendarInteractor) InviteAttendees(ctx context.Context, userID, eventID string, attendeeIDs []string) (string, error) {
+	if eventID == "" {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "id"), ErrInvite, eventID)
 	}
 	if userID == "" {
-		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrMake)
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrInvite, eventID)
+	}
+	invited := make([]string, 0, len(attendeeIDs))
+	for _, attendeeID := range attendeeIDs {
+		//owner doesn't need invitation to own event
+		if attendeeID != "" && attendeeID != userID {
+			invited = append(invited, attendeeID)
+		}
+	}
+	if len(invited) == 0 {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "attendees"), ErrInvite, eventID)
+	}
+	//only owner of personal event or editor of shared calendar can invite
+	if _, err := c.eventAccess(ctx, userID, eventID, entities.RoleEditor); err != nil {
+		return "", errors.Wrapf(err, ErrInvite, eventID)
 	}
-	dt, err := time.Parse(entities.LayoutDateISO, date)
+	if err := c.events.AddAttendees(ctx, eventID, invited); err != nil {
+		return "", errors.Wrapf(err, ErrInvite, eventID)
+	}
+	c.logger.Info(ctx, "users %v are invited to event id: %v", invited, eventID)
+	c.publishByID(ctx, entities.ChangeUpdated, eventID)
+	return eventID, nil
+}
+
+func (c CalendarInteractor) RespondInvitation(ctx context.Context, userID, eventID, status string) (string, error) {
+	if eventID == "" {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "id"), ErrRespond, eventID)
+	}
+	if userID == "" {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrRespond, eventID)
+	}
+	s, err := entities.ParseAttendeeStatus(status)
 	if err != nil {
-		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", date)
+		return "", errors.Wrapf(err, ErrRespond, eventID)
+	}
+	if err := c.events.SetAttendeeStatus(ctx, eventID, userID, s); err != nil {
+		return "", errors.Wrapf(err, ErrRespond, eventID)
+	}
+	c.logger.Info(ctx, "user %v responded %v to event id: %v", userID, s, eventID)
+	c.publishByID(ctx, entities.ChangeUpdated, eventID)
+	return eventID, nil
+}
+
+func (c CalendarInteractor) GetInvitations(ctx context.Context, userID, status, timeZone string) ([]*entities.Event, error) {
+	if userID == "" {
+		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrGetInvitations)
+	}
+	if status != "" && entities.AttendeeStatus(status) != entities.NeedsAction {
+		if _, err := entities.ParseAttendeeStatus(status); err != nil {
+			return nil, errors.Wrap(err, ErrGetInvitations)
+		}
 	}
-	events, err := c.events.GetForPeriodByUserID(ctx, userID, dt, dt.AddDate(0, 1, 0))
+	loc, err := entities.LoadLocation(timeZone)
 	if err != nil {
-		return nil, errors.Wrapf(err, ErrGetMonthEvents, date)
+		return nil, errors.Wrap(err, ErrGetInvitations)
 	}
-	return events, nil
+	events, err := c.events.GetInvitations(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrGetInvitations)
+	}
+	invitations := make([]*entities.Event, 0, len(events))
+	for _, event := range events {
+		if a, ok := event.Attendee(userID); ok && (status == "" || a.Status == entities.AttendeeStatus(status)) {
+			invitations = append(invitations, event.In(loc))
+		}
+	}
+	return invitations, nil
+}
+
+// MakeCalendar makes shared calendar owned by the user.
+func (c CalendarInteractor) MakeCalendar(ctx context.Context, userID, title string) (string, error) {
+	calendar, err := entities.NewCalendar(title, userID)
+	if err != nil {
+		return "", errors.Wrap(err, ErrMakeCalendar)
+	}
+	id, err := c.events.AddCalendar(ctx, *calendar)
+	if err != nil {
+		return "", errors.Wrap(err, ErrMakeCalendar)
+	}
+	c.logger.Info(ctx, "Calendar id: %v added", id)
+	return id, nil
+}
+
+func (c CalendarInteractor) GetCalendars(ctx context.Context, userID string) ([]*entities.Calendar, error) {
+	if userID == "" {
+		return nil, errors.Wrap(fmt.Errorf(entities.ErrNoField, "userid"), ErrGetCalendars)
+	}
+	calendars, err := c.events.GetCalendarsByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrGetCalendars)
+	}
+	return calendars, nil
+}
+
+func (c CalendarInteractor) ShareCalendar(ctx context.Context, userID, calendarID, memberID, role string) (string, error) {
+	if memberID == "" {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "user"), ErrShare, calendarID)
+	}
+	r, err := entities.ParseRole(role)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrShare, calendarID)
+	}
+	if err := c.setRole(ctx, userID, calendarID, memberID, r); err != nil {
+		return "", errors.Wrapf(err, ErrShare, calendarID)
+	}
+	c.logger.Info(ctx, "user %v got role %v in calendar id: %v", memberID, r, calendarID)
+	return calendarID, nil
+}
+
+func (c CalendarInteractor) UnshareCalendar(ctx context.Context, userID, calendarID, memberID string) (string, error) {
+	if memberID == "" {
+		return "", errors.Wrapf(fmt.Errorf(entities.ErrNoField, "user"), ErrUnshare, calendarID)
+	}
+	if err := c.setRole(ctx, userID, calendarID, memberID, ""); err != nil {
+		return "", errors.Wrapf(err, ErrUnshare, calendarID)
+	}
+	c.logger.Info(ctx, "user %v lost access to calendar id: %v", memberID, calendarID)
+	return calendarID, nil
+}
+
+// setRole changes role of member in calendar of owner userID, the last owner can't lose the role.
+func (c CalendarInteractor) setRole(ctx context.Context, userID, calendarID, memberID string, role entities.Role) error {
+	calendar, err := c.calendarAccess(ctx, userID, calendarID, entities.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if calendar.RoleOf(memberID) == entities.RoleOwner && role != entities.RoleOwner && calendar.Owners() == 1 {
+		return entities.ErrLastOwner
+	}
+	return c.events.SetRole(ctx, calendarID, memberID, role)
+}
+
+func (c CalendarInteractor) DeleteCalendar(ctx context.Context, userID, calendarID string) (string, error) {
+	calendar, err := c.calendarAccess(ctx, userID, calendarID, entities.RoleOwner)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	//events are deleted with calendar, so they are read before to publish their deletion
+	var deleted []*entities.Event
+	if c.changes != nil {
+		deleted, err = c.events.GetForPeriodByCalendarID(ctx, calendarID, time.Time{}, maxTime)
+		if err != nil {
+			return "", errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+		}
+	}
+	if err := c.events.DeleteCalendar(ctx, calendarID); err != nil {
+		return "", errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	c.logger.Info(ctx, "Calendar id: %v deleted", calendarID)
+	for _, event := range deleted {
+		c.publish(ctx, entities.ChangeDeleted, event, calendar)
+	}
+	return calendarID, nil
+}
+
+// GetCalendarEvents returns occurrences of calendar events in [dateStart,dateEnd) of user time zone, free-busy-only role sees only busy time.
+func (c CalendarInteractor) GetCalendarEvents(ctx context.Context, userID, calendarID, dateStart, dateEnd, timeZone string) ([]*entities.Event, error) {
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrCalendarEvents, calendarID, dateStart, dateEnd)
+	}
+	start, err := time.ParseInLocation(entities.LayoutDateISO, dateStart, loc)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", dateStart)
+	}
+	end, err := time.ParseInLocation(entities.LayoutDateISO, dateEnd, loc)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrDateFormat, "error parse dateTime:  %v", dateEnd)
+	}
+	calendar, err := c.calendarAccess(ctx, userID, calendarID, entities.RoleFreeBusy)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrCalendarEvents, calendarID, dateStart, dateEnd)
+	}
+	events, err := c.events.GetForPeriodByCalendarID(ctx, calendarID, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrCalendarEvents, calendarID, dateStart, dateEnd)
+	}
+	if !calendar.RoleOf(userID).Allows(entities.RoleViewer) {
+		for i, event := range events {
+			events[i] = event.Busy()
+		}
+	}
+	return localizeEvents(expandEvents(events, start, end), loc), nil
+}
+
+// ListEvents returns page of user events overlapping [dateStart,dateEnd) of user time zone, bounds are dates or date times and may be empty.
+// The next page is read by cursor of the previous one with the same sort, recurring events are listed as whole series.
+func (c CalendarInteractor) ListEvents(ctx context.Context, userID, dateStart, dateEnd, search, sort, pageSize, cursor, timeZone string) (*entities.EventPage, error) {
+	if userID == "" {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrListEvents, dateStart, dateEnd)
+	}
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+	}
+	query := entities.ListQuery{UserID: userID, Search: search, Limit: entities.DefaultPageSize}
+	if query.Start, err = parseBound(dateStart, loc); err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+	}
+	if query.End, err = parseBound(dateEnd, loc); err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+	}
+	if !query.Start.IsZero() && !query.End.IsZero() && !query.End.After(query.Start) {
+		return nil, errors.Wrapf(entities.ErrPeriod, ErrListEvents, dateStart, dateEnd)
+	}
+	if query.Sort, err = entities.ParseSortOrder(sort); err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+	}
+	if pageSize != "" {
+		query.Limit, err = strconv.Atoi(pageSize)
+		if err != nil || query.Limit < 1 || query.Limit > entities.MaxPageSize {
+			return nil, errors.Wrapf(entities.ErrPageSize, ErrListEvents, dateStart, dateEnd)
+		}
+	}
+	if cursor != "" {
+		if query.After, err = entities.DecodeCursor(cursor, query.Sort); err != nil {
+			return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+		}
+	}
+	page, err := c.events.ListEvents(ctx, query)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, dateStart, dateEnd)
+	}
+	page.Events = localizeEvents(page.Events, loc)
+	return page, nil
+}
+
+// SearchEvents returns events which title or text have words starting with any word of query, events matching more words go first.
+func (c CalendarInteractor) SearchEvents(ctx context.Context, userID, query, limit, timeZone string) ([]*entities.SearchHit, error) {
+	if userID == "" {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrSearchEvents, query)
+	}
+	terms := entities.Tokenize(query)
+	if len(terms) == 0 {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "query"), ErrSearchEvents, query)
+	}
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrSearchEvents, query)
+	}
+	n := entities.DefaultSearchLimit
+	if limit != "" {
+		n, err = strconv.Atoi(limit)
+		if err != nil || n < 1 || n > entities.MaxPageSize {
+			return nil, errors.Wrapf(entities.ErrPageSize, ErrSearchEvents, query)
+		}
+	}
+	hits, err := c.events.SearchEvents(ctx, userID, terms, n)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrSearchEvents, query)
+	}
+	for _, hit := range hits {
+		hit.Event = hit.Event.In(loc)
+	}
+	return hits, nil
+}
+
+// WatchEvents subscribes user to changes of events after revision, empty revision watches changes from now.
+// Events of changes are rendered in the user time zone, subscription must be cancelled by caller.
+func (c CalendarInteractor) WatchEvents(ctx context.Context, userID, revision, timeZone string) (*entities.Subscription, error) {
+	if userID == "" {
+		return nil, errors.Wrapf(fmt.Errorf(entities.ErrNoField, "userid"), ErrWatchEvents, revision)
+	}
+	if c.changes == nil {
+		return nil, errors.Wrapf(ErrNoFeed, ErrWatchEvents, revision)
+	}
+	loc, err := entities.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrWatchEvents, revision)
+	}
+	after := uint64(0)
+	if revision != "" {
+		after, err = strconv.ParseUint(revision, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(entities.ErrRevisionFormat, ErrWatchEvents, revision)
+		}
+	}
+	sub, err := c.changes.Subscribe(userID, after)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrWatchEvents, revision)
+	}
+	return localizeSubscription(sub, loc), nil
+}
+
+// publishByID publishes change of event which state is read after write.
+func (c CalendarInteractor) publishByID(ctx context.Context, kind entities.ChangeKind, eventID string) {
+	if c.changes == nil {
+		return
+	}
+	event, err := c.events.GetByEventID(ctx, eventID)
+	if err != nil {
+		c.logger.Warn(ctx, "change of event id: %v isn't published: %v", eventID, err)
+		return
+	}
+	c.publish(ctx, kind, event, nil)
+}
+
+// publish publishes change of event to watchers, calendar of shared event is read if it isn't passed.
+func (c CalendarInteractor) publish(ctx context.Context, kind entities.ChangeKind, event *entities.Event, calendar *entities.Calendar) {
+	if c.changes == nil {
+		return
+	}
+	if calendar == nil && event.CalendarID != "" {
+		var err error
+		if calendar, err = c.events.GetCalendar(ctx, event.CalendarID); err != nil {
+			c.logger.Warn(ctx, "change of event id: %v is published only to its users: %v", event.ID, err)
+		}
+	}
+	c.changes.Publish(entities.NewChange(kind, event, calendar))
+}
+
+// calendarAccess returns calendar if user role in it allows required access.
+func (c CalendarInteractor) calendarAccess(ctx context.Context, userID, calendarID string, required entities.Role) (*entities.Calendar, error) {
+	if calendarID == "" {
+		return nil, fmt.Errorf(entities.ErrNoField, "calendar")
+	}
+	if userID == "" {
+		return nil, fmt.Errorf(entities.ErrNoField, "userid")
+	}
+	calendar, err := c.events.GetCalendar(ctx, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if !calendar.RoleOf(userID).Allows(required) {
+		return nil, errors.Wrapf(entities.ErrAccessDenied, "role %v is required", required)
+	}
+	return calendar, nil
+}
+
+// eventAccess returns event if user has required role in its calendar, personal event is accessible only by its owner.
+func (c CalendarInteractor) eventAccess(ctx context.Context, userID, eventID string, required entities.Role) (*entities.Event, error) {
+	event, err := c.events.GetByEventID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.CalendarID == "" {
+		//personal event of other user is hidden
+		if event.UserID != userID {
+			return nil, entities.ErrEventNotFound
+		}
+		return event, nil
+	}
+	if _, err := c.calendarAccess(ctx, userID, event.CalendarID, required); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// checkBusy returns DateBusyError if the event and recurring events of its owner overlap or the recurring event overlaps
+// other events of the owner, event with exceptID is skipped. Repository rejects overlap of the first occurrences
+// atomically, later occurrences are checked only here.
+func (c CalendarInteractor) checkBusy(ctx context.Context, event entities.Event, exceptID string) error {
+	end := event.End()
+	if event.IsRecurring() {
+		end = maxTime
+		if last, ok := event.Recurrence.Last(event.DateTime.In(event.Location())); ok {
+			end = last.Add(event.Duration)
+		}
+	}
+	events, err := c.events.GetOverlapping(ctx, []string{event.UserID}, event.DateTime, end)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, e := range events {
+		if e.ID != exceptID && e.UserID == event.UserID && (e.IsRecurring() || event.IsRecurring()) && e.Overlaps(event) {
+			ids = append(ids, e.ID)
+		}
+	}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		return entities.NewDateBusyError(ids...)
+	}
+	return nil
+}
+
+// parseBound parses date or date time in location, empty bound is zero time.
+func parseBound(bound string, loc *time.Location) (time.Time, error) {
+	if bound == "" {
+		return time.Time{}, nil
+	}
+	layout := entities.LayoutISO
+	if len(bound) == len(entities.LayoutDateISO) {
+		layout = entities.LayoutDateISO
+	}
+	t, err := time.ParseInLocation(layout, bound, loc)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(entities.ErrDatetimeFormat, "error parse bound: %v", bound)
+	}
+	return t.UTC(), nil
+}
+
+// localizeSubscription renders events of subscription changes in location.
+func localizeSubscription(sub *entities.Subscription, loc *time.Location) *entities.Subscription {
+	backlog := make([]entities.Change, 0, len(sub.Backlog))
+	for _, change := range sub.Backlog {
+		change.Event = change.Event.In(loc)
+		backlog = append(backlog, change)
+	}
+	changes := make(chan entities.Change)
+	done := make(chan struct{})
+	go func() {
+		defer close(changes)
+		for change := range sub.Changes {
+			change.Event = change.Event.In(loc)
+			select {
+			case changes <- change:
+			case <-done:
+				return
+			}
+		}
+	}()
+	once := &sync.Once{}
+	return &entities.Subscription{
+		Backlog: backlog,
+		Changes: changes,
+		Cancel: func() {
+			once.Do(func() {
+				close(done)
+				sub.Cancel()
+			})
+		},
+	}
+}
+
+// expandEvents replaces events by their occurrences in [start,end).
+func expandEvents(events []*entities.Event, start, end time.Time) []*entities.Event {
+	expanded := make([]*entities.Event, 0, len(events))
+	for _, event := range events {
+		expanded = append(expanded, event.Occurrences(start, end)...)
+	}
+	sort.SliceStable(expanded, func(i, j int) bool {
+		return expanded[i].DateTime.Before(expanded[j].DateTime)
+	})
+	return expanded
+}
+
+// localizeIntervals renders intervals in the user location.
+func localizeIntervals(intervals []entities.Interval, loc *time.Location) []entities.Interval {
+	localized := make([]entities.Interval, 0, len(intervals))
+	for _, in := range intervals {
+		localized = append(localized, in.In(loc))
+	}
+	return localized
+}
+
+// localizeEvents renders events in the user location.
+func localizeEvents(events []*entities.Event, loc *time.Location) []*entities.Event {
+	localized := make([]*entities.Event, 0, len(events))
+	for _, event := range events {
+		localized = append(localized, event.In(loc))
+	}
+	return localized
 }
@@ -2,19 +2,27 @@ package app
 
 import (
 	"context"
+	"net"
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_scheduler/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/adminserver"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/queueservice/kafkaservice"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/queueservice/rabbitservice"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/db"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/logger"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 type App struct {
@@ -24,6 +32,9 @@ func NewApp() *App {
 	return &App{}
 }
 
+// defaultHeartbeat is Admin.Heartbeat if it isn't set.
+const defaultHeartbeat = time.Minute
+
 type RepoType string
 
 const (
@@ -55,17 +66,62 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 		return errors.Wrapf(err, "can't init logger")
 	}
 
+	shutdownTracing, err := tracing.Init(tracing.Config{
+		Service:  "calendar_scheduler",
+		Exporter: cfg.Tracing.Exporter,
+		Endpoint: cfg.Tracing.Endpoint,
+		File:     cfg.Tracing.File,
+		Ratio:    cfg.Tracing.Ratio,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "can't init tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error(context.Background(), errors.Wrap(err, "can't flush spans"))
+		}
+	}()
+
 	repo, err := InitRepo(cfg, logger)
 	if err != nil {
 		return errors.Wrapf(err, "can't init repository")
 	}
+	checks := health.NewHealth(logger)
+	if dbRepo, ok := repo.(*db.EventRepo); ok {
+		checks.AddReadiness("postgres", health.CheckerFunc(func(ctx context.Context) error {
+			return dbRepo.Connect(ctx, cfg.DB.DSN)
+		}))
+	}
+	repo = metrics.NewEventRepo(repo, string(cfg.RepoType))
 
 	broker, err := InitQueue(cfg, logger)
 	if err != nil {
 		return errors.Wrapf(err, "can't init queue broker")
 	}
+	if checker, ok := broker.(health.Checker); ok {
+		checks.AddReadiness(string(cfg.Queue), checker)
+	}
+
+	schedule := usecases.Schedule{
+		Tick:      cfg.Schedule.Tick,
+		Tolerance: cfg.Schedule.Tolerance,
+		Lookback:  cfg.Schedule.Lookback,
+	}
+	scheduler := usecases.NewScheduler(repo, broker, schedule, logger)
+	heartbeat := cfg.Admin.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	checks.AddLiveness("scheduler", health.Heartbeat(scheduler.LastScan, heartbeat+cfg.Schedule.Tick))
+
+	cleaning, err := InitCleaning(cfg)
+	if err != nil {
+		return errors.Wrapf(err, "can't init cleaning of old events")
+	}
 
-	scheduler := usecases.NewScheduler(repo, broker, logger)
+	adminServer := adminserver.NewAdminServer(net.JoinHostPort("0.0.0.0", cfg.Admin.Port), checks, logger)
+	go adminServer.Serve()
+	defer adminServer.StopServe()
 
 	done := make(chan os.Signal, 3)
 	signal.Notify(done, os.Interrupt)
@@ -79,10 +135,18 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 		done <- os.Interrupt
 	}()
 	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.Info(ctx, "start relaying outbox to queue...")
+		scheduler.RelayOutbox(ctx)
+		logger.Info(ctx, "stop relaying outbox to queue...")
+		done <- os.Interrupt
+	}()
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		logger.Info(ctx, "start cleaning old events from repo...")
-		scheduler.CleanEvents(ctx, 360)
+		scheduler.CleanEvents(ctx, cleaning)
 		logger.Info(ctx, "stop cleaning old events from repo...")
 		done <- os.Interrupt
 	}()
@@ -114,14 +178,40 @@ func InitRepo(cfg *Config, logger mainusecase.Logger) (entities.EventRepo, error
 func InitQueue(cfg *Config, logger mainusecase.Logger) (entities.NotifyQueue, error) {
 	switch cfg.Queue {
 	case rabbit:
-		broker, err := rabbitservice.NewRabbitManager(cfg.Rabbit.Addr, cfg.Rabbit.ExchangeName, cfg.Rabbit.QueueName, logger)
+		broker, err := rabbitservice.NewRabbitManager(cfg.Rabbit.Addr, cfg.Rabbit.ExchangeName, cfg.Rabbit.QueueName, 0, cfg.Rabbit.MaxInFlight, logger)
 		if err != nil {
 			return nil, errors.Wrapf(err, "can't init db repository")
 		}
 		return broker, nil
 	case kafka:
-		return nil, errors.New("kafka broker is not supported")
+		broker, err := kafkaservice.NewKafkaManager(cfg.Kafka.Addrs, cfg.Kafka.Topic, cfg.Kafka.GroupID, logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init kafka broker")
+		}
+		return broker, nil
 	default:
 		return nil, errors.New("unknown queue type. I know next types:rabbit,kafka")
 	}
 }
+
+func InitCleaning(cfg *Config) (usecases.Cleaning, error) {
+	c := cfg.Retention
+	schedule, err := cron.ParseStandard(c.Cron)
+	if err != nil {
+		return usecases.Cleaning{}, errors.Wrapf(err, "can't parse cron %v", c.Cron)
+	}
+	mode, err := entities.ParseRetentionMode(c.Mode)
+	if err != nil {
+		return usecases.Cleaning{}, err
+	}
+	return usecases.Cleaning{
+		Cron: schedule,
+		Retention: entities.Retention{
+			Mode:      mode,
+			Keep:      c.Keep,
+			Users:     c.Users,
+			Calendars: c.Calendars,
+		},
+		Batch: c.Batch,
+	}, nil
+}
@@ -1,11 +1,18 @@
 package app
 
+import "time"
+
 type Config struct {
-	Log      Log       `yaml:"log"`
-	RepoType RepoType  `yaml:"repotype"`
-	DB       DB        `yaml:"db"`
-	Queue    QueueType `yaml:"queue"`
-	Rabbit   Rabbit    `yaml:"rabbit"`
+	Log       Log       `yaml:"log"`
+	RepoType  RepoType  `yaml:"repotype"`
+	DB        DB        `yaml:"db"`
+	Queue     QueueType `yaml:"queue"`
+	Rabbit    Rabbit    `yaml:"rabbit"`
+	Kafka     Kafka     `yaml:"kafka"`
+	Schedule  Schedule  `yaml:"schedule"`
+	Retention Retention `yaml:"retention"`
+	Admin     Admin     `yaml:"admin"`
+	Tracing   Tracing   `yaml:"tracing"`
 }
 type Log struct {
 	File  string `yaml:"file"`
@@ -16,9 +23,49 @@ type Rabbit struct {
 	Addr         string `yaml:"addr"`
 	ExchangeName string `yaml:"exchangeName"`
 	QueueName    string `yaml:"queueName"`
+	// MaxInFlight is maximum number of pushed alerts waiting for confirmation of broker.
+	MaxInFlight int `yaml:"maxInFlight"`
+}
+
+type Kafka struct {
+	Addrs   []string `yaml:"addrs"`
+	Topic   string   `yaml:"topic"`
+	GroupID string   `yaml:"groupID"`
 }
 
 type DB struct {
 	Driver string `yaml:"driver"`
 	DSN    string `yaml:"dsn"`
 }
+
+type Schedule struct {
+	Tick      time.Duration `yaml:"tick"`
+	Tolerance time.Duration `yaml:"tolerance"`
+	Lookback  time.Duration `yaml:"lookback"`
+}
+
+type Retention struct {
+	Cron  string        `yaml:"cron"`
+	Mode  string        `yaml:"mode"`
+	Keep  time.Duration `yaml:"keep"`
+	Batch int           `yaml:"batch"`
+	// Users and Calendars are own retention periods by user and calendar IDs.
+	Users     map[string]time.Duration `yaml:"users"`
+	Calendars map[string]time.Duration `yaml:"calendars"`
+}
+
+// Admin is port of admin server with /metrics, /healthz and /readyz endpoints. Heartbeat is how long ago the last
+// scan of reminders may finish before liveness check fails.
+type Admin struct {
+	Port      string        `yaml:"port"`
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+// Tracing exporter is otlp, stdout, file or none, endpoint is OTLP/HTTP url of collector, ratio is share of sampled
+// traces started by the app.
+type Tracing struct {
+	Exporter string  `yaml:"exporter"`
+	Endpoint string  `yaml:"endpoint"`
+	File     string  `yaml:"file"`
+	Ratio    float64 `yaml:"ratio"`
+}
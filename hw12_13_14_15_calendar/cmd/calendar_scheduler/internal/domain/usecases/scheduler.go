@@ -2,11 +2,32 @@ package usecases
 
 import (
 	"context"
+	"time"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 )
 
 type (
 	Scheduler interface {
 		SendAlerts(ctx context.Context)
-		CleanEvents(ctx context.Context, daysToDelete int)
+		// CleanEvents removes old events by retention policy every time cron of cleaning fires.
+		CleanEvents(ctx context.Context, cleaning Cleaning)
+		// RelayOutbox publishes lifecycle messages of events from outbox to notify queue.
+		RelayOutbox(ctx context.Context)
+		// LastScan returns time of the last scan of reminders, it is heartbeat of SendAlerts.
+		LastScan() time.Time
 	}
 )
+
+// Cron returns the next activation time after the given time, zero time means that cron never fires.
+type Cron interface {
+	Next(time.Time) time.Time
+}
+
+// Cleaning describes when and how old events are removed.
+type Cleaning struct {
+	Cron      Cron
+	Retention entities.Retention
+	// Batch is maximum number of events removed in one transaction.
+	Batch int
+}
@@ -3,51 +3,112 @@ package usecases
 import (
 	"context"
 	"fmt"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 const (
 	ErrSend  = "can't send alert"
 	ErrClean = "can't clean events"
+	ErrRelay = "can't relay outbox"
 )
 
 var _ Scheduler = (*SchedulerInteractor)(nil)
 
+// alertsWatermark is name of watermark of reminders scan.
+const alertsWatermark = "alerts"
+
+// defaultLookback is Lookback of Schedule if it isn't set.
+const defaultLookback = 24 * time.Hour
+
+// Schedule describes how reminders are scanned.
+type Schedule struct {
+	// Tick is interval between scans, it can't be longer than Tolerance.
+	Tick time.Duration
+	// Tolerance is how late reminder may fire. Every scan covers Tolerance before the watermark too, so reminder
+	// which became due just before the previous scan (e.g. event is saved by concurrent transaction) isn't missed.
+	Tolerance time.Duration
+	// Lookback limits how old missed reminders are sent, e.g. after scheduler downtime.
+	Lookback time.Duration
+}
+
+// maxRetryDelay limits delay between pushes of failed alert.
+const maxRetryDelay = time.Minute
+
+// retry is due reminder which push failed, it is pushed to the rest of its recipients again with exponential delay.
+// Retry keeps claim of reminder, maxRetryDelay is shorter than NotifyClaimTTL, so claim is renewed before it expires.
+type retry struct {
+	due *entities.DueReminder
+	// userIDs are recipients which didn't get notify yet.
+	userIDs  []string
+	attempts int
+	next     time.Time
+}
+
+func (r *retry) key() string {
+	return fmt.Sprintf("%v/%v/%v", r.due.Event.ID, r.due.Event.DateTime.UnixNano(), r.due.Reminder)
+}
+
+// outboxBatch is number of outbox messages relayed in one transaction.
+const outboxBatch = 100
+
 type SchedulerInteractor struct {
-	events    entities.EventRepo
-	alerts    entities.NotifyQueue
-	logger    usecases.Logger
-	alertCash alertCash
+	events   entities.EventRepo
+	alerts   entities.NotifyQueue
+	schedule Schedule
+	logger   usecases.Logger
+	// retries are alerts which push failed and which claims are held by scheduler, they are used by SendAlerts loop only.
+	retries map[string]*retry
+	// lastScan is unix nano time of the last finished scan, it is heartbeat of SendAlerts loop.
+	lastScan int64
+	// clock returns time of scan.
+	clock func() time.Time
 }
 
-func NewScheduler(eRepo entities.EventRepo, nQueue entities.NotifyQueue, logger usecases.Logger) *SchedulerInteractor {
+func NewScheduler(eRepo entities.EventRepo, nQueue entities.NotifyQueue, schedule Schedule, logger usecases.Logger) *SchedulerInteractor {
+	if schedule.Tick <= 0 {
+		schedule.Tick = time.Second
+	}
+	if schedule.Tolerance <= 0 {
+		schedule.Tolerance = schedule.Tick
+	}
+	if schedule.Tick > schedule.Tolerance {
+		schedule.Tick = schedule.Tolerance
+	}
+	if schedule.Lookback <= 0 {
+		schedule.Lookback = defaultLookback
+	}
+	if schedule.Lookback < schedule.Tolerance {
+		schedule.Lookback = schedule.Tolerance
+	}
 	return &SchedulerInteractor{
-		events: eRepo,
-		alerts: nQueue,
-		logger: logger,
-		alertCash: alertCash{
-			cash:    map[string]time.Time{},
-			RWMutex: &sync.RWMutex{},
-		},
+		events:   eRepo,
+		alerts:   nQueue,
+		schedule: schedule,
+		logger:   logger,
+		retries:  make(map[string]*retry),
+		lastScan: time.Now().UnixNano(),
+		clock:    time.Now,
 	}
 }
 
+// LastScan returns time of the last finished scan of reminders, it is creation time of scheduler before the first scan.
+func (s *SchedulerInteractor) LastScan() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastScan))
+}
+
 func (s *SchedulerInteractor) SendAlerts(ctx context.Context) {
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		s.alertCash.cleanCash(ctx)
-		s.logger.Info(ctx, "stop cleaning alerts from cash")
-	}()
 	loop := true
-	tick := time.NewTicker(time.Second)
+	tick := time.NewTicker(s.schedule.Tick)
 	select {
 	case <-ctx.Done():
 		loop = false
@@ -60,48 +121,151 @@ func (s *SchedulerInteractor) SendAlerts(ctx context.Context) {
 			loop = false
 			tick.Stop()
 			break
-		case <-tick.C: //todo: more correct is notify about all events not be alerting before(eg. when scheduler is off), and add flag "isnotified" in DB, but i decided add local day-alerts alertCash
+		case <-tick.C:
 			s.doSend(ctx)
 		}
 	}
-	wg.Wait()
 }
 
+// doSend pushes every reminder which notify time passed since the previous scan and which isn't notified yet to
+// its owner and accepted attendees. Reminder is claimed by repository, so several schedulers can run concurrently.
+// Reminder is marked notified only after queue confirms pushes to all recipients, failed pushes are retried by
+// the next scans while claim is renewed. Retries are kept in memory only: after restart scan starts from the oldest
+// claimed but not notified reminder, so reminder is claimed again after its claim expires and pushed to all
+// recipients, who skip duplicate by message id.
 func (s *SchedulerInteractor) doSend(ctx context.Context) {
-	events, e := s.events.GetByNotifyDate(ctx, time.Now())
-	if e != nil {
-		s.logger.Error(ctx, errors.Wrap(e, ErrSend))
-	}
-	for _, event := range events {
-		s.alertCash.RLock()
-		if _, ok := s.alertCash.cash[event.ID]; !ok {
-			s.alertCash.cash[event.ID] = event.DateTime
-			s.logger.Info(context.TODO(), fmt.Sprintf(`new event "id:%v title:%v" for alerting is found`, event.ID, event.Title))
-			n := entities.Notify{
-				ID:       event.ID,
-				Title:    event.Title,
-				UserID:   event.UserID,
-				DateTime: event.DateTime,
-			}
+	ctx, span := tracing.Start(ctx, "scheduler.scan")
+	defer span.End()
+	now := s.clock()
+	defer func() {
+		metrics.ObserveScan(time.Since(now))
+		atomic.StoreInt64(&s.lastScan, time.Now().UnixNano())
+	}()
+	s.doRetry(ctx, now)
+	from, err := s.scanFrom(ctx, now)
+	if err != nil {
+		s.logger.Error(ctx, errors.Wrap(err, ErrSend))
+		return
+	}
+	due, err := s.events.FetchDueUnnotified(ctx, from, now)
+	if err != nil {
+		s.logger.Error(ctx, errors.Wrap(err, ErrSend))
+		return
+	}
+	for _, d := range due {
+		r := &retry{due: d, userIDs: d.Event.Recipients()}
+		if existing, ok := s.retries[r.key()]; ok {
+			existing.due = d
+			continue
+		}
+		s.logger.Info(ctx, fmt.Sprintf(`new event "id:%v title:%v" for alerting by reminder %v is found`, d.Event.ID, d.Event.Title, d.Reminder))
+		s.push(ctx, r, now)
+	}
+	if err := s.events.SetWatermark(ctx, alertsWatermark, now); err != nil {
+		s.logger.Error(ctx, errors.Wrap(err, ErrSend))
+	}
+}
 
-			go func() {
-				if err := s.alerts.Push(n); err != nil {
-					s.logger.Error(context.TODO(), errors.Wrapf(err, ErrSend))
-				}
-			}()
+// doRetry renews claim of failed alert and pushes it when its delay is over, alert which is older than Lookback
+// or which claim is lost is dropped.
+func (s *SchedulerInteractor) doRetry(ctx context.Context, now time.Time) {
+	oldest := now.Add(-s.schedule.Lookback)
+	for key, r := range s.retries {
+		if r.due.NotifyTime().Before(oldest) {
+			s.logger.Error(ctx, errors.Errorf("%v: reminder %v of event %v isn't pushed until lookback", ErrSend, r.due.Reminder, r.due.Event.ID))
+			delete(s.retries, key)
+			continue
+		}
+		renewed, err := s.events.RenewClaim(ctx, r.due, now)
+		if err != nil {
+			s.logger.Error(ctx, errors.Wrap(err, ErrSend))
+			continue
+		}
+		if !renewed {
+			s.logger.Info(ctx, "claim of reminder %v of event %v is lost, retry is dropped", r.due.Reminder, r.due.Event.ID)
+			delete(s.retries, key)
+			continue
+		}
+		if r.next.After(now) {
+			continue
+		}
+		s.push(ctx, r, now)
+	}
+}
+
+// push pushes alert to the rest of its recipients and marks it notified, failed alert is put to retries.
+func (s *SchedulerInteractor) push(ctx context.Context, r *retry, now time.Time) {
+	ctx, span := tracing.Start(ctx, "scheduler.push", trace.WithAttributes(tracing.EventID(r.due.Event.ID)))
+	defer span.End()
+	r.userIDs = s.pushRecipients(ctx, r.due, r.userIDs)
+	if len(r.userIDs) > 0 {
+		span.SetStatus(codes.Error, "notify is not pushed to every recipient")
+		delay := s.schedule.Tick << uint(r.attempts)
+		if delay > maxRetryDelay || delay <= 0 {
+			delay = maxRetryDelay
 		}
-		s.alertCash.RUnlock()
+		r.attempts++
+		r.next = now.Add(delay)
+		s.retries[r.key()] = r
+		return
+	}
+	delete(s.retries, r.key())
+	if err := s.events.MarkNotified(ctx, r.due.Event.ID, r.due.Event.DateTime, r.due.Reminder); err != nil {
+		s.logger.Error(ctx, errors.Wrapf(err, ErrSend))
+	}
+}
+
+// scanFrom returns start of scan period: Tolerance before the watermark or the oldest claimed but not notified
+// reminder if it is earlier, but not earlier than Lookback before now.
+func (s *SchedulerInteractor) scanFrom(ctx context.Context, now time.Time) (time.Time, error) {
+	oldest := now.Add(-s.schedule.Lookback)
+	watermark, err := s.events.GetWatermark(ctx, alertsWatermark)
+	if err != nil {
+		return time.Time{}, err
+	}
+	from := watermark.Add(-s.schedule.Tolerance)
+	unnotified, err := s.events.GetOldestUnnotified(ctx, oldest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !unnotified.IsZero() && unnotified.Before(from) {
+		from = unnotified
+	}
+	if from.Before(oldest) {
+		return oldest, nil
 	}
+	return from, nil
 }
 
-func (s *SchedulerInteractor) CleanEvents(ctx context.Context, daysToDelete int) {
+// pushRecipients pushes notify for every recipient of event and returns recipients which pushes failed.
+func (s *SchedulerInteractor) pushRecipients(ctx context.Context, d *entities.DueReminder, userIDs []string) []string {
+	for i, userID := range userIDs {
+		n := entities.Notify{
+			ID:        d.Event.ID,
+			Title:     d.Event.Title,
+			UserID:    userID,
+			DateTime:  d.Event.DateTime,
+			Channel:   d.Reminder.Channel,
+			MessageID: d.MessageID(userID),
+		}
+		err := s.alerts.Push(ctx, n)
+		metrics.AlertPushed(err)
+		if err != nil {
+			s.logger.Error(ctx, errors.Wrapf(err, ErrSend))
+			return userIDs[i:]
+		}
+	}
+	return nil
+}
+
+func (s *SchedulerInteractor) RelayOutbox(ctx context.Context) {
 	loop := true
 	tick := time.NewTicker(time.Second)
 	select {
 	case <-ctx.Done():
 		loop = false
 	default:
-		s.doClean(ctx, daysToDelete)
+		s.doRelay(ctx)
 	}
 	for loop {
 		select {
@@ -110,47 +274,61 @@ func (s *SchedulerInteractor) CleanEvents(ctx context.Context, daysToDelete int)
 			tick.Stop()
 			break
 		case <-tick.C:
-			s.doClean(ctx, daysToDelete)
+			s.doRelay(ctx)
 		}
 	}
 }
 
-func (s *SchedulerInteractor) doClean(ctx context.Context, daysToDelete int) {
-	events, err := s.events.GetForPeriod(ctx, time.Time{}, time.Now().AddDate(0, 0, -daysToDelete))
-	if err != nil {
-		s.logger.Error(context.TODO(), errors.Wrapf(err, ErrClean))
-	}
-	for _, event := range events {
-		err := s.events.DeleteByID(ctx, event.ID)
+// doRelay publishes outbox by batches until it is empty. Message which is not pushed to every recipient stays in outbox,
+// so recipients may get it twice and skip it by message id.
+func (s *SchedulerInteractor) doRelay(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := s.events.RelayOutbox(ctx, outboxBatch, func(m entities.OutboxMessage) error {
+			for _, n := range m.Notifies() {
+				if err := s.alerts.Push(ctx, n); err != nil {
+					return err
+				}
+			}
+			s.logger.Info(ctx, "event %v is %v, message is relayed to %v recipients", m.EventID, m.Kind, len(m.Recipients))
+			return nil
+		})
 		if err != nil {
-			s.logger.Error(context.TODO(), errors.Wrapf(err, ErrClean))
+			s.logger.Error(ctx, errors.Wrap(err, ErrRelay))
+			return
+		}
+		if published < outboxBatch {
+			return
 		}
-		s.logger.Info(context.TODO(), "old event %v is deleted", event.ID)
 	}
 }
 
-type alertCash struct {
-	cash map[string]time.Time
-	*sync.RWMutex
-}
-
-func (a *alertCash) cleanCash(ctx context.Context) {
-	loop := true
-	tick := time.NewTicker(time.Minute)
-	for loop {
+func (s *SchedulerInteractor) CleanEvents(ctx context.Context, cleaning Cleaning) {
+	for {
+		now := time.Now()
+		next := cleaning.Cron.Next(now)
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(now))
 		select {
 		case <-ctx.Done():
-			loop = false
-			tick.Stop()
-			break
-		case <-tick.C:
-			a.Lock()
-			for id, ntime := range a.cash {
-				if ntime.Before(time.Now().AddDate(0, 0, -1)) {
-					delete(a.cash, id)
-				}
-			}
-			a.Unlock()
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.doClean(ctx, cleaning)
+		}
+	}
+}
+
+// doClean removes events by every query of retention, events of users and calendars with own retention are removed by own queries.
+func (s *SchedulerInteractor) doClean(ctx context.Context, cleaning Cleaning) {
+	for _, query := range cleaning.Retention.Queries(time.Now(), cleaning.Batch) {
+		removed, err := s.events.DeleteOlderThan(ctx, query)
+		if err != nil {
+			s.logger.Error(ctx, errors.Wrapf(err, ErrClean))
+		}
+		if removed > 0 {
+			s.logger.Info(ctx, "%v events older than %v are removed by %v mode", removed, query.Before, query.Mode)
 		}
 	}
 }
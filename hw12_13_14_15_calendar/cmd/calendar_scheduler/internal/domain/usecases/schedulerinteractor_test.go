@@ -0,0 +1,311 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
+)
+
+type fakeQueue struct {
+	alerts []entities.Notify
+	fails  int
+}
+
+func (q *fakeQueue) Pull(ctx context.Context, handle entities.NotifyHandler) error {
+	return nil
+}
+
+func (q *fakeQueue) Push(ctx context.Context, n entities.Notify) error {
+	if q.fails > 0 {
+		q.fails--
+		return errors.New("unavailable")
+	}
+	q.alerts = append(q.alerts, n)
+	return nil
+}
+
+// pushFailer fails pushes to one user.
+type pushFailer struct {
+	queue    *fakeQueue
+	failUser string
+}
+
+func (q *pushFailer) Pull(ctx context.Context, handle entities.NotifyHandler) error {
+	return nil
+}
+
+func (q *pushFailer) Push(ctx context.Context, n entities.Notify) error {
+	if n.UserID == q.failUser {
+		return errors.New("unconfirmed")
+	}
+	return q.queue.Push(ctx, n)
+}
+
+func TestRelayOutbox(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "")
+	require.Nil(t, err)
+	id, err := repo.Add(ctx, *event)
+	require.Nil(t, err)
+	require.Nil(t, repo.AddAttendees(ctx, id, []string{"guest"}))
+	require.Nil(t, repo.SetAttendeeStatus(ctx, id, "guest", entities.Accepted))
+	require.Nil(t, repo.DeleteByID(ctx, id))
+
+	queue := &fakeQueue{fails: 1}
+	scheduler := NewScheduler(repo, queue, Schedule{}, logger)
+	scheduler.doRelay(ctx)
+	require.Empty(t, queue.alerts)
+
+	scheduler.doRelay(ctx)
+	require.Len(t, queue.alerts, 3)
+	require.Equal(t, entities.NotifyCreated, queue.alerts[0].Kind)
+	require.Equal(t, "owner", queue.alerts[0].UserID)
+	require.Equal(t, entities.NotifyCancelled, queue.alerts[1].Kind)
+	require.Equal(t, []string{"owner", "guest"}, []string{queue.alerts[1].UserID, queue.alerts[2].UserID})
+	require.NotEqual(t, queue.alerts[1].MessageID, queue.alerts[2].MessageID)
+
+	scheduler.doRelay(ctx)
+	require.Len(t, queue.alerts, 3, "relayed messages are removed from outbox")
+}
+
+func TestSendAlerts(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "30m,2h:email,90m:webhook")
+	require.Nil(t, err)
+	_, err = repo.Add(ctx, *event)
+	require.Nil(t, err)
+
+	queue := &fakeQueue{fails: 1}
+	scheduler := NewScheduler(repo, queue, Schedule{}, logger)
+	created := scheduler.LastScan()
+	scheduler.doSend(ctx)
+	require.True(t, scheduler.LastScan().After(created), "scan is heartbeat")
+	require.Len(t, queue.alerts, 1, "failed reminder stays claimed, the next one is sent")
+	require.Equal(t, entities.ChannelWebhook, queue.alerts[0].Channel)
+	unnotified, err := repo.GetOldestUnnotified(ctx, time.Now().Add(-defaultLookback))
+	require.Nil(t, err)
+	require.Equal(t, event.DateTime.Add(-2*time.Hour), unnotified, "scan starts from failed reminder")
+
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 1, "notified and claimed reminders are skipped")
+}
+
+func TestSendAlertsRetry(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "2h")
+	require.Nil(t, err)
+	id, err := repo.Add(ctx, *event)
+	require.Nil(t, err)
+	require.Nil(t, repo.AddAttendees(ctx, id, []string{"guest"}))
+	require.Nil(t, repo.SetAttendeeStatus(ctx, id, "guest", entities.Accepted))
+
+	queue := &fakeQueue{}
+	scheduler := NewScheduler(repo, &pushFailer{queue: queue, failUser: "guest"}, Schedule{}, logger)
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 1)
+	require.Len(t, scheduler.retries, 1)
+	unnotified, err := repo.GetOldestUnnotified(ctx, time.Now().Add(-defaultLookback))
+	require.Nil(t, err)
+	require.Equal(t, event.DateTime.Add(-2*time.Hour), unnotified, "scan starts from failed reminder")
+
+	scheduler.alerts = queue
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 1, "alert is retried after delay")
+	for _, r := range scheduler.retries {
+		require.Equal(t, 1, r.attempts)
+		r.next = time.Now()
+	}
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 2, "alert is pushed to failed recipient only")
+	require.Equal(t, "guest", queue.alerts[1].UserID)
+	require.Empty(t, scheduler.retries)
+
+	due, err := repo.FetchDueUnnotified(ctx, time.Now().Add(-24*time.Hour), time.Now())
+	require.Nil(t, err)
+	require.Empty(t, due, "reminder is marked notified after all pushes are confirmed")
+	watermark, err := repo.GetWatermark(ctx, alertsWatermark)
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now(), watermark, time.Second)
+}
+
+func TestSendAlertsAfterClaimTTL(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "2h")
+	require.Nil(t, err)
+	_, err = repo.Add(ctx, *event)
+	require.Nil(t, err)
+
+	queue := &fakeQueue{fails: 1}
+	scheduler := NewScheduler(repo, queue, Schedule{}, logger)
+	scheduler.doSend(ctx)
+	require.Empty(t, queue.alerts)
+
+	// scheduler is restarted, so its retries are lost, but scans don't pass the claimed reminder.
+	now := time.Now()
+	for _, after := range []time.Duration{0, time.Minute, entities.NotifyClaimTTL + time.Minute} {
+		scheduler = NewScheduler(repo, queue, Schedule{}, logger)
+		scheduler.clock = func() time.Time { return now.Add(after) }
+		scheduler.doSend(ctx)
+	}
+	require.Len(t, queue.alerts, 1, "reminder is sent after its claim expires")
+	require.Equal(t, "owner", queue.alerts[0].UserID)
+	unnotified, err := repo.GetOldestUnnotified(ctx, now.Add(-defaultLookback))
+	require.Nil(t, err)
+	require.True(t, unnotified.IsZero())
+}
+
+func TestSendAlertsRetryRenewsClaim(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "2h")
+	require.Nil(t, err)
+	_, err = repo.Add(ctx, *event)
+	require.Nil(t, err)
+
+	queue := &fakeQueue{fails: 100}
+	scheduler := NewScheduler(repo, queue, Schedule{}, logger)
+	other := NewScheduler(repo, queue, Schedule{}, logger)
+	now := time.Now()
+	for after := time.Duration(0); after <= 2*entities.NotifyClaimTTL; after += maxRetryDelay {
+		scheduler.clock = func() time.Time { return now.Add(after) }
+		scheduler.doSend(ctx)
+		other.clock = scheduler.clock
+		other.doSend(ctx)
+		require.Empty(t, other.retries, "claim is renewed by retries")
+	}
+	require.Len(t, scheduler.retries, 1)
+
+	queue.fails = 0
+	for _, r := range scheduler.retries {
+		r.next = now
+	}
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 1)
+	require.Equal(t, "owner", queue.alerts[0].UserID)
+}
+
+func TestSendAlertsRetryLostClaim(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "2h")
+	require.Nil(t, err)
+	_, err = repo.Add(ctx, *event)
+	require.Nil(t, err)
+
+	queue := &fakeQueue{fails: 1}
+	scheduler := NewScheduler(repo, queue, Schedule{}, logger)
+	scheduler.doSend(ctx)
+	require.Len(t, scheduler.retries, 1)
+
+	// claim expires while scheduler is stalled, other scheduler claims and sends reminder.
+	now := time.Now().Add(entities.NotifyClaimTTL + time.Minute)
+	other := NewScheduler(repo, queue, Schedule{}, logger)
+	other.clock = func() time.Time { return now }
+	other.doSend(ctx)
+	require.Len(t, queue.alerts, 1)
+
+	scheduler.clock = other.clock
+	scheduler.doSend(ctx)
+	require.Empty(t, scheduler.retries, "retry is dropped when claim is lost")
+	require.Len(t, queue.alerts, 1)
+}
+
+func TestSendAlertsRetryAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	event, err := entities.NewEvent("title", time.Now().Add(time.Hour).Format(entities.LayoutISO), "1h", "text", "owner", "2h")
+	require.Nil(t, err)
+	id, err := repo.Add(ctx, *event)
+	require.Nil(t, err)
+	require.Nil(t, repo.AddAttendees(ctx, id, []string{"guest"}))
+	require.Nil(t, repo.SetAttendeeStatus(ctx, id, "guest", entities.Accepted))
+
+	queue := &fakeQueue{}
+	scheduler := NewScheduler(repo, &pushFailer{queue: queue, failUser: "guest"}, Schedule{}, logger)
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 1)
+
+	// retry is re-derived from claim after restart, recipients skip duplicates by message id.
+	scheduler = NewScheduler(repo, queue, Schedule{}, logger)
+	scheduler.clock = func() time.Time { return time.Now().Add(entities.NotifyClaimTTL + time.Minute) }
+	scheduler.doSend(ctx)
+	require.Len(t, queue.alerts, 3)
+	require.Equal(t, queue.alerts[0].MessageID, queue.alerts[1].MessageID)
+	require.Equal(t, "guest", queue.alerts[2].UserID)
+	require.NotEqual(t, queue.alerts[0].MessageID, queue.alerts[2].MessageID)
+	require.Empty(t, scheduler.retries)
+}
+
+func TestSendAlertsFromWatermark(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	start := time.Now().Add(time.Hour)
+	event, err := entities.NewEvent("title", start.Format(entities.LayoutISO), "1h", "text", "owner", "90m,65m:email,30m")
+	require.Nil(t, err)
+	_, err = repo.Add(ctx, *event)
+	require.Nil(t, err)
+	require.Nil(t, repo.SetWatermark(ctx, alertsWatermark, time.Now().Add(-10*time.Minute)))
+
+	queue := &fakeQueue{}
+	scheduler := NewScheduler(repo, queue, Schedule{Tick: time.Minute, Tolerance: time.Second}, logger)
+	require.Equal(t, time.Second, scheduler.schedule.Tick, "tick is limited by tolerance")
+	scheduler.doSend(ctx)
+
+	require.Len(t, queue.alerts, 1, "reminder scanned before the watermark and future reminder aren't sent")
+	require.Equal(t, entities.ChannelEmail, queue.alerts[0].Channel)
+	watermark, err := repo.GetWatermark(ctx, alertsWatermark)
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now(), watermark, time.Second)
+}
+
+func TestCleanEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := mocks.NewMockLogger()
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+	require.Nil(t, err)
+	for _, userID := range []string{"owner", "vip"} {
+		event, err := entities.NewEvent("title", time.Now().AddDate(0, 0, -10).Format(entities.LayoutISO), "1h", "text", userID, "")
+		require.Nil(t, err)
+		_, err = repo.Add(ctx, *event)
+		require.Nil(t, err)
+	}
+
+	scheduler := NewScheduler(repo, &fakeQueue{}, Schedule{}, logger)
+	scheduler.doClean(ctx, Cleaning{Retention: entities.Retention{
+		Keep:  24 * time.Hour,
+		Users: map[string]time.Duration{"vip": 30 * 24 * time.Hour},
+	}})
+
+	_, err = repo.GetForPeriodByUserID(ctx, "owner", time.Now().AddDate(-1, 0, 0), time.Now())
+	require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "old events of owner must be removed")
+	events, err := repo.GetForPeriodByUserID(ctx, "vip", time.Now().AddDate(-1, 0, 0), time.Now())
+	require.Nil(t, err)
+	require.Len(t, events, 1, "events of vip are kept for a month")
+}
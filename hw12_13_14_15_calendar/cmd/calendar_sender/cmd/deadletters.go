@@ -0,0 +1,48 @@
+/*
+Copyright © 2020 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/data/app"
+)
+
+var replay bool
+var limit int
+
+// deadLettersCmd represents the deadletters command.
+var deadLettersCmd = &cobra.Command{
+	Use:   "deadletters",
+	Short: `inspect and replay dead-lettered notifications`,
+	Long:  `prints notifications which the sender failed to handle as json lines; with --replay they are returned to the queue, broken messages are left in the dead letter queue`,
+	Run: func(cmd *cobra.Command, args []string) {
+		a := app.NewApp()
+		if err := a.DeadLetters(cfg, replay, limit, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deadLettersCmd)
+
+	deadLettersCmd.Flags().BoolVar(&replay, "replay", false, "return dead letters to the queue")
+	deadLettersCmd.Flags().IntVar(&limit, "limit", 0, "maximum number of dead letters, 0 means all of them")
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -80,9 +81,15 @@ func initConfig() {
 	}
 
 	//how can i substitute config param in windows? evsubst is absent =(
+	if dsn, ok := viper.Get("DSN").(string); dsn != "" && ok {
+		cfg.DB.DSN = dsn
+	}
 	if addr, ok := viper.Get("RABBIT_ADDR").(string); addr != "" && ok {
 		cfg.Rabbit.Addr = addr
 	}
+	if addrs, ok := viper.Get("KAFKA_ADDRS").(string); addrs != "" && ok {
+		cfg.Kafka.Addrs = strings.Split(addrs, ",")
+	}
 }
 
 // Execute adds all child cmd to the root cmd and sets flags appropriately.
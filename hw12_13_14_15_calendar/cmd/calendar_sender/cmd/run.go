@@ -26,7 +26,7 @@ import (
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: `the "sender" process`,
-	Long:  `the "sender" process, which reads messages from the queue and sends notifications;you do not need to send directly - it is enough to log messages / output to STDOUT`,
+	Long:  `the "sender" process, which reads messages from the queue and sends notifications;notifications are delivered by the notifier (smtp, webhook or file) chosen in config`,
 	Run: func(cmd *cobra.Command, args []string) {
 		a := app.NewApp()
 		if err := a.Run(cfg, debug); err != nil {
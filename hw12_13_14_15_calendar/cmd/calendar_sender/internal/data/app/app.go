@@ -2,17 +2,29 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/signal"
 	"sync"
 
 	"github.com/pkg/errors"
 
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/data/controllers/notifier"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/adminserver"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/queueservice/kafkaservice"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/queueservice/rabbitservice"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/db"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	mainusecase "github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/logger"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 type App struct {
@@ -22,6 +34,13 @@ func NewApp() *App {
 	return &App{}
 }
 
+type RepoType string
+
+const (
+	dbRepo       RepoType = "db"
+	inmemoryRepo RepoType = "inmemory"
+)
+
 type QueueType string
 
 const (
@@ -29,6 +48,14 @@ const (
 	kafka  QueueType = "kafka"
 )
 
+type NotifierType string
+
+const (
+	smtpNotifier    NotifierType = "smtp"
+	webhookNotifier NotifierType = "webhook"
+	fileNotifier    NotifierType = "file"
+)
+
 func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -46,12 +73,57 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 		return errors.Wrapf(err, "can't init logger")
 	}
 
+	shutdownTracing, err := tracing.Init(tracing.Config{
+		Service:  "calendar_sender",
+		Exporter: cfg.Tracing.Exporter,
+		Endpoint: cfg.Tracing.Endpoint,
+		File:     cfg.Tracing.File,
+		Ratio:    cfg.Tracing.Ratio,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "can't init tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error(context.Background(), errors.Wrap(err, "can't flush spans"))
+		}
+	}()
+
+	repo, err := InitRepo(cfg, logger)
+	if err != nil {
+		return errors.Wrapf(err, "can't init repository")
+	}
+	checks := health.NewHealth(logger)
+	if dbRepo, ok := repo.(*db.EventRepo); ok {
+		checks.AddReadiness("postgres", health.CheckerFunc(func(ctx context.Context) error {
+			return dbRepo.Connect(ctx, cfg.DB.DSN)
+		}))
+	}
+	repo = metrics.NewEventRepo(repo, string(cfg.RepoType))
+
 	broker, err := InitQueue(cfg, logger)
 	if err != nil {
 		return errors.Wrapf(err, "can't init queue broker")
 	}
+	if checker, ok := broker.(health.Checker); ok {
+		checks.AddReadiness(string(cfg.Queue), checker)
+	}
 
-	scheduler := usecases.NewSender(broker, logger)
+	notifier, err := InitNotifier(cfg)
+	if err != nil {
+		return errors.Wrapf(err, "can't init notifier")
+	}
+
+	retry := usecases.Retry{
+		Attempts: cfg.Notifier.Retry.Attempts,
+		Delay:    cfg.Notifier.Retry.Delay,
+		MaxDelay: cfg.Notifier.Retry.MaxDelay,
+	}
+	scheduler := usecases.NewSender(broker, repo, notifier, retry, cfg.Handled.TTL, logger)
+
+	adminServer := adminserver.NewAdminServer(net.JoinHostPort("0.0.0.0", cfg.Admin.Port), checks, logger)
+	go adminServer.Serve()
+	defer adminServer.StopServe()
 
 	done := make(chan os.Signal, 2)
 	signal.Notify(done, os.Interrupt)
@@ -73,17 +145,139 @@ func (a *App) Run(cfg *Config, isDebug bool) (err error) {
 	return nil
 }
 
+// DeadLetters writes up to limit dead-lettered notifies to out as json lines, they are returned to the queue if replay is set.
+func (a *App) DeadLetters(cfg *Config, replay bool, limit int, out io.Writer) error {
+	ctx := context.Background()
+	logger, err := logger.NewLogger(os.Stderr, cfg.Log.Level)
+	if err != nil {
+		return errors.Wrapf(err, "can't init logger")
+	}
+	if cfg.Queue != rabbit {
+		return errors.Errorf("dead letters are supported by %v queue only", rabbit)
+	}
+	broker, err := InitQueue(cfg, logger)
+	if err != nil {
+		return errors.Wrapf(err, "can't init queue broker")
+	}
+	dlq := broker.(entities.DeadLetterQueue)
+
+	if replay {
+		replayed, err := dlq.Replay(ctx, limit)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%v dead letters are replayed\n", replayed)
+		return err
+	}
+	letters, err := dlq.DeadLetters(ctx, limit)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	for _, letter := range letters {
+		if err := enc.Encode(letter); err != nil {
+			return errors.Wrapf(err, "can't write dead letter")
+		}
+	}
+	return nil
+}
+
+func InitRepo(cfg *Config, logger mainusecase.Logger) (entities.EventRepo, error) {
+	switch cfg.RepoType {
+	case dbRepo:
+		repo, err := db.NewDBEventRepo(cfg.DB.Driver, cfg.DB.DSN, logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init db repository")
+		}
+		return repo, nil
+	case inmemoryRepo:
+		repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init inmemo repository")
+		}
+		return repo, nil
+	default:
+		return nil, errors.New("unknown repository type. I know next types:db-database,inmemory-map struct into app")
+	}
+}
+
 func InitQueue(cfg *Config, logger mainusecase.Logger) (entities.NotifyQueue, error) {
 	switch cfg.Queue {
 	case rabbit:
-		broker, err := rabbitservice.NewRabbitManager(cfg.Rabbit.Addr, cfg.Rabbit.ExchangeName, cfg.Rabbit.QueueName, logger)
+		broker, err := rabbitservice.NewRabbitManager(cfg.Rabbit.Addr, cfg.Rabbit.ExchangeName, cfg.Rabbit.QueueName, cfg.Rabbit.MaxRedelivery, 0, logger)
 		if err != nil {
 			return nil, errors.Wrapf(err, "can't init db repository")
 		}
 		return broker, nil
 	case kafka:
-		return nil, errors.New("kafka broker is not supported")
+		broker, err := kafkaservice.NewKafkaManager(cfg.Kafka.Addrs, cfg.Kafka.Topic, cfg.Kafka.GroupID, logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init kafka broker")
+		}
+		return broker, nil
 	default:
 		return nil, errors.New("unknown queue type. I know next types:rabbit,kafka")
 	}
 }
+
+// InitNotifier returns notifier of configured type, alerts of reminder channels are routed to notifiers configured for channels.
+func InitNotifier(cfg *Config) (usecases.Notifier, error) {
+	notifiers := map[NotifierType]usecases.Notifier{}
+	get := func(t NotifierType) (usecases.Notifier, error) {
+		if n, ok := notifiers[t]; ok {
+			return n, nil
+		}
+		n, err := newNotifier(cfg, t)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[t] = n
+		return n, nil
+	}
+
+	def, err := get(cfg.Notifier.Type)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Notifier.Channels) == 0 {
+		return def, nil
+	}
+	channels := make(map[entities.Channel]usecases.Notifier, len(cfg.Notifier.Channels))
+	for channel, t := range cfg.Notifier.Channels {
+		c, err := entities.ParseChannel(channel)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init notifier of channel %v", channel)
+		}
+		if channels[c], err = get(t); err != nil {
+			return nil, err
+		}
+	}
+	return notifier.NewRouter(def, channels), nil
+}
+
+func newNotifier(cfg *Config, t NotifierType) (usecases.Notifier, error) {
+	switch t {
+	case smtpNotifier:
+		c := cfg.Notifier.SMTP
+		n, err := notifier.NewSMTPNotifier(c.Addr, c.Username, c.Password, c.From, c.To)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init smtp notifier")
+		}
+		return n, nil
+	case webhookNotifier:
+		c := cfg.Notifier.Webhook
+		n, err := notifier.NewWebhookNotifier(c.URL, c.Secret, c.Timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init webhook notifier")
+		}
+		return n, nil
+	case fileNotifier:
+		n, err := notifier.NewFileNotifier(cfg.Notifier.File.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't init file notifier")
+		}
+		return n, nil
+	default:
+		return nil, errors.New("unknown notifier type. I know next types:smtp,webhook,file")
+	}
+}
@@ -1,17 +1,92 @@
 package app
 
+import "time"
+
 type Config struct {
-	Log    Log       `yaml:"log"`
-	Queue  QueueType `yaml:"queue"`
-	Rabbit Rabbit    `yaml:"rabbit"`
+	Log      Log          `yaml:"log"`
+	RepoType RepoType     `yaml:"repotype"`
+	DB       DB           `yaml:"db"`
+	Handled  Handled      `yaml:"handled"`
+	Queue    QueueType    `yaml:"queue"`
+	Rabbit   Rabbit       `yaml:"rabbit"`
+	Kafka    Kafka        `yaml:"kafka"`
+	Notifier NotifierConf `yaml:"notifier"`
+	Admin    Admin        `yaml:"admin"`
+	Tracing  Tracing      `yaml:"tracing"`
 }
 type Log struct {
 	File  string `yaml:"file"`
 	Level string `yaml:"level"`
 }
 
+type DB struct {
+	Driver string `yaml:"driver"`
+	DSN    string `yaml:"dsn"`
+}
+
+// Handled is how long ids of delivered lifecycle messages are kept in repository to skip their duplicates.
+type Handled struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
 type Rabbit struct {
 	Addr         string `yaml:"addr"`
 	ExchangeName string `yaml:"exchangeName"`
 	QueueName    string `yaml:"queueName"`
+	// MaxRedelivery is count of failed handlings after which notify is moved to dead letter queue.
+	MaxRedelivery int `yaml:"maxRedelivery"`
+}
+
+type Kafka struct {
+	Addrs   []string `yaml:"addrs"`
+	Topic   string   `yaml:"topic"`
+	GroupID string   `yaml:"groupID"`
+}
+
+type NotifierConf struct {
+	Type NotifierType `yaml:"type"`
+	// Channels maps reminder channel to notifier type, alerts of other channels are delivered by notifier of Type.
+	Channels map[string]NotifierType `yaml:"channels"`
+	Retry    Retry                   `yaml:"retry"`
+	SMTP     SMTP                    `yaml:"smtp"`
+	Webhook  Webhook                 `yaml:"webhook"`
+	File     File                    `yaml:"file"`
+}
+
+type Retry struct {
+	Attempts int           `yaml:"attempts"`
+	Delay    time.Duration `yaml:"delay"`
+	MaxDelay time.Duration `yaml:"maxDelay"`
+}
+
+type SMTP struct {
+	Addr     string `yaml:"addr"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+}
+
+type Webhook struct {
+	URL     string        `yaml:"url"`
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type File struct {
+	Path string `yaml:"path"`
+}
+
+// Admin is port of admin server with /metrics, /healthz and /readyz endpoints.
+type Admin struct {
+	Port string `yaml:"port"`
+}
+
+// Tracing exporter is otlp, stdout, file or none, endpoint is OTLP/HTTP url of collector, ratio is share of sampled
+// traces started by the app.
+type Tracing struct {
+	Exporter string  `yaml:"exporter"`
+	Endpoint string  `yaml:"endpoint"`
+	File     string  `yaml:"file"`
+	Ratio    float64 `yaml:"ratio"`
 }
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const (
+	ErrFileCreate = "can't create file notifier"
+	ErrFileWrite  = "can't write alert to journal"
+)
+
+var _ usecases.Notifier = (*FileNotifier)(nil)
+
+// FileNotifier appends alerts to the journal file, one json record per line.
+type FileNotifier struct {
+	mu   *sync.Mutex
+	file *os.File
+}
+
+type record struct {
+	SentAt time.Time
+	entities.Notify
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrFileCreate)
+	}
+	return &FileNotifier{
+		mu:   &sync.Mutex{},
+		file: file,
+	}, nil
+}
+
+func (f *FileNotifier) Notify(ctx context.Context, alert entities.Notify) error {
+	line, err := json.Marshal(record{SentAt: time.Now().UTC(), Notify: alert})
+	if err != nil {
+		return errors.Wrap(err, ErrFileWrite)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, ErrFileWrite)
+	}
+	if err := f.file.Sync(); err != nil {
+		return errors.Wrap(err, ErrFileWrite)
+	}
+	return nil
+}
+
+func (f *FileNotifier) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+var testAlert = entities.Notify{
+	ID:       "00112233-4455-6677-8899-aabbccddeeff",
+	Title:    "title",
+	UserID:   "11112233-4455-6677-8899-aabbccddeeff",
+	DateTime: time.Date(2020, 7, 7, 12, 12, 12, 0, time.UTC),
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	ctx := context.Background()
+	t.Run("signed delivery", func(t *testing.T) {
+		var received entities.Notify
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.Nil(t, err)
+			require.Equal(t, Sign([]byte("secret"), r.Header.Get(TimestampHeader), body), r.Header.Get(SignatureHeader))
+			require.Nil(t, json.Unmarshal(body, &received))
+		}))
+		defer server.Close()
+		n, err := NewWebhookNotifier(server.URL, "secret", time.Second)
+		require.Nil(t, err)
+
+		require.Nil(t, n.Notify(ctx, testAlert))
+		require.Equal(t, testAlert, received)
+	})
+	t.Run("receiver error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		n, err := NewWebhookNotifier(server.URL, "secret", time.Second)
+		require.Nil(t, err)
+
+		err = n.Notify(ctx, testAlert)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "502")
+	})
+}
+
+func TestFileNotifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notifier")
+	require.Nil(t, err)
+	path := filepath.Join(dir, "alerts.log")
+	n, err := NewFileNotifier(path)
+	require.Nil(t, err)
+
+	require.Nil(t, n.Notify(context.Background(), testAlert))
+	require.Nil(t, n.Notify(context.Background(), testAlert))
+	require.Nil(t, n.Close())
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var r record
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &r))
+	require.Equal(t, testAlert, r.Notify)
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	n, err := NewSMTPNotifier("localhost:25", "", "", "calendar@localhost", "{user}@example.com")
+	require.Nil(t, err)
+	var to []string
+	var msg string
+	n.send = func(addr string, a smtp.Auth, from string, rcpt []string, body []byte) error {
+		to, msg = rcpt, string(body)
+		return nil
+	}
+
+	require.Nil(t, n.Notify(context.Background(), testAlert))
+	require.Equal(t, []string{testAlert.UserID + "@example.com"}, to)
+	require.Contains(t, msg, "Subject: Reminder: title")
+
+	cancelled := testAlert
+	cancelled.Kind = entities.NotifyCancelled
+	require.Nil(t, n.Notify(context.Background(), cancelled))
+	require.Contains(t, msg, "Subject: Event cancelled: title")
+
+	injected := testAlert
+	injected.Title = "title\r\nBcc: victim@example.com"
+	require.Nil(t, n.Notify(context.Background(), injected))
+	require.Equal(t, []string{testAlert.UserID + "@example.com"}, to)
+	headers := strings.Split(msg[:strings.Index(msg, "\r\n\r\n")], "\r\n")
+	require.Len(t, headers, 5, "title doesn't add headers")
+	subject, err := new(mime.WordDecoder).DecodeHeader(strings.TrimPrefix(headers[2], "Subject: "))
+	require.Nil(t, err)
+	require.Equal(t, "Reminder: "+injected.Title, subject)
+
+	_, err = NewSMTPNotifier("localhost", "", "", "calendar@localhost", "{user}@example.com")
+	require.NotNil(t, err, "port must be set")
+}
+
+type channelNotifier struct {
+	alerts []entities.Notify
+}
+
+func (c *channelNotifier) Notify(ctx context.Context, alert entities.Notify) error {
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	def, email := &channelNotifier{}, &channelNotifier{}
+	r := NewRouter(def, map[entities.Channel]usecases.Notifier{entities.ChannelEmail: email})
+
+	byEmail := testAlert
+	byEmail.Channel = entities.ChannelEmail
+	byWebhook := testAlert
+	byWebhook.Channel = entities.ChannelWebhook
+	for _, alert := range []entities.Notify{testAlert, byEmail, byWebhook} {
+		require.Nil(t, r.Notify(context.Background(), alert))
+	}
+
+	require.Equal(t, []entities.Notify{byEmail}, email.alerts)
+	require.Equal(t, []entities.Notify{testAlert, byWebhook}, def.alerts, "channel without notifier is delivered by default notifier")
+}
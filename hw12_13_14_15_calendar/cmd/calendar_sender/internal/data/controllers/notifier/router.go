@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+var _ usecases.Notifier = (*Router)(nil)
+
+// Router delivers alert by notifier of its reminder channel, alert of unknown or default channel is delivered by default notifier.
+type Router struct {
+	def      usecases.Notifier
+	channels map[entities.Channel]usecases.Notifier
+}
+
+func NewRouter(def usecases.Notifier, channels map[entities.Channel]usecases.Notifier) *Router {
+	return &Router{
+		def:      def,
+		channels: channels,
+	}
+}
+
+func (r *Router) Notify(ctx context.Context, alert entities.Notify) error {
+	if n, ok := r.channels[alert.Channel]; ok {
+		return n.Notify(ctx, alert)
+	}
+	return r.def.Notify(ctx, alert)
+}
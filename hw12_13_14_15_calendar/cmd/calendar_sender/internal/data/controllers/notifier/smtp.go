@@ -0,0 +1,87 @@
+// Package notifier contains delivery channels of the sender.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const (
+	ErrSMTPCreate = "can't create smtp notifier"
+	ErrSMTPSend   = "can't send email"
+	// UserPlaceholder is replaced by user id in the recipient template, e.g. "{user}@example.com".
+	UserPlaceholder = "{user}"
+)
+
+var _ usecases.Notifier = (*SMTPNotifier)(nil)
+
+// SMTPNotifier sends alert as plain text email.
+type SMTPNotifier struct {
+	addr, from, to string
+	auth           smtp.Auth
+	send           func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewSMTPNotifier(addr, username, password, from, to string) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrSMTPCreate)
+	}
+	if from == "" || to == "" {
+		return nil, errors.Wrap(errors.New("sender and recipient must be set"), ErrSMTPCreate)
+	}
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{
+		addr: addr,
+		from: from,
+		to:   to,
+		auth: auth,
+		send: smtp.SendMail,
+	}, nil
+}
+
+func (s *SMTPNotifier) Notify(ctx context.Context, alert entities.Notify) error {
+	to := strings.ReplaceAll(s.to, UserPlaceholder, alert.UserID)
+	if err := s.send(s.addr, s.auth, s.from, []string{to}, s.message(to, alert)); err != nil {
+		return errors.Wrap(err, ErrSMTPSend)
+	}
+	return nil
+}
+
+func (s *SMTPNotifier) message(to string, alert entities.Notify) []byte {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %v\r\n", s.from)
+	fmt.Fprintf(msg, "To: %v\r\n", to)
+	subject, text := summary(alert)
+	//title is set by user, so it is encoded to keep line breaks out of headers
+	fmt.Fprintf(msg, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", subject+": "+alert.Title))
+	fmt.Fprint(msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(msg, "Event %q "+text+" %v.\r\n", alert.Title, alert.DateTime.Format(entities.LayoutISO))
+	return msg.Bytes()
+}
+
+// summary returns subject prefix and body verb of email by kind of alert.
+func summary(alert entities.Notify) (subject, text string) {
+	switch alert.Kind {
+	case entities.NotifyCreated:
+		return "New event", "is planned at"
+	case entities.NotifyRescheduled:
+		return "Event rescheduled", "now starts at"
+	case entities.NotifyCancelled:
+		return "Event cancelled", "is cancelled, it was planned at"
+	}
+	return "Reminder", "starts at"
+}
@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/cmd/calendar_sender/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+const (
+	ErrWebhookCreate = "can't create webhook notifier"
+	ErrWebhookSend   = "can't send webhook"
+	ErrWebhookStatus = "webhook receiver responded with status %v"
+
+	// SignatureHeader contains hex HMAC-SHA256 of "<timestamp>.<body>" signed by shared secret.
+	SignatureHeader = "X-Calendar-Signature"
+	TimestampHeader = "X-Calendar-Timestamp"
+)
+
+var _ usecases.Notifier = (*WebhookNotifier)(nil)
+
+// WebhookNotifier posts alert as json to the receiver url.
+type WebhookNotifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string, timeout time.Duration) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, errors.Wrap(errors.New("url must be set"), ErrWebhookCreate)
+	}
+	return &WebhookNotifier{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, alert entities.Notify) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return errors.Wrap(err, ErrWebhookSend)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, ErrWebhookSend)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(w.secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, ErrWebhookSend)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Wrap(fmt.Errorf(ErrWebhookStatus, resp.StatusCode), ErrWebhookSend)
+	}
+	return nil
+}
+
+// Sign returns signature which receiver can use to check that webhook is sent by the calendar.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
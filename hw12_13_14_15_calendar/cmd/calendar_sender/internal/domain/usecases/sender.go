@@ -2,10 +2,16 @@ package usecases
 
 import (
 	"context"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 )
 
 type (
 	Sender interface {
 		SendingAlerts(ctx context.Context) error
 	}
+	// Notifier delivers alert to the user by some channel (email, webhook, file etc.).
+	Notifier interface {
+		Notify(ctx context.Context, alert entities.Notify) error
+	}
 )
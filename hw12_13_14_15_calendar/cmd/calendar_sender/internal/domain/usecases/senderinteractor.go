@@ -2,60 +2,150 @@ package usecases
 
 import (
 	"context"
-	"fmt"
-	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 const (
-	ErrSend  = "can't send alert"
-	ErrClean = "can't clean events"
+	ErrSend     = "can't send alert"
+	ErrDeliver  = "can't deliver alert %v after %v attempts"
+	ErrAttempt  = "attempt %v to deliver alert %v is failed"
+	ErrReserve  = "can't reserve delivery of message %v"
+	ErrComplete = "can't save delivery of message %v"
 )
 
 var _ Sender = (*SenderInteractor)(nil)
 
+// DefaultHandledTTL is how long ids of delivered messages are kept to skip their duplicates if it isn't set.
+const DefaultHandledTTL = 24 * time.Hour
+
+// deliveryPendingTTL is how long message being delivered is hidden from other senders, it is longer than retries of delivery.
+const deliveryPendingTTL = 10 * time.Minute
+
+// deliveredResponse is response of idempotency record of delivered message.
+const deliveredResponse = "delivered"
+
+// Retry describes how failed delivery is repeated: delay is doubled after every attempt up to MaxDelay.
+type Retry struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
 type SenderInteractor struct {
-	alerts entities.NotifyQueue
-	logger usecases.Logger
+	alerts     entities.NotifyQueue
+	deliveries entities.IdempotencyRepo
+	notifier   Notifier
+	retry      Retry
+	handledTTL time.Duration
+	logger     usecases.Logger
 }
 
-func NewSender(nQueue entities.NotifyQueue, logger usecases.Logger) *SenderInteractor {
+// NewSender returns sender which keeps ids of delivered lifecycle messages in deliveries for handledTTL, so every
+// sender skips their duplicates.
+func NewSender(nQueue entities.NotifyQueue, deliveries entities.IdempotencyRepo, notifier Notifier, retry Retry, handledTTL time.Duration, logger usecases.Logger) *SenderInteractor {
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+	if handledTTL <= 0 {
+		handledTTL = DefaultHandledTTL
+	}
 	return &SenderInteractor{
-		alerts: nQueue,
-		logger: logger,
+		alerts:     nQueue,
+		deliveries: deliveries,
+		notifier:   notifier,
+		retry:      retry,
+		handledTTL: handledTTL,
+		logger:     logger,
 	}
 }
 
-func (s *SenderInteractor) processAlert(ctx context.Context, alerts <-chan entities.Notify) {
-	loop := true
-	for loop {
+// processAlert delivers alert with retries. Error means that alert is not delivered and must stay in the queue.
+// Lifecycle message with already delivered message id is acknowledged without delivery, message being delivered by
+// another sender stays in the queue.
+func (s *SenderInteractor) processAlert(ctx context.Context, alert entities.Notify) (err error) {
+	ctx, span := tracing.Start(ctx, "sender.deliver", trace.WithAttributes(tracing.EventID(alert.ID)))
+	defer func() { tracing.End(span, err) }()
+
+	if alert.MessageID != "" {
+		var duplicate bool
+		if duplicate, err = s.reserve(ctx, alert); err != nil {
+			return err
+		}
+		if duplicate {
+			s.logger.Info(ctx, "message %v is already delivered, duplicate is skipped", alert.MessageID)
+			metrics.Deliver(string(alert.Channel), metrics.Duplicate)
+			return nil
+		}
+		defer func() { s.complete(ctx, alert, err) }()
+	}
+	delay := s.retry.Delay
+	for attempt := 1; attempt <= s.retry.Attempts; attempt++ {
+		if err = s.notifier.Notify(ctx, alert); err == nil {
+			s.logger.Info(ctx, "alert %v for user %v is delivered", alert.ID, alert.UserID)
+			metrics.Deliver(string(alert.Channel), metrics.Delivered)
+			return nil
+		}
+		s.logger.Warn(ctx, errors.Wrapf(err, ErrAttempt, attempt, alert.ID).Error())
+		if attempt == s.retry.Attempts {
+			break
+		}
 		select {
-		case alert, ok := <-alerts:
-			if !ok {
-				loop = false
-				break
-			}
-			fmt.Printf("%+v\n", alert)
 		case <-ctx.Done():
-			loop = false
-			break
+			metrics.Deliver(string(alert.Channel), metrics.Failed)
+			return errors.Wrapf(ctx.Err(), ErrDeliver, alert.ID, attempt)
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if s.retry.MaxDelay > 0 && delay > s.retry.MaxDelay {
+			delay = s.retry.MaxDelay
 		}
 	}
+	metrics.Deliver(string(alert.Channel), metrics.Failed)
+	return errors.Wrapf(err, ErrDeliver, alert.ID, s.retry.Attempts)
 }
-func (s *SenderInteractor) SendingAlerts(ctx context.Context) error {
-	notifies := make(chan entities.Notify)
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		s.processAlert(ctx, notifies)
-	}()
-	err := s.alerts.Pull(ctx, notifies)
+
+// reserve saves id of lifecycle message as being delivered, it returns true if message is already delivered.
+func (s *SenderInteractor) reserve(ctx context.Context, alert entities.Notify) (bool, error) {
+	now := time.Now()
+	existing, err := s.deliveries.ReserveIdempotency(ctx, entities.Idempotency{
+		UserID:      alert.UserID,
+		Key:         entities.DeliveryKey(alert.MessageID),
+		Fingerprint: entities.Fingerprint("deliver", alert.MessageID),
+		ExpiresAt:   now.Add(deliveryPendingTTL),
+	}, now)
+	if err != nil {
+		return false, errors.Wrapf(err, ErrReserve, alert.MessageID)
+	}
+	if existing == nil {
+		return false, nil
+	}
+	if existing.InProgress() {
+		return false, errors.Wrapf(entities.ErrIdempotencyPending, ErrReserve, alert.MessageID)
+	}
+	return true, nil
+}
+
+// complete keeps id of delivered message for handledTTL, id of not delivered message is released for redelivery.
+func (s *SenderInteractor) complete(ctx context.Context, alert entities.Notify, deliverErr error) {
+	var err error
+	if deliverErr != nil {
+		err = s.deliveries.ReleaseIdempotency(ctx, alert.UserID, entities.DeliveryKey(alert.MessageID))
+	} else {
+		err = s.deliveries.CompleteIdempotency(ctx, alert.UserID, entities.DeliveryKey(alert.MessageID), deliveredResponse, time.Now().Add(s.handledTTL))
+	}
 	if err != nil {
-		close(notifies)
+		s.logger.Error(ctx, errors.Wrapf(err, ErrComplete, alert.MessageID))
 	}
-	wg.Wait()
-	return err
+}
+
+func (s *SenderInteractor) SendingAlerts(ctx context.Context) error {
+	return errors.Wrap(s.alerts.Pull(ctx, s.processAlert), ErrSend)
 }
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
+)
+
+type fakeQueue struct {
+	alerts []entities.Notify
+	acked  []bool
+}
+
+func (q *fakeQueue) Pull(ctx context.Context, handle entities.NotifyHandler) error {
+	for _, n := range q.alerts {
+		q.acked = append(q.acked, handle(ctx, n) == nil)
+	}
+	return nil
+}
+
+func (q *fakeQueue) Push(ctx context.Context, n entities.Notify) error {
+	q.alerts = append(q.alerts, n)
+	return nil
+}
+
+type fakeNotifier struct {
+	fails int
+	calls int
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, alert entities.Notify) error {
+	n.calls++
+	if n.calls <= n.fails {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestSendingAlerts(t *testing.T) {
+	l := mocks.NewMockLogger()
+	retry := Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	tCases := []struct {
+		title         string
+		fails         int
+		expectedCalls int
+		expectedAck   bool
+	}{
+		{"delivered at once", 0, 1, true},
+		{"delivered after retries", 2, 3, true},
+		{"not delivered", 5, 3, false},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.title, func(t *testing.T) {
+			q := &fakeQueue{}
+			require.Nil(t, q.Push(context.Background(), entities.Notify{ID: "id"}))
+			n := &fakeNotifier{fails: tCase.fails}
+
+			require.Nil(t, NewSender(q, newRepo(t), n, retry, 0, l).SendingAlerts(context.Background()))
+
+			require.Equal(t, tCase.expectedCalls, n.calls)
+			require.Equal(t, []bool{tCase.expectedAck}, q.acked)
+		})
+	}
+}
+
+func newRepo(t *testing.T) entities.IdempotencyRepo {
+	repo, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), mocks.NewMockLogger())
+	require.Nil(t, err)
+	return repo
+}
+
+func TestSendingDuplicates(t *testing.T) {
+	q := &fakeQueue{}
+	require.Nil(t, q.Push(context.Background(), entities.Notify{ID: "id", UserID: "user", Kind: entities.NotifyCancelled, MessageID: "outbox-1-user"}))
+	require.Nil(t, q.Push(context.Background(), entities.Notify{ID: "id", UserID: "user", Kind: entities.NotifyCancelled, MessageID: "outbox-1-user"}))
+	require.Nil(t, q.Push(context.Background(), entities.Notify{ID: "id"}))
+	require.Nil(t, q.Push(context.Background(), entities.Notify{ID: "id"}))
+	n := &fakeNotifier{}
+
+	require.Nil(t, NewSender(q, newRepo(t), n, Retry{}, 0, mocks.NewMockLogger()).SendingAlerts(context.Background()))
+
+	require.Equal(t, 3, n.calls, "reminders have no message id and aren't skipped")
+	require.Equal(t, []bool{true, true, true, true}, q.acked)
+}
+
+func TestSendingDuplicatesAfterRestart(t *testing.T) {
+	repo := newRepo(t)
+	alert := entities.Notify{ID: "id", UserID: "user", Kind: entities.NotifyCancelled, MessageID: "outbox-1-user"}
+
+	failed := &fakeNotifier{fails: 1}
+	q := &fakeQueue{alerts: []entities.Notify{alert}}
+	require.Nil(t, NewSender(q, repo, failed, Retry{}, 0, mocks.NewMockLogger()).SendingAlerts(context.Background()))
+	require.Equal(t, []bool{false}, q.acked, "failed delivery is released for redelivery")
+
+	// redelivered message is handled by another sender, its duplicate by the third one.
+	for _, calls := range []int{1, 0} {
+		n := &fakeNotifier{}
+		q := &fakeQueue{alerts: []entities.Notify{alert}}
+		require.Nil(t, NewSender(q, repo, n, Retry{}, 0, mocks.NewMockLogger()).SendingAlerts(context.Background()))
+		require.Equal(t, calls, n.calls)
+		require.Equal(t, []bool{true}, q.acked)
+	}
+}
+
+func TestSendingWithRequestKeyOfMessageID(t *testing.T) {
+	repo := newRepo(t)
+	alert := entities.Notify{ID: "id", UserID: "user", Kind: entities.NotifyCancelled, MessageID: "outbox-1-user"}
+	// request of the user with idempotency key equal to message id is in progress.
+	existing, err := repo.ReserveIdempotency(context.Background(), entities.Idempotency{
+		UserID: alert.UserID, Key: alert.MessageID, Fingerprint: entities.Fingerprint("delete", "id"), ExpiresAt: time.Now().Add(time.Hour),
+	}, time.Now())
+	require.Nil(t, err)
+	require.Nil(t, existing)
+
+	n := &fakeNotifier{}
+	q := &fakeQueue{alerts: []entities.Notify{alert}}
+	require.Nil(t, NewSender(q, repo, n, Retry{}, 0, mocks.NewMockLogger()).SendingAlerts(context.Background()))
+	require.Equal(t, 1, n.calls, "records of requests and deliveries don't share keys")
+	require.Equal(t, []bool{true}, q.acked)
+}
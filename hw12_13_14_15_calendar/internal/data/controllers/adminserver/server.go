@@ -0,0 +1,46 @@
+package adminserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+)
+
+// AdminServer serves operational endpoints on own port, so they aren't exposed with api: /metrics for prometheus,
+// /healthz and /readyz for probes of orchestrator.
+type AdminServer struct {
+	logger usecases.Logger
+	server *http.Server
+}
+
+func NewAdminServer(addr string, checks *health.Health, logger usecases.Logger) *AdminServer {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", checks.LiveHandler())
+	mux.Handle("/readyz", checks.ReadyHandler())
+	return &AdminServer{
+		logger: logger,
+		server: &http.Server{Addr: addr, Handler: mux},
+	}
+}
+
+func (s *AdminServer) Serve() {
+	s.logger.Info(context.Background(), "starting admin server at %v", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+		s.logger.Error(context.Background(), errors.Wrapf(err, "can't start admin server at %v", s.server.Addr))
+	}
+}
+
+func (s *AdminServer) StopServe() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Error(ctx, "can't stop admin server with error: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
+)
+
+const (
+	ModeJWT    = "jwt"
+	ModeHeader = "header"
+
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	DefaultUserClaim = "sub"
+	bearerPrefix     = "bearer "
+
+	// minSecretLen is minimal length of HS256 secret, key must be as long as hash output (RFC 7518).
+	minSecretLen = 32
+	// devSecret was default secret of shipped config, it is public, so it is never accepted.
+	devSecret = "calendar-dev-secret"
+)
+
+var (
+	ErrNoCredentials = errors.New("no credentials")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrNoUserClaim   = errors.New("token has no user id claim")
+	ErrUnknownKey    = errors.New("unknown signing key")
+	ErrConfig        = errors.New("wrong auth config")
+)
+
+const (
+	ErrReadJWKS  = "can't read jwks file %v"
+	ErrParseJWKS = "can't parse jwks file %v"
+)
+
+// Authenticator identifies the user of the request by its headers, header returns value of header by lowercase key.
+type Authenticator interface {
+	Authenticate(header func(key string) string) (userID string, err error)
+}
+
+// NewAuthenticator returns authenticator for mode, header mode trusts x-user-id and must be used only for local development.
+func NewAuthenticator(mode, algorithm, secret, jwksFile, userClaim string) (Authenticator, error) {
+	switch mode {
+	case ModeHeader:
+		return NewHeaderAuthenticator(), nil
+	case ModeJWT, "":
+		return NewJWTAuthenticator(algorithm, secret, jwksFile, userClaim)
+	default:
+		return nil, errors.Wrapf(ErrConfig, "unknown auth mode %v, I know next modes: jwt, header", mode)
+	}
+}
+
+// HeaderAuthenticator takes user id from x-user-id header as is.
+type HeaderAuthenticator struct {
+}
+
+func NewHeaderAuthenticator() *HeaderAuthenticator {
+	return &HeaderAuthenticator{}
+}
+
+func (a HeaderAuthenticator) Authenticate(header func(key string) string) (string, error) {
+	userID := header(util.AuthHeaderKey)
+	if len(userID) == 0 {
+		return "", ErrNoCredentials
+	}
+	return userID, nil
+}
+
+// JWTAuthenticator takes user id from claim of the bearer token of Authorization header.
+type JWTAuthenticator struct {
+	algorithm string
+	secret    []byte
+	keys      map[string]*rsa.PublicKey
+	userClaim string
+	parser    *jwt.Parser
+}
+
+// NewJWTAuthenticator makes HS256 authenticator with secret or RS256 authenticator with public keys from jwks file.
+func NewJWTAuthenticator(algorithm, secret, jwksFile, userClaim string) (*JWTAuthenticator, error) {
+	if userClaim == "" {
+		userClaim = DefaultUserClaim
+	}
+	a := &JWTAuthenticator{
+		algorithm: algorithm,
+		userClaim: userClaim,
+		//only configured algorithm is accepted, so RS256 public key can't be used as HS256 secret
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{algorithm})),
+	}
+	switch algorithm {
+	case AlgHS256:
+		if secret == "" || secret == devSecret {
+			return nil, errors.Wrap(ErrConfig, "HS256 requires secret, set it by AUTH_SECRET")
+		}
+		if len(secret) < minSecretLen {
+			return nil, errors.Wrapf(ErrConfig, "HS256 secret must be at least %v bytes", minSecretLen)
+		}
+		a.secret = []byte(secret)
+	case AlgRS256:
+		keys, err := LoadJWKS(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+	default:
+		return nil, errors.Wrapf(ErrConfig, "unknown jwt algorithm %v, I know next algorithms: HS256, RS256", algorithm)
+	}
+	return a, nil
+}
+
+// Authenticate accepts only token with exp claim, exp, nbf and iat claims are checked by parser.
+func (a JWTAuthenticator) Authenticate(header func(key string) string) (string, error) {
+	authorization := header(util.AuthorizationHeaderKey)
+	if len(authorization) <= len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return "", ErrNoCredentials
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(authorization[len(bearerPrefix):]), claims, a.key)
+	if err != nil {
+		return "", errors.Wrap(ErrInvalidToken, err.Error())
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return "", errors.Wrap(ErrInvalidToken, "token has no exp claim")
+	}
+	userID, ok := claims[a.userClaim].(string)
+	if !ok || len(userID) == 0 {
+		return "", errors.Wrapf(ErrNoUserClaim, "claim %v", a.userClaim)
+	}
+	return userID, nil
+}
+
+func (a JWTAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	if a.algorithm == AlgHS256 {
+		return a.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	//token without kid can be checked by the only key of the set
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrUnknownKey, "kid %v", kid)
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadJWKS returns RSA public keys of JSON Web Key Set file by their kid, keys of other types and usages are skipped.
+func LoadJWKS(file string) (map[string]*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrReadJWKS, file)
+	}
+	set := jwks{}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrapf(err, ErrParseJWKS, file)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, ErrParseJWKS, file)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, ErrParseJWKS, file)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.Wrapf(ErrConfig, "jwks file %v has no RSA signing keys", file)
+	}
+	return keys, nil
+}
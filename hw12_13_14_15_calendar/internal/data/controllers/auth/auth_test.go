@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser   = "00112233-4455-6677-8899-aabbccddeeff"
+	testSecret = "0123456789abcdef0123456789abcdef"
+)
+
+// valid returns claims of the user with claim name which expire in a minute.
+func valid(claim, userID string) jwt.MapClaims {
+	return jwt.MapClaims{claim: userID, "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func headers(h map[string]string) func(string) string {
+	return func(key string) string {
+		return h[key]
+	}
+}
+
+func bearer(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) func(string) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.Nil(t, err)
+	return headers(map[string]string{"authorization": "Bearer " + signed})
+}
+
+func writeJWKS(t *testing.T, dir string, kid string, key *rsa.PublicKey) string {
+	set := jwks{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	require.Nil(t, err)
+	file := filepath.Join(dir, "jwks.json")
+	require.Nil(t, ioutil.WriteFile(file, data, 0644))
+	return file
+}
+
+func TestHS256(t *testing.T) {
+	a, err := NewAuthenticator(ModeJWT, AlgHS256, testSecret, "", "user_id")
+	require.Nil(t, err)
+
+	t.Run("good token", func(t *testing.T) {
+		userID, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", valid("user_id", testUser)))
+		require.Nil(t, err)
+		require.Equal(t, testUser, userID)
+	})
+	t.Run("wrong secret", func(t *testing.T) {
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte("other"), "", valid("user_id", testUser)))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("expired token", func(t *testing.T) {
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+			"user_id": testUser,
+			"exp":     time.Now().Add(-time.Minute).Unix(),
+		}))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("token without exp", func(t *testing.T) {
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"user_id": testUser}))
+		require.True(t, errors.Is(err, ErrInvalidToken), "token must expire")
+	})
+	t.Run("token not valid yet", func(t *testing.T) {
+		claims := valid("user_id", testUser)
+		claims["nbf"] = time.Now().Add(time.Minute).Unix()
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("token issued in future", func(t *testing.T) {
+		claims := valid("user_id", testUser)
+		claims["iat"] = time.Now().Add(time.Minute).Unix()
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("weak secrets", func(t *testing.T) {
+		for _, secret := range []string{"", devSecret, "secret"} {
+			_, err := NewAuthenticator(ModeJWT, AlgHS256, secret, "", "")
+			require.Truef(t, errors.Is(err, ErrConfig), "secret %q must be rejected", secret)
+		}
+	})
+	t.Run("no user claim", func(t *testing.T) {
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodHS256, []byte(testSecret), "", valid("sub", testUser)))
+		require.True(t, errors.Is(err, ErrNoUserClaim))
+	})
+	t.Run("no token", func(t *testing.T) {
+		_, err := a.Authenticate(headers(map[string]string{"x-user-id": testUser}))
+		require.True(t, errors.Is(err, ErrNoCredentials), "legacy header must be ignored")
+	})
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	dir, err := ioutil.TempDir("", "jwks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := writeJWKS(t, dir, "key1", &key.PublicKey)
+
+	a, err := NewAuthenticator(ModeJWT, AlgRS256, "", file, "")
+	require.Nil(t, err)
+
+	t.Run("good token", func(t *testing.T) {
+		userID, err := a.Authenticate(bearer(t, jwt.SigningMethodRS256, key, "key1", valid("sub", testUser)))
+		require.Nil(t, err)
+		require.Equal(t, testUser, userID)
+	})
+	t.Run("token without kid", func(t *testing.T) {
+		userID, err := a.Authenticate(bearer(t, jwt.SigningMethodRS256, key, "", valid("sub", testUser)))
+		require.Nil(t, err)
+		require.Equal(t, testUser, userID)
+	})
+	t.Run("unknown kid", func(t *testing.T) {
+		_, err := a.Authenticate(bearer(t, jwt.SigningMethodRS256, key, "key2", valid("sub", testUser)))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("HS256 token signed by public key", func(t *testing.T) {
+		data, err := ioutil.ReadFile(file)
+		require.Nil(t, err)
+		_, err = a.Authenticate(bearer(t, jwt.SigningMethodHS256, data, "key1", valid("sub", testUser)))
+		require.True(t, errors.Is(err, ErrInvalidToken))
+	})
+	t.Run("missing jwks file", func(t *testing.T) {
+		_, err := NewAuthenticator(ModeJWT, AlgRS256, "", filepath.Join(dir, "absent.json"), "")
+		require.NotNil(t, err)
+	})
+}
+
+func TestHeader(t *testing.T) {
+	a, err := NewAuthenticator(ModeHeader, "", "", "", "")
+	require.Nil(t, err)
+
+	userID, err := a.Authenticate(headers(map[string]string{"x-user-id": testUser}))
+	require.Nil(t, err)
+	require.Equal(t, testUser, userID)
+
+	_, err = a.Authenticate(headers(map[string]string{}))
+	require.True(t, errors.Is(err, ErrNoCredentials))
+
+	_, err = NewAuthenticator("basic", "", "", "", "")
+	require.True(t, errors.Is(err, ErrConfig))
+}
@@ -0,0 +1,199 @@
+package kafkaservice
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+)
+
+const (
+	ErrManagerCreate = "can't create kafka queue manager"
+	ErrInitProducer  = "can't init producer"
+	ErrInitConsumer  = "can't init consumer"
+	ErrPush          = "pushing error"
+	ErrPull          = "pulling error"
+	ErrHandle        = "can't handle notify %v from partition %v offset %v, it will be retried"
+)
+
+// defaultRetryDelay is pause before next handling of not processed message.
+// Kafka has no negative acknowledgement, so message is retried until it is processed and its offset is committed.
+const defaultRetryDelay = time.Second
+
+// metricsQueue is queue label of metrics.
+const metricsQueue = "kafka"
+
+var _ entities.NotifyQueue = (*KafkaManager)(nil)
+
+type KafkaManager struct {
+	addrs          []string
+	topic, groupID string
+	config         *sarama.Config
+	producer       sarama.SyncProducer
+	group          sarama.ConsumerGroup
+	retryDelay     time.Duration
+	logger         usecases.Logger
+
+	newProducer func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error)
+	newGroup    func(addrs []string, groupID string, config *sarama.Config) (sarama.ConsumerGroup, error)
+}
+
+func NewKafkaManager(addrs []string, topic, groupID string, logger usecases.Logger) (*KafkaManager, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_0_0_0
+	//notifies of one user are ordered because they are always in the same partition
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Return.Errors = true
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, ErrManagerCreate)
+	}
+
+	return &KafkaManager{
+		addrs:       addrs,
+		topic:       topic,
+		groupID:     groupID,
+		config:      config,
+		retryDelay:  defaultRetryDelay,
+		logger:      logger,
+		newProducer: sarama.NewSyncProducer,
+		newGroup:    sarama.NewConsumerGroup,
+	}, nil
+}
+
+func (k *KafkaManager) initProducer() error {
+	producer, err := k.newProducer(k.addrs, k.config)
+	if err != nil {
+		return errors.Wrap(err, ErrInitProducer)
+	}
+	k.producer = producer
+	return nil
+}
+
+func (k *KafkaManager) initConsumer() error {
+	group, err := k.newGroup(k.addrs, k.groupID, k.config)
+	if err != nil {
+		return errors.Wrap(err, ErrInitConsumer)
+	}
+	k.group = group
+	return nil
+}
+
+// Pull consumes notifies as member of consumer group, so several senders share partitions of the topic.
+func (k *KafkaManager) Pull(ctx context.Context, handle entities.NotifyHandler) error {
+	if k.group == nil {
+		if err := k.initConsumer(); err != nil {
+			return errors.Wrap(err, ErrPull)
+		}
+	}
+	go func() {
+		for err := range k.group.Errors() {
+			k.logger.Error(ctx, errors.Wrap(err, ErrPull))
+		}
+	}()
+
+	handler := &groupHandler{handle: handle, retryDelay: k.retryDelay, logger: k.logger}
+	for {
+		//Consume returns on every rebalance, so it is called in the loop
+		if err := k.group.Consume(ctx, []string{k.topic}, handler); err != nil {
+			return errors.Wrap(err, ErrPull)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (k *KafkaManager) Push(ctx context.Context, n entities.Notify) error {
+	if k.producer == nil {
+		if err := k.initProducer(); err != nil {
+			return errors.Wrap(err, ErrPush)
+		}
+	}
+	msg, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, ErrPush)
+	}
+	_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: k.topic,
+		Key:   sarama.StringEncoder(n.UserID),
+		Value: sarama.ByteEncoder(msg),
+	})
+	metrics.Published(metricsQueue, err)
+	if err != nil {
+		return errors.Wrap(err, ErrPush)
+	}
+	return nil
+}
+
+func (k *KafkaManager) Close() error {
+	if k.producer != nil {
+		if err := k.producer.Close(); err != nil {
+			return err
+		}
+	}
+	if k.group != nil {
+		return k.group.Close()
+	}
+	return nil
+}
+
+type groupHandler struct {
+	handle     entities.NotifyHandler
+	retryDelay time.Duration
+	logger     usecases.Logger
+}
+
+func (h *groupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim commits offset of the message only after it is processed.
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for msg := range claim.Messages() {
+		if !h.process(ctx, msg) {
+			//session is finished, not processed message will be consumed again from committed offset
+			return nil
+		}
+		session.MarkMessage(msg, "")
+		session.Commit()
+		metrics.Consumed(metricsQueue, metrics.ConsumedAcked)
+	}
+	return nil
+}
+
+// process handles message until success. It returns false if session is finished before.
+// Broken message can't be handled ever, so it is skipped.
+func (h *groupHandler) process(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	var n entities.Notify
+	if err := json.Unmarshal(msg.Value, &n); err != nil {
+		h.logger.Error(ctx, errors.Wrap(err, ErrPull))
+		return true
+	}
+	for {
+		err := h.handle(ctx, n)
+		if err == nil {
+			return true
+		}
+		h.logger.Error(ctx, errors.Wrapf(err, ErrHandle, n.ID, msg.Partition, msg.Offset))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(h.retryDelay):
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package kafkaservice
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
+)
+
+const (
+	testTopic   = "alerts"
+	testGroup   = "sender"
+	partitionsN = 4
+	user1       = "00112233-4455-6677-8899-aabbccddeeff"
+	user2       = "11112233-4455-6677-8899-aabbccddeeff"
+)
+
+func TestKafkaManager(t *testing.T) {
+	t.Run("partitioning by user", func(t *testing.T) {
+		broker := newFakeBroker()
+		k := newTestManager(t, broker, testGroup)
+		for i := 0; i < 10; i++ {
+			require.Nil(t, k.Push(context.Background(), entities.Notify{ID: string(rune('a' + i)), UserID: user1}))
+			require.Nil(t, k.Push(context.Background(), entities.Notify{ID: string(rune('a' + i)), UserID: user2}))
+		}
+
+		users := map[string]map[int32]bool{}
+		for p, log := range broker.partitions {
+			for _, msg := range log {
+				if users[string(msg.Key)] == nil {
+					users[string(msg.Key)] = map[int32]bool{}
+				}
+				users[string(msg.Key)][int32(p)] = true
+			}
+		}
+		require.Len(t, users, 2)
+		require.Len(t, users[user1], 1, "all notifies of user must be in one partition")
+		require.Len(t, users[user2], 1, "all notifies of user must be in one partition")
+	})
+	t.Run("offset is committed after processing", func(t *testing.T) {
+		broker := newFakeBroker()
+		k := newTestManager(t, broker, testGroup)
+		require.Nil(t, k.Push(context.Background(), entities.Notify{ID: "1", UserID: user1}))
+		require.Nil(t, k.Push(context.Background(), entities.Notify{ID: "2", UserID: user1}))
+		require.Nil(t, k.Push(context.Background(), entities.Notify{ID: "3", UserID: user2}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mu := &sync.Mutex{}
+		processed := map[string]int{}
+		failed := false
+		err := k.Pull(ctx, func(ctx context.Context, n entities.Notify) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if n.ID == "2" && !failed {
+				failed = true
+				return errors.New("unavailable")
+			}
+			processed[n.ID]++
+			if len(processed) == 3 {
+				cancel()
+			}
+			return nil
+		})
+
+		require.Nil(t, err)
+		require.Equal(t, map[string]int{"1": 1, "2": 1, "3": 1}, processed)
+		require.Equal(t, int64(3), broker.committedSum(testGroup))
+
+		require.Empty(t, pullFor(t, newTestManager(t, broker, testGroup)), "committed notifies must not be consumed by the group again")
+		require.Len(t, pullFor(t, newTestManager(t, broker, "other")), 3, "other group must consume all notifies")
+	})
+	t.Run("not processed notify is redelivered", func(t *testing.T) {
+		broker := newFakeBroker()
+		k := newTestManager(t, broker, testGroup)
+		require.Nil(t, k.Push(context.Background(), entities.Notify{ID: "1", UserID: user1}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := k.Pull(ctx, func(ctx context.Context, n entities.Notify) error {
+			return errors.New("unavailable")
+		})
+
+		require.Nil(t, err)
+		require.Equal(t, int64(0), broker.committedSum(testGroup))
+		require.Len(t, pullFor(t, newTestManager(t, broker, testGroup)), 1)
+	})
+}
+
+func newTestManager(t *testing.T, broker *fakeBroker, groupID string) *KafkaManager {
+	k, err := NewKafkaManager([]string{"fake:9092"}, testTopic, groupID, mocks.NewMockLogger())
+	require.Nil(t, err)
+	k.retryDelay = time.Millisecond
+	k.newProducer = func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error) {
+		return &fakeProducer{broker: broker, partitioner: config.Producer.Partitioner(testTopic)}, nil
+	}
+	k.newGroup = func(addrs []string, groupID string, config *sarama.Config) (sarama.ConsumerGroup, error) {
+		return &fakeGroup{broker: broker, groupID: groupID, errors: make(chan error)}, nil
+	}
+	return k
+}
+
+// pullFor returns notifies consumed during short time.
+func pullFor(t *testing.T, k *KafkaManager) []entities.Notify {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	mu := &sync.Mutex{}
+	var notifies []entities.Notify
+	require.Nil(t, k.Pull(ctx, func(ctx context.Context, n entities.Notify) error {
+		mu.Lock()
+		defer mu.Unlock()
+		notifies = append(notifies, n)
+		return nil
+	}))
+	return notifies
+}
+
+// fakeBroker is in-process broker with one topic, it keeps partition logs and committed offsets of groups.
+type fakeBroker struct {
+	mu         sync.Mutex
+	partitions [partitionsN][]*sarama.ConsumerMessage
+	committed  map[string]*[partitionsN]int64
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{committed: map[string]*[partitionsN]int64{}}
+}
+
+func (b *fakeBroker) append(msg *sarama.ConsumerMessage) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msg.Offset = int64(len(b.partitions[msg.Partition]))
+	b.partitions[msg.Partition] = append(b.partitions[msg.Partition], msg)
+	return msg.Offset
+}
+
+func (b *fakeBroker) fetch(partition int32, offset int64) []*sarama.ConsumerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.partitions[partition][offset:]
+}
+
+func (b *fakeBroker) offsets(groupID string) *[partitionsN]int64 {
+	if _, ok := b.committed[groupID]; !ok {
+		b.committed[groupID] = &[partitionsN]int64{}
+	}
+	return b.committed[groupID]
+}
+
+func (b *fakeBroker) commit(groupID string, partition int32, offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offsets(groupID)[partition] = offset
+}
+
+func (b *fakeBroker) committedOffset(groupID string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offsets(groupID)[partition]
+}
+
+func (b *fakeBroker) committedSum(groupID string) (sum int64) {
+	for p := int32(0); p < partitionsN; p++ {
+		sum += b.committedOffset(groupID, p)
+	}
+	return sum
+}
+
+type fakeProducer struct {
+	broker      *fakeBroker
+	partitioner sarama.Partitioner
+}
+
+func (p *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	partition, err := p.partitioner.Partition(msg, partitionsN)
+	if err != nil {
+		return 0, 0, err
+	}
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return 0, 0, err
+	}
+	value, err := msg.Value.Encode()
+	if err != nil {
+		return 0, 0, err
+	}
+	offset := p.broker.append(&sarama.ConsumerMessage{Topic: msg.Topic, Partition: partition, Key: key, Value: value})
+	return partition, offset, nil
+}
+
+func (p *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	for _, msg := range msgs {
+		if _, _, err := p.SendMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	return nil
+}
+
+// fakeGroup is the only member of the group, so it claims all partitions.
+type fakeGroup struct {
+	broker  *fakeBroker
+	groupID string
+	errors  chan error
+}
+
+func (g *fakeGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	session := &fakeSession{ctx: ctx, group: g, marked: map[int32]int64{}}
+	if err := handler.Setup(session); err != nil {
+		return err
+	}
+	wg := &sync.WaitGroup{}
+	for p := int32(0); p < partitionsN; p++ {
+		claim := &fakeClaim{partition: p, offset: g.broker.committedOffset(g.groupID, p), messages: make(chan *sarama.ConsumerMessage)}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			claim.feed(ctx, g.broker)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := handler.ConsumeClaim(session, claim); err != nil {
+				g.errors <- err
+			}
+		}()
+	}
+	wg.Wait()
+	return handler.Cleanup(session)
+}
+
+func (g *fakeGroup) Errors() <-chan error {
+	return g.errors
+}
+
+func (g *fakeGroup) Close() error {
+	close(g.errors)
+	return nil
+}
+
+type fakeSession struct {
+	ctx    context.Context
+	group  *fakeGroup
+	mu     sync.Mutex
+	marked map[int32]int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32 {
+	return map[string][]int32{testTopic: {0, 1, 2, 3}}
+}
+
+func (s *fakeSession) MemberID() string {
+	return "member"
+}
+
+func (s *fakeSession) GenerationID() int32 {
+	return 1
+}
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked[partition] = offset
+}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for partition, offset := range s.marked {
+		s.group.broker.commit(s.group.groupID, partition, offset)
+	}
+}
+
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.MarkOffset(topic, partition, offset, metadata)
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+type fakeClaim struct {
+	partition int32
+	offset    int64
+	messages  chan *sarama.ConsumerMessage
+}
+
+// feed sends messages of partition to the claim until session is finished.
+func (c *fakeClaim) feed(ctx context.Context, broker *fakeBroker) {
+	defer close(c.messages)
+	next := c.offset
+	for {
+		for _, msg := range broker.fetch(c.partition, next) {
+			select {
+			case <-ctx.Done():
+				return
+			case c.messages <- msg:
+				next++
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (c *fakeClaim) Topic() string {
+	return testTopic
+}
+
+func (c *fakeClaim) Partition() int32 {
+	return c.partition
+}
+
+func (c *fakeClaim) InitialOffset() int64 {
+	return c.offset
+}
+
+func (c *fakeClaim) HighWaterMarkOffset() int64 {
+	return c.offset
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
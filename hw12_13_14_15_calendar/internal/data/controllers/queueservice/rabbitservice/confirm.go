@@ -0,0 +1,109 @@
+package rabbitservice
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+)
+
+var (
+	ErrNacked         = errors.New("message is nacked by broker")
+	ErrConfirmTimeout = errors.New("message isn't confirmed by broker in time")
+	ErrProducerClosed = errors.New("producer channel is closed")
+)
+
+// confirmer publishes messages to the channel in confirm mode and waits for their confirmations by broker.
+// Count of published but not confirmed messages is limited, publish waits until in-flight message is confirmed.
+type confirmer struct {
+	pub      publisher
+	timeout  time.Duration
+	inFlight chan struct{}
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]chan bool
+	closed  bool
+}
+
+// newConfirmer returns confirmer of the channel, confirms are confirmations of the channel, they are closed with the channel.
+func newConfirmer(pub publisher, confirms <-chan amqp.Confirmation, maxInFlight int, timeout time.Duration) *confirmer {
+	c := &confirmer{
+		pub:      pub,
+		timeout:  timeout,
+		inFlight: make(chan struct{}, maxInFlight),
+		pending:  make(map[uint64]chan bool),
+	}
+	go c.listen(confirms)
+	return c
+}
+
+// publish returns nil only after the message is confirmed by broker.
+func (c *confirmer) publish(exchange, key string, msg amqp.Publishing) error {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case c.inFlight <- struct{}{}:
+	case <-timer.C:
+		return ErrConfirmTimeout
+	}
+	defer func() { <-c.inFlight }()
+
+	// delivery tags of channel in confirm mode are sequence numbers of published messages.
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrProducerClosed
+	}
+	if err := c.pub.Publish(exchange, key, false, false, msg); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.seq++
+	tag := c.seq
+	done := make(chan bool, 1)
+	c.pending[tag] = done
+	c.mu.Unlock()
+
+	select {
+	case ack, ok := <-done:
+		if !ok {
+			return ErrProducerClosed
+		}
+		if !ack {
+			return ErrNacked
+		}
+		return nil
+	case <-timer.C:
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		return ErrConfirmTimeout
+	}
+}
+
+// listen passes confirmations to waiting publishers, they get ErrProducerClosed when confirmations are closed.
+func (c *confirmer) listen(confirms <-chan amqp.Confirmation) {
+	for confirm := range confirms {
+		c.mu.Lock()
+		if done, ok := c.pending[confirm.DeliveryTag]; ok {
+			done <- confirm.Ack
+			delete(c.pending, confirm.DeliveryTag)
+		}
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for tag, done := range c.pending {
+		close(done)
+		delete(c.pending, tag)
+	}
+}
+
+func (c *confirmer) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
@@ -7,13 +7,19 @@ package rabbitservice
 import (
 	"context"
 	"encoding/json"
+	"sync"
+	"time"
 
 	"github.com/isayme/go-amqp-reconnect/rabbitmq"
 	"github.com/pkg/errors"
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/health"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 const (
@@ -22,52 +28,128 @@ const (
 	ErrInitConsumer  = "can't init consumer"
 	ErrPush          = "pushing error"
 	ErrPull          = "pulling error"
+	ErrHandle        = "can't handle notify %v, it is returned to queue"
+	ErrDeadLetter    = "can't handle notify %v %v times, it is moved to dead letter queue"
+	ErrDeadLetters   = "can't get dead letters"
+	ErrReplay        = "can't replay dead letters"
 )
 
+// metricsQueue is queue label of metrics.
+const metricsQueue = "rabbit"
+
+// RedeliveryHeader counts returns of message to the queue after failed handling.
+const RedeliveryHeader = "x-redelivery-count"
+
+const (
+	defaultMaxRedelivery = 5
+	defaultMaxInFlight   = 100
+	confirmTimeout       = 10 * time.Second
+)
+
+var ErrDeliveryClosed = errors.New("delivery channel is closed")
+var ErrConnectionClosed = errors.New("connection to rabbit is closed")
+
 var _ entities.NotifyQueue = (*RabbitManager)(nil)
+var _ entities.DeadLetterQueue = (*RabbitManager)(nil)
+var _ health.Checker = (*RabbitManager)(nil)
+
+// publisher publishes messages, it is a part of rabbitmq channel.
+type publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// declarer declares exchanges and queues, it is a part of rabbitmq channel.
+type declarer interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+}
+
+// confirmedPublisher returns nil only after published message is confirmed by broker.
+type confirmedPublisher interface {
+	publish(exchange, key string, msg amqp.Publishing) error
+}
 
 type RabbitManager struct {
-	conn                    *rabbitmq.Connection
-	consumeCh               *rabbitmq.Channel
-	produceCh               *rabbitmq.Channel
+	conn      *rabbitmq.Connection
+	consumeCh *rabbitmq.Channel
+	// producer publishes in confirm mode, it is created again after its channel is closed.
+	producer                *confirmer
+	producerMu              sync.Mutex
 	exchangeName, queueName string
-	logger                  usecases.Logger
+	// dlxName and dlqName are dead letter exchange and queue of messages which can't be handled.
+	dlxName, dlqName string
+	maxRedelivery    int
+	maxInFlight      int
+	logger           usecases.Logger
 }
 
-func NewRabbitManager(addr, exchangeName, queueName string, logger usecases.Logger) (*RabbitManager, error) {
+// NewRabbitManager returns manager of the queue, message is dead-lettered after maxRedelivery failed handlings.
+// Push waits for confirmation of message by broker, at most maxInFlight messages wait for confirmation at once.
+func NewRabbitManager(addr, exchangeName, queueName string, maxRedelivery, maxInFlight int, logger usecases.Logger) (*RabbitManager, error) {
 	conn, err := rabbitmq.Dial(addr)
 	if err != nil {
 		return nil, errors.Wrap(err, ErrManagerCreate)
 	}
+	if maxRedelivery <= 0 {
+		maxRedelivery = defaultMaxRedelivery
+	}
+	if maxInFlight <= 0 {
+		maxInFlight = defaultMaxInFlight
+	}
 
 	return &RabbitManager{
-		conn:         conn,
-		exchangeName: exchangeName,
-		queueName:    queueName,
-		logger:       logger,
+		conn:          conn,
+		exchangeName:  exchangeName,
+		queueName:     queueName,
+		dlxName:       exchangeName + ".dlx",
+		dlqName:       queueName + ".dlq",
+		maxRedelivery: maxRedelivery,
+		maxInFlight:   maxInFlight,
+		logger:        logger,
 	}, nil
 }
 
-func (r *RabbitManager) initProducer() error {
-	sendCh, err := r.conn.Channel()
-	if err != nil {
-		return errors.Wrap(err, ErrInitProducer)
+// declare declares exchange and queue with dead letter exchange and queue. Queue is declared without arguments as
+// it was declared before dead letters, because broker rejects declaration of existing queue with other arguments.
+// Dead letter exchange is attached to the queue by broker policy (make rabbit_policy), then rejected messages are
+// routed to the dead letter queue.
+func (r *RabbitManager) declare(ch declarer) error {
+	if err := ch.ExchangeDeclare(r.dlxName, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(r.dlqName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(r.dlqName, "", r.dlxName, false, nil); err != nil {
+		return err
 	}
 
-	err = sendCh.ExchangeDeclare(r.exchangeName, amqp.ExchangeDirect, true, false, false, false, nil)
+	if err := ch.ExchangeDeclare(r.exchangeName, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return err
+	}
+	_, err := ch.QueueDeclare(r.queueName, true, false, false, false, nil)
 	if err != nil {
-		return errors.Wrap(err, ErrInitProducer)
+		return err
 	}
+	return ch.QueueBind(r.queueName, "", r.exchangeName, false, nil)
+}
 
-	_, err = sendCh.QueueDeclare(r.queueName, true, false, false, false, nil)
+// initProducer opens channel in confirm mode. It isn't reconnected channel, as delivery tags of confirms start again
+// on the new channel, so producer is created again after its channel is closed.
+func (r *RabbitManager) initProducer() error {
+	sendCh, err := r.conn.Connection.Channel()
 	if err != nil {
 		return errors.Wrap(err, ErrInitProducer)
 	}
-
-	if err := sendCh.QueueBind(r.queueName, "", r.exchangeName, false, nil); err != nil {
+	if err := r.declare(sendCh); err != nil {
 		return errors.Wrap(err, ErrInitProducer)
 	}
-	r.produceCh = sendCh
+	if err := sendCh.Confirm(false); err != nil {
+		return errors.Wrap(err, ErrInitProducer)
+	}
+	confirms := sendCh.NotifyPublish(make(chan amqp.Confirmation, r.maxInFlight))
+	r.producer = newConfirmer(sendCh, confirms, r.maxInFlight, confirmTimeout)
 	return nil
 }
 
@@ -76,60 +158,261 @@ func (r *RabbitManager) initConsumer() error {
 	if err != nil {
 		return errors.Wrap(err, ErrInitConsumer)
 	}
+	if err := r.declare(consumeCh.Channel); err != nil {
+		return errors.Wrap(err, ErrInitConsumer)
+	}
 	r.consumeCh = consumeCh
 	return nil
 }
 
-func (r *RabbitManager) Pull(ctx context.Context, alert chan<- entities.Notify) error {
-	if r.produceCh == nil {
+func (r *RabbitManager) Pull(ctx context.Context, handle entities.NotifyHandler) error {
+	if r.consumeCh == nil {
 		if err := r.initConsumer(); err != nil {
 			return errors.Wrap(err, ErrPull)
 		}
 	}
-	var n entities.Notify
-	var msg amqp.Delivery
 	d, err := r.consumeCh.Consume(r.queueName, "", false, false, false, false, nil)
 	if err != nil {
-		r.logger.Error(ctx, errors.Wrap(err, ErrPull))
+		return errors.Wrap(err, ErrPull)
 	}
 
-	loop := true
-	for loop {
+	for {
 		select {
 		case <-ctx.Done():
-			loop = false
-			break
-		case msg = <-d:
-			err := json.Unmarshal(msg.Body, &n)
-			if err != nil {
+			return nil
+		case msg, ok := <-d:
+			if !ok {
+				return errors.Wrap(ErrDeliveryClosed, ErrPull)
+			}
+			r.process(ctx, r, msg, handle)
+		}
+	}
+}
+
+// process acks the message only after successful handling, otherwise message is published to the queue again
+// with incremented RedeliveryHeader and it is acked after broker confirms the copy. Message which handling failed maxRedelivery times and broken message, which
+// can't be handled ever, are rejected without requeue, so they are moved to the dead letter queue.
+func (r *RabbitManager) process(ctx context.Context, pub confirmedPublisher, msg amqp.Delivery, handle entities.NotifyHandler) {
+	ctx, span := r.startSpan(tracing.Extract(ctx, tableCarrier(msg.Headers)), "process", trace.SpanKindConsumer)
+	defer span.End()
+
+	var n entities.Notify
+	if err := json.Unmarshal(msg.Body, &n); err != nil {
+		tracing.Fail(span, err)
+		r.logger.Error(ctx, errors.Wrap(err, ErrPull))
+		metrics.Consumed(metricsQueue, metrics.ConsumedDeadLettered)
+		if err := msg.Reject(false); err != nil {
+			r.logger.Error(ctx, errors.Wrap(err, ErrPull))
+		}
+		return
+	}
+	span.SetAttributes(tracing.EventID(n.ID))
+	if err := handle(ctx, n); err != nil {
+		tracing.Fail(span, err)
+		redelivered := redeliveries(msg.Headers)
+		if redelivered+1 >= r.maxRedelivery {
+			r.logger.Error(ctx, errors.Wrapf(err, ErrDeadLetter, n.ID, redelivered+1))
+			metrics.Consumed(metricsQueue, metrics.ConsumedDeadLettered)
+			if err := msg.Reject(false); err != nil {
 				r.logger.Error(ctx, errors.Wrap(err, ErrPull))
 			}
-			alert <- n
-			err = msg.Ack(true)
-			if err != nil {
+			return
+		}
+		r.logger.Error(ctx, errors.Wrapf(err, ErrHandle, n.ID))
+		if err := r.redeliver(pub, msg, redelivered+1); err != nil {
+			r.logger.Error(ctx, errors.Wrap(err, ErrPull))
+			metrics.Consumed(metricsQueue, metrics.ConsumedRequeued)
+			if err := msg.Nack(false, true); err != nil {
 				r.logger.Error(ctx, errors.Wrap(err, ErrPull))
 			}
+			return
 		}
+		metrics.Consumed(metricsQueue, metrics.ConsumedRedelivered)
+	} else {
+		metrics.Consumed(metricsQueue, metrics.ConsumedAcked)
+	}
+	if err := msg.Ack(false); err != nil {
+		r.logger.Error(ctx, errors.Wrap(err, ErrPull))
 	}
-	return nil
 }
 
-func (r *RabbitManager) Push(n entities.Notify) error {
-	if r.produceCh == nil {
-		if err := r.initProducer(); err != nil {
-			return errors.Wrap(err, ErrPush)
+// redeliver publishes copy of the message with redelivered count to the queue and waits for its confirmation.
+func (r *RabbitManager) redeliver(pub confirmedPublisher, msg amqp.Delivery, redelivered int) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if redelivered > 0 {
+		headers[RedeliveryHeader] = int32(redelivered)
+	} else {
+		delete(headers, RedeliveryHeader)
+	}
+	return pub.publish(r.exchangeName, "", amqp.Publishing{
+		Headers:      headers,
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Body:         msg.Body,
+	})
+}
+
+// redeliveries returns count of returns of message to the queue.
+func redeliveries(headers amqp.Table) int {
+	switch v := headers[RedeliveryHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// DeadLetters returns up to limit dead-lettered notifies, they are left in the dead letter queue.
+func (r *RabbitManager) DeadLetters(ctx context.Context, limit int) ([]entities.DeadLetter, error) {
+	letters := []entities.DeadLetter{}
+	err := r.getDeadLetters(ctx, limit, func(msg amqp.Delivery) (bool, error) {
+		letters = append(letters, deadLetter(msg))
+		return false, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ErrDeadLetters)
+	}
+	return letters, nil
+}
+
+// Replay returns up to limit dead-lettered notifies to the queue with reset redelivery count, dead letter is acked only
+// after its copy is confirmed by broker. Broken messages can't be handled ever, so they are left in the dead letter queue.
+func (r *RabbitManager) Replay(ctx context.Context, limit int) (replayed int, err error) {
+	err = r.getDeadLetters(ctx, limit, func(msg amqp.Delivery) (bool, error) {
+		if deadLetter(msg).Broken {
+			return false, nil
+		}
+		if err := r.redeliver(r, msg, 0); err != nil {
+			return false, err
 		}
+		if err := msg.Ack(false); err != nil {
+			return false, err
+		}
+		replayed++
+		return true, nil
+	})
+	if err != nil {
+		return replayed, errors.Wrap(err, ErrReplay)
 	}
+	return replayed, nil
+}
+
+// deadLetterChannel gets messages of the dead letter queue, it is a part of rabbitmq channel.
+type deadLetterChannel interface {
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	Nack(tag uint64, multiple bool, requeue bool) error
+}
+
+// getDeadLetters passes up to limit messages of the dead letter queue to handle, limit is the queue depth at start at
+// most, so replayed messages which are dead-lettered again aren't got twice. Handle reports whether it acked message.
+func (r *RabbitManager) getDeadLetters(ctx context.Context, limit int, handle func(msg amqp.Delivery) (acked bool, err error)) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	if err := r.declare(ch.Channel); err != nil {
+		return err
+	}
+	q, err := ch.QueueInspect(r.dlqName)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 || limit > q.Messages {
+		limit = q.Messages
+	}
+	return r.handleDeadLetters(ctx, ch, limit, handle)
+}
+
+// handleDeadLetters gets up to limit messages, messages which are not acked by handle are returned to the dead letter queue.
+func (r *RabbitManager) handleDeadLetters(ctx context.Context, ch deadLetterChannel, limit int, handle func(msg amqp.Delivery) (bool, error)) error {
+	unacked := []uint64{}
+	defer func() {
+		for _, tag := range unacked {
+			if err := ch.Nack(tag, false, true); err != nil {
+				r.logger.Error(ctx, errors.Wrap(err, ErrDeadLetters))
+			}
+		}
+	}()
+	for i := 0; i < limit; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		msg, ok, err := ch.Get(r.dlqName, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		acked, err := handle(msg)
+		if !acked {
+			unacked = append(unacked, msg.DeliveryTag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deadLetter(msg amqp.Delivery) entities.DeadLetter {
+	letter := entities.DeadLetter{Body: string(msg.Body), Redeliveries: redeliveries(msg.Headers)}
+	if err := json.Unmarshal(msg.Body, &letter.Notify); err != nil {
+		letter.Broken = true
+	}
+	return letter
+}
+
+// Check reports state of connection to rabbit, connection is reopened after failure, so error is transient.
+func (r *RabbitManager) Check(ctx context.Context) error {
+	if r.conn == nil || r.conn.Connection == nil || r.conn.IsClosed() {
+		return ErrConnectionClosed
+	}
+	return nil
+}
+
+// Push publishes persistent message and returns nil only after the message is confirmed by broker.
+func (r *RabbitManager) Push(ctx context.Context, n entities.Notify) (err error) {
+	ctx, span := r.startSpan(ctx, "send", trace.SpanKindProducer)
+	span.SetAttributes(tracing.EventID(n.ID))
+	defer func() { tracing.End(span, err) }()
+
 	msg, err := json.Marshal(n)
 	if err != nil {
 		return errors.Wrap(err, ErrPush)
 	}
-	err = r.produceCh.Publish(r.exchangeName, "", false, false, amqp.Publishing{
-		ContentType: "application/json",
-		Body:        msg,
+	headers := amqp.Table{}
+	tracing.Inject(ctx, tableCarrier(headers))
+	err = r.publish(r.exchangeName, "", amqp.Publishing{
+		Headers:      headers,
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         msg,
 	})
+	metrics.Published(metricsQueue, err)
 	if err != nil {
 		return errors.Wrap(err, ErrPush)
 	}
 	return nil
 }
+
+// publish publishes message by producer in confirm mode, producer is created again after its channel is closed.
+func (r *RabbitManager) publish(exchange, key string, msg amqp.Publishing) error {
+	r.producerMu.Lock()
+	if r.producer == nil || r.producer.isClosed() {
+		if err := r.initProducer(); err != nil {
+			r.producerMu.Unlock()
+			return err
+		}
+	}
+	producer := r.producer
+	r.producerMu.Unlock()
+	return producer.publish(exchange, key, msg)
+}
@@ -0,0 +1,260 @@
+package rabbitservice
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
+)
+
+type fakeAcknowledger struct {
+	acked, nacked, rejected, requeued int
+	nackedTags                        []uint64
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked++
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.nacked++
+	a.nackedTags = append(a.nackedTags, tag)
+	if requeue {
+		a.requeued++
+	}
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.rejected++
+	if requeue {
+		a.requeued++
+	}
+	return nil
+}
+
+type fakePublisher struct {
+	published []amqp.Publishing
+	// unconfirmed is error of confirmation of published messages.
+	unconfirmed error
+}
+
+func (p *fakePublisher) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) publish(exchange, key string, msg amqp.Publishing) error {
+	if p.unconfirmed != nil {
+		return p.unconfirmed
+	}
+	return p.Publish(exchange, key, false, false, msg)
+}
+
+// fakeDeadLetters is dead letter queue which dead-letters replayed messages again.
+type fakeDeadLetters struct {
+	*fakeAcknowledger
+	tag uint64
+}
+
+func (q *fakeDeadLetters) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	q.tag++
+	return amqp.Delivery{Acknowledger: q.fakeAcknowledger, DeliveryTag: q.tag, Body: []byte(`{"ID":"1"}`)}, true, nil
+}
+
+// fakeBroker rejects declaration of existing queue with other arguments like rabbit does.
+type fakeBroker struct {
+	queues map[string]amqp.Table
+}
+
+func (b *fakeBroker) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (b *fakeBroker) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if declared, ok := b.queues[name]; ok && len(declared)+len(args) > 0 && !reflect.DeepEqual(declared, args) {
+		return amqp.Queue{}, &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg"}
+	}
+	b.queues[name] = args
+	return amqp.Queue{Name: name}, nil
+}
+
+func (b *fakeBroker) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func TestRabbitManagerDeclare(t *testing.T) {
+	broker := &fakeBroker{queues: make(map[string]amqp.Table)}
+	// queue is declared by previous version without dead letter exchange.
+	_, err := broker.QueueDeclare("topic_calendar", true, false, false, false, nil)
+	require.Nil(t, err)
+
+	r := &RabbitManager{exchangeName: "exchange_calendar", queueName: "topic_calendar", dlxName: "exchange_calendar.dlx", dlqName: "topic_calendar.dlq"}
+	require.Nil(t, r.declare(broker), "existing queue is declared again")
+	require.Nil(t, r.declare(broker))
+	require.Empty(t, broker.queues["topic_calendar"])
+	require.Contains(t, broker.queues, "topic_calendar.dlq")
+}
+
+func TestRabbitManagerProcess(t *testing.T) {
+	ctx := context.Background()
+	r := &RabbitManager{exchangeName: "alerts", maxRedelivery: 3, logger: mocks.NewMockLogger()}
+	body, err := json.Marshal(entities.Notify{ID: "1", UserID: "owner"})
+	require.Nil(t, err)
+	failing := func(ctx context.Context, n entities.Notify) error {
+		return errors.New("unavailable")
+	}
+
+	t.Run("handled", func(t *testing.T) {
+		ack, pub := &fakeAcknowledger{}, &fakePublisher{}
+		var handled entities.Notify
+		r.process(ctx, pub, amqp.Delivery{Acknowledger: ack, Body: body}, func(ctx context.Context, n entities.Notify) error {
+			handled = n
+			return nil
+		})
+		require.Equal(t, "1", handled.ID)
+		require.Equal(t, 1, ack.acked)
+		require.Empty(t, pub.published)
+	})
+	t.Run("broken message is dead-lettered", func(t *testing.T) {
+		ack, pub := &fakeAcknowledger{}, &fakePublisher{}
+		r.process(ctx, pub, amqp.Delivery{Acknowledger: ack, Body: []byte("{")}, failing)
+		require.Equal(t, 1, ack.rejected)
+		require.Equal(t, 0, ack.requeued)
+		require.Equal(t, 0, ack.acked)
+	})
+	t.Run("failed message is redelivered with counter", func(t *testing.T) {
+		ack, pub := &fakeAcknowledger{}, &fakePublisher{}
+		msg := amqp.Delivery{Acknowledger: ack, Body: body}
+		for i := 1; i < r.maxRedelivery; i++ {
+			r.process(ctx, pub, msg, failing)
+			require.Len(t, pub.published, i)
+			require.Equal(t, i, redeliveries(pub.published[i-1].Headers))
+			msg.Headers = pub.published[i-1].Headers
+		}
+		require.Equal(t, r.maxRedelivery-1, ack.acked, "original message is acked after its copy is published")
+
+		r.process(ctx, pub, msg, failing)
+		require.Len(t, pub.published, r.maxRedelivery-1)
+		require.Equal(t, 1, ack.rejected, "message is dead-lettered after max redelivery")
+		require.Equal(t, 0, ack.requeued)
+	})
+	t.Run("message is requeued if its copy isn't confirmed", func(t *testing.T) {
+		ack, pub := &fakeAcknowledger{}, &fakePublisher{unconfirmed: ErrNacked}
+		r.process(ctx, pub, amqp.Delivery{Acknowledger: ack, Body: body}, failing)
+		require.Equal(t, 0, ack.acked)
+		require.Equal(t, 1, ack.requeued)
+	})
+}
+
+func TestHandleDeadLetters(t *testing.T) {
+	r := &RabbitManager{dlqName: "alerts.dlq", logger: mocks.NewMockLogger()}
+	q := &fakeDeadLetters{fakeAcknowledger: &fakeAcknowledger{}}
+	handled := 0
+	err := r.handleDeadLetters(context.Background(), q, 4, func(msg amqp.Delivery) (bool, error) {
+		handled++
+		if msg.DeliveryTag%2 == 1 {
+			return false, nil
+		}
+		return true, msg.Ack(false)
+	})
+	require.Nil(t, err)
+	require.Equal(t, 4, handled, "replay stops at the queue depth though messages are dead-lettered again")
+	require.Equal(t, 2, q.acked)
+	require.Equal(t, []uint64{1, 3}, q.nackedTags, "only not acked messages are returned to the queue")
+
+	q = &fakeDeadLetters{fakeAcknowledger: &fakeAcknowledger{}}
+	err = r.handleDeadLetters(context.Background(), q, 4, func(msg amqp.Delivery) (bool, error) {
+		return false, ErrNacked
+	})
+	require.Equal(t, ErrNacked, err)
+	require.Equal(t, []uint64{1}, q.nackedTags, "message of failed replay is returned to the queue")
+}
+
+func TestTraceContext(t *testing.T) {
+	shutdown, err := tracing.Init(tracing.Config{Service: "test"})
+	require.Nil(t, err)
+	defer shutdown(context.Background())
+
+	ctx, span := tracing.Start(context.Background(), "scan")
+	defer span.End()
+	headers := amqp.Table{}
+	tracing.Inject(ctx, tableCarrier(headers))
+	require.NotEmpty(t, headers["traceparent"])
+
+	r := &RabbitManager{queueName: "alerts", maxRedelivery: 3, logger: mocks.NewMockLogger()}
+	body, err := json.Marshal(entities.Notify{ID: "1"})
+	require.Nil(t, err)
+	pub := &fakePublisher{}
+	var traceID string
+	r.process(context.Background(), pub, amqp.Delivery{Acknowledger: &fakeAcknowledger{}, Headers: headers, Body: body},
+		func(ctx context.Context, n entities.Notify) error {
+			traceID, _ = tracing.IDs(ctx)
+			return errors.New("unavailable")
+		})
+	expected, _ := tracing.IDs(ctx)
+	require.Equal(t, expected, traceID, "consumer continues trace of producer")
+	require.Len(t, pub.published, 1)
+	require.Equal(t, headers["traceparent"], pub.published[0].Headers["traceparent"], "redelivered copy keeps trace context")
+}
+
+func TestDeadLetter(t *testing.T) {
+	letter := deadLetter(amqp.Delivery{Body: []byte(`{"ID":"1"}`), Headers: amqp.Table{RedeliveryHeader: int64(2)}})
+	require.False(t, letter.Broken)
+	require.Equal(t, "1", letter.Notify.ID)
+	require.Equal(t, 2, letter.Redeliveries)
+
+	letter = deadLetter(amqp.Delivery{Body: []byte("{")})
+	require.True(t, letter.Broken)
+	require.Equal(t, "{", letter.Body)
+}
+
+func TestConfirmer(t *testing.T) {
+	t.Run("publish waits for confirmation", func(t *testing.T) {
+		confirms := make(chan amqp.Confirmation)
+		pub := &fakePublisher{}
+		c := newConfirmer(pub, confirms, 2, time.Second)
+		results := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				results <- c.publish("alerts", "", amqp.Publishing{})
+			}()
+		}
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return len(c.pending) == 2
+		}, time.Second, time.Millisecond, "in-flight messages are limited")
+
+		confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+		require.Nil(t, <-results)
+		confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+		require.Equal(t, ErrNacked, <-results)
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.seq == 3
+		}, time.Second, time.Millisecond)
+
+		close(confirms)
+		require.Equal(t, ErrProducerClosed, <-results, "unconfirmed message fails when channel is closed")
+		require.True(t, c.isClosed())
+		require.Equal(t, ErrProducerClosed, c.publish("alerts", "", amqp.Publishing{}))
+		require.Len(t, pub.published, 3)
+	})
+	t.Run("confirmation timeout", func(t *testing.T) {
+		c := newConfirmer(&fakePublisher{}, make(chan amqp.Confirmation), 1, 10*time.Millisecond)
+		require.Equal(t, ErrConfirmTimeout, c.publish("alerts", "", amqp.Publishing{}))
+		require.Empty(t, c.pending)
+	})
+}
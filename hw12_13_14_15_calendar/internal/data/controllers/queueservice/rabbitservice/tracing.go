@@ -0,0 +1,47 @@
+package rabbitservice
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// tableCarrier adapts headers of amqp message to propagation.TextMapCarrier.
+type tableCarrier amqp.Table
+
+func (c tableCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+func (c tableCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c tableCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// startSpan starts span of messaging operation, span is named by queue and operation.
+func (r *RabbitManager) startSpan(ctx context.Context, operation string, kind trace.SpanKind) (context.Context, trace.Span) {
+	return tracing.Start(ctx, r.queueName+" "+operation,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("rabbitmq"),
+			semconv.MessagingDestinationKey.String(r.exchangeName),
+			semconv.MessagingDestinationKindTopic,
+		))
+}
@@ -11,7 +11,11 @@ import (
 
 const RequestID = contextKey("RequestID")
 const UserID = contextKey("UserID")
+const TimeZone = contextKey("TimeZone")
 const AuthHeaderKey = "x-user-id"
+const AuthorizationHeaderKey = "authorization"
+const TimeZoneHeaderKey = "x-timezone"
+const IdempotencyHeaderKey = "idempotency-key"
 
 type contextKey string
 
@@ -30,6 +34,22 @@ func SetUserID(ctx context.Context, userID string) context.Context {
 	return ctx
 }
 
+// GetTimeZone returns IANA time zone of the requester, empty zone means UTC.
+func GetTimeZone(ctx context.Context) (timeZone string) {
+	if ctx == nil {
+		return
+	}
+	timeZone, _ = ctx.Value(TimeZone).(string)
+	return
+}
+
+func SetTimeZone(ctx context.Context, timeZone string) context.Context {
+	if len(GetTimeZone(ctx)) == 0 {
+		return context.WithValue(ctx, TimeZone, timeZone)
+	}
+	return ctx
+}
+
 func GetRequestID(ctx context.Context) (reqID string) {
 	if ctx == nil {
 		return
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,19 +17,88 @@ import (
 
 //infrastructure layer error.
 const (
-	uniqueViolation = "23505" //uniqueViolation              = "23505"
+	uniqueViolation     = "23505" //uniqueViolation              = "23505"
+	exclusionViolation  = "23P01" //exclusionViolation           = "23P01"
+	foreignKeyViolation = "23503" //foreignKeyViolation          = "23503"
 )
 
+// attendeesColumn aggregates attendees of event into userid=status list.
+const attendeesColumn = `coalesce((select string_agg(a.userid::text || '=' || a.status, ',' order by a.userid) from public.attendees a where a.eventid = events.id), '')`
+
+// remindersColumn aggregates reminders of event into seconds=channel list from the earliest reminder.
+const remindersColumn = `coalesce((select string_agg(r.remindbefore || '=' || r.channel, ',' order by r.remindbefore desc, r.channel) from public.reminders r where r.eventid = events.id), '')`
+
+// eventColumns are columns of Event in order of its scan fields.
+const eventColumns = `id, title, datetime, duration, text, userid, ` + remindersColumn + `, rrule, exdate, timezone, coalesce(calendarid::text, ''), ` + attendeesColumn + `, version`
+
+// archiveColumns are columns of events_archive in order of insert.
+const archiveColumns = `id, title, datetime, duration, text, userid, ` + remindersColumn + `, rrule, exdate, timezone, calendarid, ` + attendeesColumn
+
+// cleanScope selects events of user $2 and calendar $3 if they are set except events of users $4 and calendars $5 lists.
+const cleanScope = `($2 = '' or userid::text = $2) and ($3 = '' or calendarid::text = $3)
+	and not (userid::text = any(string_to_array($4, ',')))
+	and (calendarid is null or not (calendarid::text = any(string_to_array($5, ','))))`
+
+// defaultCleanBatch is number of events removed in one transaction if batch of query isn't set.
+const defaultCleanBatch = 1000
+
+// rruleUntil is UNTIL of recurrence rule in UTC, rule is saved with UNTIL in UTC, see entities.Recurrence.RRule.
+// It is null for rule without UNTIL.
+const rruleUntil = `to_timestamp(substring(rrule from 'UNTIL=([0-9]{8}T[0-9]{6})'), 'YYYYMMDD"T"HH24MISS')::timestamp`
+
+// remindedEvents selects events which have reminder with notify time in [$1, $2], recurring events are selected if they
+// start before $2 and don't end by UNTIL before $1, occurrences of series are checked by caller.
+const remindedEvents = `exists (select 1 from public.reminders r where r.eventid = events.id
+	and (datetime at time zone 'UTC' - make_interval(secs => r.remindbefore)) <= $2
+	and (rrule <> '' and coalesce((` + rruleUntil + ` - make_interval(secs => r.remindbefore)) >= $1, true)
+		or (datetime at time zone 'UTC' - make_interval(secs => r.remindbefore)) >= $1))`
+
+// listOrders are keyset conditions and orders of ListEvents by sort order, title is compared bytewise like in Go.
+var listOrders = map[entities.SortOrder]struct{ after, order string }{
+	entities.SortDateTime:     {`(datetime, id) > ($6, $7)`, `datetime, id`},
+	entities.SortDateTimeDesc: {`(datetime, id) < ($6, $7)`, `datetime desc, id desc`},
+	entities.SortTitle:        {`(title COLLATE "C", id) > ($6, $7)`, `title COLLATE "C", id`},
+	entities.SortTitleDesc:    {`(title COLLATE "C", id) < ($6, $7)`, `title COLLATE "C" desc, id desc`},
+}
+
+// aclColumn aggregates ACL of calendar into userid=role list.
+const aclColumn = `coalesce((select string_agg(c.userid::text || '=' || c.role, ',' order by c.userid) from public.calendar_acl c where c.calendarid = calendars.id), '')`
+
 //interface layer error.
 const (
 	ErrAdd             = "can't add to database event: %v"
 	ErrGetbyID         = "can't get event from database by id: %v"
 	ErrGetbyDate       = "can't get event from database by date: %v"
-	ErrGetbyNotifyDate = "can't get event from database by notify date: %v"
+	ErrGetDueReminders = "can't get reminders from database with notify time from %v to %v"
 	ErrGetForPeriod    = "can't get event from database for period: %v-%v"
+	ErrGetOverlapping  = "can't get events of users %v from database overlapping period: %v-%v"
 	ErrUpdatebyID      = "can't update event in database by id: %v"
 	ErrDeletebyID      = "can't delete event in database by id: %v"
 	ErrConvert         = "can't convert between business event and database event entities"
+	ErrFetchDue        = "can't fetch due unnotified events from database for period: %v-%v"
+	ErrMarkNotified    = "can't mark reminder %v of event %v occurrence %v as notified"
+	ErrGetWatermark    = "can't get watermark %v from database"
+	ErrGetUnnotified   = "can't get the oldest unnotified reminder from database after %v"
+	ErrRenewClaim      = "can't renew claim of reminder %v of event %v occurrence %v"
+	ErrDeleteOlderThan = "can't remove events older than %v"
+	ErrSetWatermark    = "can't set watermark %v to %v"
+	ErrWriteReminders  = "can't write reminders of event %v to database"
+	ErrAddAttendees    = "can't invite users %v to event %v in database"
+	ErrSetStatus       = "can't set status of user %v to event %v in database"
+	ErrGetInvitations  = "can't get invitations of user %v from database"
+	ErrAddCalendar     = "can't add to database calendar: %v"
+	ErrGetCalendar     = "can't get calendar from database by id: %v"
+	ErrGetCalendars    = "can't get calendars of user %v from database"
+	ErrSetRole         = "can't set role of user %v in calendar %v in database"
+	ErrDeleteCalendar  = "can't delete calendar in database by id: %v"
+	ErrListEvents      = "can't list events of user %v from database"
+	ErrSearchEvents    = "can't search events of user %v in database by terms %v"
+	ErrWriteOutbox     = "can't write %v message of event %v to outbox"
+	ErrRelayOutbox     = "can't relay messages of outbox from database"
+	ErrReserveKey      = "can't reserve idempotency key %v of user %v in database"
+	ErrCompleteKey     = "can't complete idempotency key %v of user %v in database"
+	ErrReleaseKey      = "can't release idempotency key %v of user %v in database"
+	ErrRenewKey        = "can't renew idempotency key %v of user %v in database"
 )
 
 var _ entities.EventRepo = (*EventRepo)(nil)
@@ -45,7 +115,18 @@ type Event struct {
 	Duration   int
 	Text       string
 	UserID     uuid.UUID
-	TimeNotify int
+	Reminders  string
+	RRule      string
+	ExDate     string
+	TimeZone   string
+	CalendarID string
+	Attendees  string
+	Version    int64
+}
+
+// fields returns pointers to fields in order of eventColumns.
+func (dbe *Event) fields() []interface{} {
+	return []interface{}{&dbe.ID, &dbe.Title, &dbe.DateTime, &dbe.Duration, &dbe.Text, &dbe.UserID, &dbe.Reminders, &dbe.RRule, &dbe.ExDate, &dbe.TimeZone, &dbe.CalendarID, &dbe.Attendees, &dbe.Version}
 }
 
 func NewDBEventRepo(driver, dsn string, logger usecases.Logger) (*EventRepo, error) {
@@ -59,16 +140,25 @@ func NewDBEventRepo(driver, dsn string, logger usecases.Logger) (*EventRepo, err
 	}, nil
 }
 
-func (repo *EventRepo) Add(ctx context.Context, event entities.Event) (string, error) {
+// Add inserts event and its created message to outbox in one transaction.
+func (repo *EventRepo) Add(ctx context.Context, event entities.Event) (id string, err error) {
 	dbEvent, err := fromDomainEvent(event)
 	if err != nil {
 		return "", errors.Wrap(err, ErrConvert)
 	}
-	var id string
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrAdd, event)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	row := repo.db.QueryRowContext(ctx, `INSERT INTO public.events(
-	id, title, datetime, duration, text, userid, timenotify)
-	values (uuid_generate_v4(),$1, $2, $3, $4, $5, $6) returning id`, dbEvent.Title, dbEvent.DateTime, dbEvent.Duration, dbEvent.Text, dbEvent.UserID, dbEvent.TimeNotify)
+	row := tracedQueryRow(ctx, tx, `INSERT INTO public.events(
+	id, title, datetime, duration, text, userid, rrule, exdate, timezone, calendarid)
+	values (uuid_generate_v4(),$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid) returning id`, dbEvent.Title, dbEvent.DateTime, dbEvent.Duration, dbEvent.Text, dbEvent.UserID, dbEvent.RRule, dbEvent.ExDate, dbEvent.TimeZone, dbEvent.CalendarID)
 
 	if row == nil {
 		return "", errors.Wrapf(errors.New("insert query return nil row"), ErrAdd, event)
@@ -76,35 +166,48 @@ func (repo *EventRepo) Add(ctx context.Context, event entities.Event) (string, e
 
 	err = row.Scan(&id)
 	if err != nil {
-		if strings.Contains(err.Error(), uniqueViolation) {
+		if isBusyViolation(err) {
 			//yes, it is bad practice but we are inside the
 			//"interface layer" and we could't use driver errors such as https://github.com/jackc/pgx/wiki/Error-Handling,
 			//because driver - "infrastructure layer" (dependency direction1)
-			return "", entities.ErrDateBusy
+			return "", repo.busyError(ctx, dbEvent, "")
 		}
 		return "", errors.Wrapf(err, ErrAdd, event)
 	}
+	event.ID = id
+	if err = writeReminders(ctx, tx, id, event.Reminders); err != nil {
+		return "", err
+	}
+	if err = writeOutbox(ctx, tx, entities.NotifyCreated, nil, event); err != nil {
+		return "", err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", errors.Wrapf(err, ErrAdd, event)
+	}
 	return id, nil
 }
 
 func (repo *EventRepo) GetByID(ctx context.Context, userID, eventID string) (*entities.Event, error) {
-	row := repo.db.QueryRowContext(ctx, `select id, title, datetime, duration, text, userid, timenotify 
+	row := tracedQueryRow(ctx, repo.db, `select `+eventColumns+` 
 												from public.events where userid=$1 and id = $2`, userID, eventID)
 	if row == nil {
 		return nil, entities.ErrEventNotFound
 	}
 	dbevent := Event{}
-	err := row.Scan(&dbevent.ID, &dbevent.Title, &dbevent.DateTime, &dbevent.Duration, &dbevent.Text, &dbevent.UserID, &dbevent.TimeNotify)
+	err := row.Scan(dbevent.fields()...)
 	if err != nil {
 		return nil, SQLError(err, fmt.Sprintf(ErrGetbyID, eventID))
 	}
-	event := toDomainEvent(dbevent)
+	event, err := toDomainEvent(dbevent)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrConvert)
+	}
 	return event, nil
 }
 
 func (repo *EventRepo) GetByDate(ctx context.Context, userID string, date time.Time) ([]*entities.Event, error) {
-	rows, err := repo.db.QueryContext(ctx, `select id, title, datetime, duration, text, userid, timenotify 
-												 	from public.events where cast (datetime as date)=cast ($1 as date) and userid=$2`, date, userID)
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` 
+												 	from public.events where (cast (datetime at time zone 'UTC' as date)=cast ($1 as date) or (rrule <> '' and cast (datetime at time zone 'UTC' as date)<=cast ($1 as date))) and userid=$2`, date.UTC(), userID)
 
 	if err != nil && err != sql.ErrNoRows {
 		return nil, SQLError(err, fmt.Sprintf(ErrGetbyDate, date))
@@ -114,18 +217,29 @@ func (repo *EventRepo) GetByDate(ctx context.Context, userID string, date time.T
 	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetbyDate, date))
 }
 
-func (repo *EventRepo) GetByNotifyDate(ctx context.Context, date time.Time) ([]*entities.Event, error) {
-	rows, err := repo.db.QueryContext(ctx, `select id, title, datetime, duration, text, userid, timenotify from public.events where timenotify is not null and (cast (datetime as date) - make_interval(secs => timenotify))=cast ($1 as date)`, date)
+func (repo *EventRepo) GetDueReminders(ctx context.Context, from, to time.Time) ([]*entities.DueReminder, error) {
+	errorString := fmt.Sprintf(ErrGetDueReminders, from, to)
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events where `+remindedEvents, from.UTC(), to.UTC())
 	if err != nil && err != sql.ErrNoRows {
-		return nil, SQLError(err, fmt.Sprintf(ErrGetbyNotifyDate, date))
+		return nil, SQLError(err, errorString)
 	}
 	defer rows.Close()
 
-	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetbyNotifyDate, date))
+	events, err := repo.rowsToEvents(rows, errorString)
+	if err != nil {
+		return nil, err
+	}
+	due := []*entities.DueReminder{}
+	for _, event := range events {
+		due = append(due, event.DueReminders(from, to)...)
+	}
+	entities.SortDueReminders(due)
+	return due, nil
 }
 
 func (repo *EventRepo) GetForPeriodByUserID(ctx context.Context, userID string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
-	rows, err := repo.db.QueryContext(ctx, `select id, title, datetime, duration, text, userid, timenotify from public.events where (datetime between  $1 and $2)  and userid=$3`, dateStart, dateEnd, userID)
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events where ((datetime between  $1 and $2) or (rrule <> '' and datetime <= $2)) 
+		and (userid=$3 or exists (select 1 from public.attendees a where a.eventid = events.id and a.userid = $3 and a.status = 'accepted'))`, dateStart, dateEnd, userID)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, SQLError(err, fmt.Sprintf(ErrGetForPeriod, dateStart, dateEnd))
 	}
@@ -135,7 +249,7 @@ func (repo *EventRepo) GetForPeriodByUserID(ctx context.Context, userID string,
 }
 
 func (repo *EventRepo) GetForPeriod(ctx context.Context, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
-	rows, err := repo.db.QueryContext(ctx, `select id, title, datetime, duration, text, userid, timenotify from public.events where (datetime between  $1 and $2)`, dateStart, dateEnd)
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events where (datetime between  $1 and $2)`, dateStart, dateEnd)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, SQLError(err, fmt.Sprintf(ErrGetForPeriod, dateStart, dateEnd))
 	}
@@ -144,62 +258,487 @@ func (repo *EventRepo) GetForPeriod(ctx context.Context, dateStart time.Time, da
 	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetForPeriod, dateStart, dateEnd))
 }
 
-func (repo *EventRepo) UpdateByID(ctx context.Context, userID, eventID string, event entities.Event) error {
-	dbEvent, err := fromDomainEvent(event)
+func (repo *EventRepo) GetOverlapping(ctx context.Context, userIDs []string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	//user ids are passed as one string because database/sql drivers don't share the way to pass arrays
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events 
+		where (userid = any(string_to_array($1, ',')::uuid[]) or exists (select 1 from public.attendees a 
+			where a.eventid = events.id and a.userid = any(string_to_array($1, ',')::uuid[]) and a.status = 'accepted')) and datetime < $3 and (rrule <> '' or public.event_period(datetime, duration) && tstzrange($2, $3, '[)'))`,
+		strings.Join(userIDs, ","), dateStart, dateEnd)
 	if err != nil {
-		return errors.Wrap(err, ErrConvert)
+		return nil, errors.Wrapf(err, ErrGetOverlapping, userIDs, dateStart, dateEnd)
 	}
+	defer rows.Close()
 
-	result, err := repo.db.ExecContext(ctx, `UPDATE public.events
-	SET title=$3, datetime=$4, duration=$5, text=$6, userid=$7, timenotify=$8
-	WHERE  userid=$1 and id=$2;`, userID, eventID, dbEvent.Title, dbEvent.DateTime, dbEvent.Duration, dbEvent.Text, dbEvent.UserID, dbEvent.TimeNotify)
+	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetOverlapping, userIDs, dateStart, dateEnd))
+}
 
+// UpdateByID updates event and adds rescheduled message to outbox in one transaction if schedule of event is changed.
+func (repo *EventRepo) UpdateByID(ctx context.Context, userID, eventID string, event entities.Event) (err error) {
+	dbEvent, err := fromDomainEvent(event)
+	if err != nil {
+		return errors.Wrap(err, ErrConvert)
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrapf(err, ErrUpdatebyID, eventID)
 	}
-
-	rows, err := result.RowsAffected()
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	old, err := repo.lockEvent(ctx, tx, `userid=$1 and id=$2`, userID, eventID)
 	if err != nil {
 		return errors.Wrapf(err, ErrUpdatebyID, eventID)
 	}
-	if rows != 1 {
+	if old == nil {
 		return entities.ErrEventNotFound
 	}
+	//event is locked, so its version can't be changed till commit
+	if err = old.CheckVersion(event.Version); err != nil {
+		return errors.Wrapf(err, ErrUpdatebyID, eventID)
+	}
 
+	_, err = tracedExec(ctx, tx, `UPDATE public.events
+	SET title=$3, datetime=$4, duration=$5, text=$6, userid=$7, rrule=$8, exdate=$9, timezone=$10, version=version+1
+	WHERE  userid=$1 and id=$2;`, userID, eventID, dbEvent.Title, dbEvent.DateTime, dbEvent.Duration, dbEvent.Text, dbEvent.UserID, dbEvent.RRule, dbEvent.ExDate, dbEvent.TimeZone)
+
+	if err != nil {
+		if isBusyViolation(err) {
+			return repo.busyError(ctx, dbEvent, eventID)
+		}
+		return errors.Wrapf(err, ErrUpdatebyID, eventID)
+	}
+
+	event.ID = eventID
+	event.Attendees = old.Attendees
+	if entities.FormatReminders(old.Reminders) != entities.FormatReminders(event.Reminders) {
+		if err = writeReminders(ctx, tx, eventID, event.Reminders); err != nil {
+			return err
+		}
+	}
+	if err = writeOutbox(ctx, tx, entities.NotifyRescheduled, old, event); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, ErrUpdatebyID, eventID)
+	}
 	return nil
 }
 
-func (repo *EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string) error {
-	result, err := repo.db.ExecContext(ctx, `DELETE FROM public.events where  userid=$1 and id=$2;`, userID, eventID)
+func (repo *EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string, version int64) error {
+	return repo.delete(ctx, eventID, version, `userid=$1 and id=$2`, userID, eventID)
+}
+
+func (repo *EventRepo) DeleteByID(ctx context.Context, eventID string) error {
+	return repo.delete(ctx, eventID, 0, `id=$1`, eventID)
+}
+
+// delete deletes event selected by condition if it has version and adds cancelled message to outbox in one transaction.
+func (repo *EventRepo) delete(ctx context.Context, eventID string, version int64, condition string, args ...interface{}) (err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrapf(err, ErrDeletebyID, eventID)
 	}
-
-	rows, err := result.RowsAffected()
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	event, err := repo.lockEvent(ctx, tx, condition, args...)
 	if err != nil {
 		return errors.Wrapf(err, ErrDeletebyID, eventID)
 	}
-	if rows != 1 {
+	if event == nil {
 		return entities.ErrEventNotFound
 	}
+	if err = event.CheckVersion(version); err != nil {
+		return errors.Wrapf(err, ErrDeletebyID, eventID)
+	}
+	if _, err = tracedExec(ctx, tx, `DELETE FROM public.events where id=$1;`, eventID); err != nil {
+		return errors.Wrapf(err, ErrDeletebyID, eventID)
+	}
+	if err = writeOutbox(ctx, tx, entities.NotifyCancelled, nil, *event); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, ErrDeletebyID, eventID)
+	}
+	return nil
+}
+
+// lockEvent reads event selected by condition and locks it till the end of transaction, it returns nil if there is no such event.
+func (repo *EventRepo) lockEvent(ctx context.Context, tx *sql.Tx, condition string, args ...interface{}) (*entities.Event, error) {
+	rows, err := tracedQuery(ctx, tx, `select `+eventColumns+` from public.events where `+condition+` for update`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events, err := repo.rowsToEvents(rows, "can't lock event")
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// DeleteOlderThan removes events which aren't recurring by statement in database, last occurrence of recurring event
+// depends on its rule, so recurring candidates are checked by domain and removed by IDs.
+func (repo *EventRepo) DeleteOlderThan(ctx context.Context, query entities.CleanQuery) (removed int, err error) {
+	errorString := fmt.Sprintf(ErrDeleteOlderThan, query.Before)
+	if query.Batch < 1 {
+		query.Batch = defaultCleanBatch
+	}
+	scope := func(args ...interface{}) []interface{} {
+		return append([]interface{}{query.Before.UTC(), query.UserID, query.CalendarID,
+			strings.Join(query.ExceptUserIDs, ","), strings.Join(query.ExceptCalendarIDs, ",")}, args...)
+	}
+
+	for ctx.Err() == nil {
+		n, err := repo.removeEvents(ctx, query.Mode, `rrule = '' and datetime < $1 and `+cleanScope+` order by datetime limit $6`, scope(query.Batch)...)
+		removed += n
+		if err != nil {
+			return removed, errors.Wrap(err, errorString)
+		}
+		if n < query.Batch {
+			break
+		}
+	}
+
+	after := uuid.Nil.String()
+	for ctx.Err() == nil {
+		rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events 
+			where rrule <> '' and datetime < $1 and `+cleanScope+` and id > $6::uuid order by id limit $7`, scope(after, query.Batch)...)
+		if err != nil {
+			return removed, SQLError(err, errorString)
+		}
+		candidates, err := repo.rowsToEvents(rows, errorString)
+		rows.Close()
+		if err != nil {
+			return removed, err
+		}
+		ids := []string{}
+		for _, candidate := range candidates {
+			if candidate.EndsBefore(query.Before) {
+				ids = append(ids, candidate.ID)
+			}
+		}
+		if len(ids) > 0 {
+			n, err := repo.removeEvents(ctx, query.Mode, `id::text = any(string_to_array($1, ','))`, strings.Join(ids, ","))
+			removed += n
+			if err != nil {
+				return removed, errors.Wrap(err, errorString)
+			}
+		}
+		if len(candidates) < query.Batch {
+			break
+		}
+		after = candidates[len(candidates)-1].ID
+	}
+	return removed, ctx.Err()
+}
+
+// removeEvents deletes or archives events selected by condition in one statement, rows locked by concurrent
+// transactions are skipped till the next run.
+func (repo *EventRepo) removeEvents(ctx context.Context, mode entities.RetentionMode, condition string, args ...interface{}) (int, error) {
+	selected := `select id from public.events where ` + condition + ` for update skip locked`
+	statement := `DELETE FROM public.events where id in (` + selected + `)`
+	if mode == entities.RetentionArchive {
+		statement = `WITH removed AS (DELETE FROM public.events where id in (` + selected + `) RETURNING ` + archiveColumns + `)
+		INSERT INTO public.events_archive(id, title, datetime, duration, text, userid, reminders, rrule, exdate, timezone, calendarid, attendees)
+		SELECT * FROM removed`
+	}
+	result, err := tracedExec(ctx, repo.db, statement, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
 
+// writeReminders replaces reminders of event, notify state of removed reminders is kept until the event is deleted.
+func writeReminders(ctx context.Context, tx *sql.Tx, eventID string, reminders []entities.Reminder) error {
+	if _, err := tracedExec(ctx, tx, `DELETE FROM public.reminders where eventid=$1`, eventID); err != nil {
+		return errors.Wrapf(err, ErrWriteReminders, eventID)
+	}
+	for _, r := range reminders {
+		_, err := tracedExec(ctx, tx, `INSERT INTO public.reminders(eventid, remindbefore, channel) values ($1, $2, $3)`, eventID, int(r.Offset.Seconds()), string(r.Channel))
+		if err != nil {
+			return errors.Wrapf(err, ErrWriteReminders, eventID)
+		}
+	}
 	return nil
 }
 
-func (repo *EventRepo) DeleteByID(ctx context.Context, eventID string) error {
-	result, err := repo.db.ExecContext(ctx, `DELETE FROM public.events where id=$1;`, eventID)
+// writeOutbox adds lifecycle message of event change to outbox in transaction of the change, see entities.NewOutboxMessage.
+func writeOutbox(ctx context.Context, tx *sql.Tx, kind entities.NotifyKind, old *entities.Event, event entities.Event) error {
+	m, ok := entities.NewOutboxMessage(kind, old, event, time.Now())
+	if !ok {
+		return nil
+	}
+	_, err := tracedExec(ctx, tx, `INSERT INTO public.outbox(messageid, kind, eventid, title, datetime, recipients) values ($1, $2, $3, $4, $5, $6)`,
+		uuid.NewV4(), string(m.Kind), m.EventID, m.Title, m.DateTime.UTC(), strings.Join(m.Recipients, ","))
 	if err != nil {
-		return errors.Wrapf(err, ErrDeletebyID, eventID)
+		return errors.Wrapf(err, ErrWriteOutbox, m.Kind, m.EventID)
 	}
+	return nil
+}
 
+// RelayOutbox locks messages, so several relays can run concurrently. Message is removed in the transaction of publishing,
+// so it is published again if transaction fails after publishing, consumers skip it by message id.
+func (repo *EventRepo) RelayOutbox(ctx context.Context, limit int, publish entities.OutboxPublisher) (published int, err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrRelayOutbox)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	rows, err := tracedQuery(ctx, tx, `select id, messageid, kind, eventid, title, datetime, recipients from public.outbox
+		order by id limit $1 for update skip locked`, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrRelayOutbox)
+	}
+	messages := []entities.OutboxMessage{}
+	for rows.Next() {
+		var m entities.OutboxMessage
+		var recipients string
+		if err = rows.Scan(&m.ID, &m.MessageID, &m.Kind, &m.EventID, &m.Title, &m.DateTime, &recipients); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, ErrRelayOutbox)
+		}
+		m.DateTime = m.DateTime.UTC()
+		m.Recipients = strings.Split(recipients, ",")
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, errors.Wrap(err, ErrRelayOutbox)
+	}
+
+	var publishErr error
+	for _, m := range messages {
+		//the next messages wait for the failed one to keep order of changes
+		if publishErr = publish(m); publishErr != nil {
+			break
+		}
+		if _, err = tracedExec(ctx, tx, `DELETE FROM public.outbox where id=$1`, m.ID); err != nil {
+			return 0, errors.Wrap(err, ErrRelayOutbox)
+		}
+		published++
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, ErrRelayOutbox)
+	}
+	if publishErr != nil {
+		return published, errors.Wrap(publishErr, ErrRelayOutbox)
+	}
+	return published, nil
+}
+
+func (repo *EventRepo) FetchDueUnnotified(ctx context.Context, from, now time.Time) (due []*entities.DueReminder, err error) {
+	errorString := fmt.Sprintf(ErrFetchDue, from, now)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errorString)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	//events aren't locked, every reminder is claimed only once by its notifications row, claims of concurrent schedulers
+	//wait for each other in the same order of events
+	rows, err := tracedQuery(ctx, tx, `select `+eventColumns+` from public.events where `+remindedEvents+`
+		order by id`, from.UTC(), now.UTC())
+	if err != nil {
+		return nil, SQLError(err, errorString)
+	}
+	candidates, err := repo.rowsToEvents(rows, errorString)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	due = []*entities.DueReminder{}
+	for _, candidate := range candidates {
+		for _, d := range candidate.DueReminders(from, now) {
+			claimed, err := claim(ctx, tx, d, now)
+			if err != nil {
+				return nil, errors.Wrap(err, errorString)
+			}
+			if claimed {
+				due = append(due, d)
+			}
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, errorString)
+	}
+	entities.SortDueReminders(due)
+	return due, nil
+}
+
+// claim marks reminder of occurrence as being notified now if it isn't notified yet and isn't claimed by other scheduler.
+// Claim time is truncated to precision of database, so RenewClaim finds it.
+func claim(ctx context.Context, tx *sql.Tx, d *entities.DueReminder, now time.Time) (bool, error) {
+	now = now.Truncate(time.Microsecond)
+	result, err := tracedExec(ctx, tx, `INSERT INTO public.notifications(eventid, occurrence, remindbefore, channel, claimedat) values ($1, $2, $3, $4, $5)
+		ON CONFLICT (eventid, occurrence, remindbefore, channel) DO UPDATE SET claimedat = excluded.claimedat
+		WHERE notifications.notifiedat is null and notifications.claimedat < $6`,
+		d.Event.ID, d.Event.DateTime, int(d.Reminder.Offset.Seconds()), string(d.Reminder.Channel), now, now.Add(-entities.NotifyClaimTTL))
+	if err != nil {
+		return false, err
+	}
 	rows, err := result.RowsAffected()
 	if err != nil {
-		return errors.Wrapf(err, ErrDeletebyID, eventID)
+		return false, err
 	}
-	if rows != 1 {
-		return entities.ErrEventNotFound
+	if rows == 1 {
+		d.ClaimedAt = now
+	}
+	return rows == 1, nil
+}
+
+func (repo *EventRepo) RenewClaim(ctx context.Context, d *entities.DueReminder, now time.Time) (bool, error) {
+	now = now.Truncate(time.Microsecond)
+	result, err := tracedExec(ctx, repo.db, `UPDATE public.notifications SET claimedat=$6
+		WHERE eventid=$1 and occurrence=$2 and remindbefore=$3 and channel=$4 and notifiedat is null and claimedat=$5`,
+		d.Event.ID, d.Event.DateTime, int(d.Reminder.Offset.Seconds()), string(d.Reminder.Channel), d.ClaimedAt, now)
+	if err != nil {
+		return false, errors.Wrapf(err, ErrRenewClaim, d.Reminder, d.Event.ID, d.Event.DateTime)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, ErrRenewClaim, d.Reminder, d.Event.ID, d.Event.DateTime)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	d.ClaimedAt = now
+	return true, nil
+}
+
+func (repo *EventRepo) MarkNotified(ctx context.Context, eventID string, occurrence time.Time, reminder entities.Reminder) error {
+	_, err := tracedExec(ctx, repo.db, `INSERT INTO public.notifications(eventid, occurrence, remindbefore, channel, claimedat, notifiedat) values ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (eventid, occurrence, remindbefore, channel) DO UPDATE SET notifiedat = excluded.notifiedat`,
+		eventID, occurrence, int(reminder.Offset.Seconds()), string(reminder.Channel), time.Now())
+	if err != nil {
+		return errors.Wrapf(err, ErrMarkNotified, reminder, eventID, occurrence)
+	}
+	return nil
+}
+
+func (repo *EventRepo) GetOldestUnnotified(ctx context.Context, from time.Time) (time.Time, error) {
+	var oldest sql.NullTime
+	err := tracedQueryRow(ctx, repo.db, `select min(occurrence at time zone 'UTC' - make_interval(secs => remindbefore)) from public.notifications
+		where notifiedat is null and (occurrence at time zone 'UTC' - make_interval(secs => remindbefore)) >= $1`, from.UTC()).Scan(&oldest)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, ErrGetUnnotified, from)
+	}
+	if !oldest.Valid {
+		return time.Time{}, nil
+	}
+	return oldest.Time.UTC(), nil
+}
+
+func (repo *EventRepo) GetWatermark(ctx context.Context, name string) (time.Time, error) {
+	var watermark time.Time
+	err := tracedQueryRow(ctx, repo.db, `select watermark from public.watermarks where name=$1`, name).Scan(&watermark)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, ErrGetWatermark, name)
+	}
+	return watermark, nil
+}
+
+func (repo *EventRepo) SetWatermark(ctx context.Context, name string, watermark time.Time) error {
+	_, err := tracedExec(ctx, repo.db, `INSERT INTO public.watermarks(name, watermark) values ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET watermark = excluded.watermark`, name, watermark)
+	if err != nil {
+		return errors.Wrapf(err, ErrSetWatermark, name, watermark)
+	}
+	return nil
+}
+
+// ReserveIdempotency removes expired records before insert, so expired key is reserved again.
+func (repo *EventRepo) ReserveIdempotency(ctx context.Context, record entities.Idempotency, now time.Time) (existing *entities.Idempotency, err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tracedExec(ctx, tx, `DELETE FROM public.idempotency where expiresat <= $1`, now); err != nil {
+		return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+	}
+	result, err := tracedExec(ctx, tx, `INSERT INTO public.idempotency(userid, key, fingerprint, response, expiresat) values ($1, $2, $3, $4, $5)
+		ON CONFLICT (userid, key) DO NOTHING`, record.UserID, record.Key, record.Fingerprint, record.Response, record.ExpiresAt)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+	}
+	if inserted == 0 {
+		existing = &entities.Idempotency{UserID: record.UserID, Key: record.Key}
+		err = tracedQueryRow(ctx, tx, `select fingerprint, response, expiresat from public.idempotency where userid=$1 and key=$2`,
+			record.UserID, record.Key).Scan(&existing.Fingerprint, &existing.Response, &existing.ExpiresAt)
+		if err != nil {
+			return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, ErrReserveKey, record.Key, record.UserID)
+	}
+	return existing, nil
+}
+
+func (repo *EventRepo) CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) error {
+	result, err := tracedExec(ctx, repo.db, `UPDATE public.idempotency SET response=$3, expiresat=$4 where userid=$1 and key=$2`,
+		userID, key, response, expiresAt)
+	if err != nil {
+		return errors.Wrapf(err, ErrCompleteKey, key, userID)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, ErrCompleteKey, key, userID)
+	}
+	if updated == 0 {
+		return errors.Wrapf(entities.ErrIdempotencyMissed, ErrCompleteKey, key, userID)
 	}
+	return nil
+}
+
+func (repo *EventRepo) RenewIdempotency(ctx context.Context, userID, key string, expiresAt time.Time) error {
+	result, err := tracedExec(ctx, repo.db, `UPDATE public.idempotency SET expiresat=$3 where userid=$1 and key=$2 and response=''`,
+		userID, key, expiresAt)
+	if err != nil {
+		return errors.Wrapf(err, ErrRenewKey, key, userID)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, ErrRenewKey, key, userID)
+	}
+	if updated == 0 {
+		return errors.Wrapf(entities.ErrIdempotencyMissed, ErrRenewKey, key, userID)
+	}
+	return nil
+}
 
+func (repo *EventRepo) ReleaseIdempotency(ctx context.Context, userID, key string) error {
+	_, err := tracedExec(ctx, repo.db, `DELETE FROM public.idempotency where userid=$1 and key=$2`, userID, key)
+	if err != nil {
+		return errors.Wrapf(err, ErrReleaseKey, key, userID)
+	}
 	return nil
 }
 
@@ -215,15 +754,313 @@ func (repo *EventRepo) Close() error {
 	return repo.db.Close()
 }
 
+func (repo *EventRepo) AddAttendees(ctx context.Context, eventID string, userIDs []string) error {
+	_, err := tracedExec(ctx, repo.db, `INSERT INTO public.attendees(eventid, userid, status) 
+		select $1, unnest(string_to_array($2, ',')::uuid[]), $3
+		ON CONFLICT (eventid, userid) DO NOTHING`, eventID, strings.Join(userIDs, ","), string(entities.NeedsAction))
+	if err != nil {
+		if strings.Contains(err.Error(), foreignKeyViolation) {
+			return errors.Wrapf(entities.ErrEventNotFound, ErrAddAttendees, userIDs, eventID)
+		}
+		return errors.Wrapf(err, ErrAddAttendees, userIDs, eventID)
+	}
+	return nil
+}
+
+func (repo *EventRepo) SetAttendeeStatus(ctx context.Context, eventID, userID string, status entities.AttendeeStatus) error {
+	result, err := tracedExec(ctx, repo.db, `UPDATE public.attendees SET status=$3 WHERE eventid=$1 and userid=$2`, eventID, userID, string(status))
+	if err != nil {
+		return errors.Wrapf(err, ErrSetStatus, userID, eventID)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, ErrSetStatus, userID, eventID)
+	}
+	if rows != 1 {
+		return entities.ErrNotInvited
+	}
+	return nil
+}
+
+func (repo *EventRepo) GetInvitations(ctx context.Context, userID string) ([]*entities.Event, error) {
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events 
+		where exists (select 1 from public.attendees a where a.eventid = events.id and a.userid = $1) order by datetime`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetInvitations, userID)
+	}
+	defer rows.Close()
+
+	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetInvitations, userID))
+}
+
+func (repo *EventRepo) GetByEventID(ctx context.Context, eventID string) (*entities.Event, error) {
+	row := tracedQueryRow(ctx, repo.db, `select `+eventColumns+` from public.events where id = $1`, eventID)
+	if row == nil {
+		return nil, entities.ErrEventNotFound
+	}
+	dbevent := Event{}
+	err := row.Scan(dbevent.fields()...)
+	if err != nil {
+		return nil, SQLError(err, fmt.Sprintf(ErrGetbyID, eventID))
+	}
+	event, err := toDomainEvent(dbevent)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrConvert)
+	}
+	return event, nil
+}
+
+func (repo *EventRepo) GetForPeriodByCalendarID(ctx context.Context, calendarID string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events 
+		where calendarid = $1 and ((datetime between $2 and $3) or (rrule <> '' and datetime <= $3)) order by datetime`, calendarID, dateStart, dateEnd)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetForPeriod, dateStart, dateEnd)
+	}
+	defer rows.Close()
+
+	return repo.rowsToEvents(rows, fmt.Sprintf(ErrGetForPeriod, dateStart, dateEnd))
+}
+
+func (repo *EventRepo) AddCalendar(ctx context.Context, calendar entities.Calendar) (id string, err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrAddCalendar, calendar.Title)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	err = tracedQueryRow(ctx, tx, `INSERT INTO public.calendars(id, title) values (uuid_generate_v4(), $1) returning id`, calendar.Title).Scan(&id)
+	if err != nil {
+		return "", errors.Wrapf(err, ErrAddCalendar, calendar.Title)
+	}
+	for _, entry := range calendar.ACL {
+		_, err = tracedExec(ctx, tx, `INSERT INTO public.calendar_acl(calendarid, userid, role) values ($1, $2, $3)`, id, entry.UserID, string(entry.Role))
+		if err != nil {
+			return "", errors.Wrapf(err, ErrAddCalendar, calendar.Title)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return "", errors.Wrapf(err, ErrAddCalendar, calendar.Title)
+	}
+	return id, nil
+}
+
+func (repo *EventRepo) GetCalendar(ctx context.Context, calendarID string) (*entities.Calendar, error) {
+	var id uuid.UUID
+	var title, acl string
+	err := tracedQueryRow(ctx, repo.db, `select id, title, `+aclColumn+` from public.calendars where id = $1`, calendarID).Scan(&id, &title, &acl)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entities.ErrCalendarNotFound
+		}
+		return nil, errors.Wrapf(err, ErrGetCalendar, calendarID)
+	}
+	return &entities.Calendar{ID: id.String(), Title: title, ACL: parseACL(acl)}, nil
+}
+
+func (repo *EventRepo) GetCalendarsByUserID(ctx context.Context, userID string) ([]*entities.Calendar, error) {
+	rows, err := tracedQuery(ctx, repo.db, `select id, title, `+aclColumn+` from public.calendars 
+		where exists (select 1 from public.calendar_acl c where c.calendarid = calendars.id and c.userid = $1) order by title`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrGetCalendars, userID)
+	}
+	defer rows.Close()
+
+	calendars := []*entities.Calendar{}
+	for rows.Next() {
+		var id uuid.UUID
+		var title, acl string
+		if err := rows.Scan(&id, &title, &acl); err != nil {
+			return nil, errors.Wrapf(err, ErrGetCalendars, userID)
+		}
+		calendars = append(calendars, &entities.Calendar{ID: id.String(), Title: title, ACL: parseACL(acl)})
+	}
+	if rows.Err() != nil {
+		return nil, errors.Wrapf(rows.Err(), ErrGetCalendars, userID)
+	}
+	return calendars, nil
+}
+
+func (repo *EventRepo) SetRole(ctx context.Context, calendarID, userID string, role entities.Role) error {
+	var err error
+	if role == "" {
+		_, err = tracedExec(ctx, repo.db, `DELETE FROM public.calendar_acl WHERE calendarid=$1 and userid=$2`, calendarID, userID)
+	} else {
+		_, err = tracedExec(ctx, repo.db, `INSERT INTO public.calendar_acl(calendarid, userid, role) values ($1, $2, $3)
+			ON CONFLICT (calendarid, userid) DO UPDATE SET role = excluded.role`, calendarID, userID, string(role))
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), foreignKeyViolation) {
+			return errors.Wrapf(entities.ErrCalendarNotFound, ErrSetRole, userID, calendarID)
+		}
+		return errors.Wrapf(err, ErrSetRole, userID, calendarID)
+	}
+	return nil
+}
+
+// DeleteCalendar deletes calendar and adds cancelled messages of its events to outbox in one transaction.
+func (repo *EventRepo) DeleteCalendar(ctx context.Context, calendarID string) (err error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	eventRows, err := tracedQuery(ctx, tx, `select `+eventColumns+` from public.events where calendarid = $1 for update`, calendarID)
+	if err != nil {
+		return errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	events, err := repo.rowsToEvents(eventRows, fmt.Sprintf(ErrDeleteCalendar, calendarID))
+	eventRows.Close()
+	if err != nil {
+		return err
+	}
+	//events and ACL of calendar are deleted by cascade
+	result, err := tracedExec(ctx, tx, `DELETE FROM public.calendars where id=$1;`, calendarID)
+	if err != nil {
+		return errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	if rows != 1 {
+		return entities.ErrCalendarNotFound
+	}
+	for _, event := range events {
+		if err = writeOutbox(ctx, tx, entities.NotifyCancelled, nil, *event); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, ErrDeleteCalendar, calendarID)
+	}
+	return nil
+}
+
+// ListEvents reads page by keyset of the previous page last event, so reading of any page costs the same.
+// Every series is one row, occurrences aren't expanded because keyset of the page is stored event.
+func (repo *EventRepo) ListEvents(ctx context.Context, query entities.ListQuery) (*entities.EventPage, error) {
+	order, ok := listOrders[query.Sort]
+	if !ok {
+		return nil, errors.Wrapf(entities.ErrSortOrder, ErrListEvents, query.UserID)
+	}
+	args := []interface{}{query.UserID, nullTime(query.Start), nullTime(query.End), likePattern(query.Search), query.Limit + 1}
+	after := ""
+	if query.After != nil {
+		after = " and " + order.after
+		if query.Sort.ByTitle() {
+			args = append(args, query.After.Title, query.After.ID)
+		} else {
+			args = append(args, query.After.DateTime, query.After.ID)
+		}
+	}
+	//null bounds of tstzrange are infinite, so zero start or end makes period unbounded
+	filter := `and ($3::timestamptz is null or datetime < $3)
+		and (rrule <> '' or public.event_period(datetime, duration) && tstzrange($2::timestamptz, $3::timestamptz, '[)'))
+		and ($4 = '' or title ilike $4 or text ilike $4)` + after + ` order by ` + order.order + ` limit $5`
+	//own events are read by keyset index of owner, accepted invitations by index of attendees, every branch stops at
+	//the page limit and the page is taken from their union
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+` from public.events join (
+		(select id from public.events where userid = $1 `+filter+`)
+		union all
+		(select id from public.events where userid <> $1
+		and id in (select a.eventid from public.attendees a where a.userid = $1 and a.status = 'accepted') `+filter+`)
+		) listed using (id) order by `+order.order+` limit $5`, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrListEvents, query.UserID)
+	}
+	defer rows.Close()
+
+	events, err := repo.rowsToEvents(rows, fmt.Sprintf(ErrListEvents, query.UserID))
+	if err != nil {
+		return nil, err
+	}
+	return entities.NewEventPage(events, query), nil
+}
+
+// SearchEvents matches terms as prefixes by GIN index of search column. Rank is computed as entities.Event.Rank does:
+// every term weighs TitleWeight if it is prefix of title word (A label), TextWeight if it is prefix of text word only (B label).
+func (repo *EventRepo) SearchEvents(ctx context.Context, userID string, terms []string, limit int) ([]*entities.SearchHit, error) {
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefixes = append(prefixes, term+":*")
+	}
+	//terms are words of letters and digits, so they are safe in tsquery and comma separated list
+	rows, err := tracedQuery(ctx, repo.db, `select `+eventColumns+`, (select coalesce(sum(case
+			when search @@ to_tsquery('simple', t.term || ':*A') then $4::float8
+			when search @@ to_tsquery('simple', t.term || ':*B') then $5::float8
+			else 0 end order by t.n), 0) from unnest(string_to_array($6, ',')) with ordinality t(term, n)) / $7::float8 as rank
+		from public.events 
+		where search @@ to_tsquery('simple', $2)
+		and (userid = $1 or exists (select 1 from public.attendees a where a.eventid = events.id and a.userid = $1 and a.status = 'accepted'))
+		order by rank desc, datetime desc, id limit $3`,
+		userID, strings.Join(prefixes, " | "), limit, entities.TitleWeight, entities.TextWeight, strings.Join(terms, ","), len(terms))
+	if err != nil {
+		return nil, errors.Wrapf(err, ErrSearchEvents, userID, terms)
+	}
+	defer rows.Close()
+
+	hits := []*entities.SearchHit{}
+	for rows.Next() {
+		dbevent := Event{}
+		var rank float64
+		if err := rows.Scan(append(dbevent.fields(), &rank)...); err != nil {
+			return nil, errors.Wrapf(err, ErrSearchEvents, userID, terms)
+		}
+		event, err := toDomainEvent(dbevent)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrConvert)
+		}
+		hits = append(hits, &entities.SearchHit{Event: event, Rank: rank})
+	}
+	if rows.Err() != nil {
+		return nil, errors.Wrapf(rows.Err(), ErrSearchEvents, userID, terms)
+	}
+	return hits, nil
+}
+
+// busyError returns ErrDateBusy with IDs of user events which overlap the event except event with exceptID.
+func (repo *EventRepo) busyError(ctx context.Context, dbEvent *Event, exceptID string) error {
+	rows, err := tracedQuery(ctx, repo.db, `select id from public.events where userid=$1 and id::text<>$2 
+		and public.event_period(datetime, duration) && public.event_period($3, $4) order by id`, dbEvent.UserID, exceptID, dbEvent.DateTime, dbEvent.Duration)
+	if err != nil {
+		//date is busy anyway, conflicts are unknown
+		return entities.NewDateBusyError()
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return entities.NewDateBusyError()
+		}
+		ids = append(ids, id.String())
+	}
+	return entities.NewDateBusyError(ids...)
+}
+
+func isBusyViolation(err error) bool {
+	return strings.Contains(err.Error(), exclusionViolation) || strings.Contains(err.Error(), uniqueViolation)
+}
+
 func (repo *EventRepo) rowsToEvents(rows *sql.Rows, errorString string) ([]*entities.Event, error) {
 	events := []*entities.Event{}
 	for rows.Next() {
 		dbevent := Event{}
-		err := rows.Scan(&dbevent.ID, &dbevent.Title, &dbevent.DateTime, &dbevent.Duration, &dbevent.Text, &dbevent.UserID, &dbevent.TimeNotify)
+		err := rows.Scan(dbevent.fields()...)
 		if err != nil {
 			return nil, SQLError(err, errorString)
 		}
-		event := toDomainEvent(dbevent)
+		event, err := toDomainEvent(dbevent)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrConvert)
+		}
 		events = append(events, event)
 	}
 
@@ -237,17 +1074,24 @@ func (repo *EventRepo) rowsToEvents(rows *sql.Rows, errorString string) ([]*enti
 	return events, nil
 }
 
-func toDomainEvent(dbe Event) *entities.Event {
+func toDomainEvent(dbe Event) (*entities.Event, error) {
 	event := &entities.Event{
 		ID:         dbe.ID.String(),
 		Title:      dbe.Title,
-		DateTime:   dbe.DateTime,
+		DateTime:   dbe.DateTime.UTC(),
 		Duration:   time.Second * time.Duration(dbe.Duration),
 		Text:       dbe.Text,
 		UserID:     dbe.UserID.String(),
-		TimeNotify: time.Second * time.Duration(dbe.TimeNotify),
+		Reminders:  parseReminders(dbe.Reminders),
+		TimeZone:   dbe.TimeZone,
+		Attendees:  parseAttendees(dbe.Attendees),
+		CalendarID: dbe.CalendarID,
+		Version:    dbe.Version,
+	}
+	if err := event.SetRecurrence(dbe.RRule, dbe.ExDate); err != nil {
+		return nil, errors.Wrap(err, "can't convert db event to domain event")
 	}
-	return event
+	return event, nil
 }
 func fromDomainEvent(event entities.Event) (*Event, error) {
 	id, err := uuid.FromString(event.ID)
@@ -263,12 +1107,90 @@ func fromDomainEvent(event entities.Event) (*Event, error) {
 	dbe.Title = event.Title
 	dbe.Text = event.Text
 	dbe.Duration = int(event.Duration.Seconds())
-	dbe.TimeNotify = int(event.TimeNotify.Seconds())
+	dbe.Reminders = formatReminders(event.Reminders)
 	dbe.UserID = uid
-	dbe.DateTime = event.DateTime
+	dbe.DateTime = event.DateTime.UTC()
+	dbe.RRule = event.Recurrence.RRule()
+	dbe.ExDate = event.ExDate()
+	dbe.TimeZone = event.Location().String()
+	dbe.CalendarID = event.CalendarID
 	return dbe, nil
 }
 
+// parseAttendees parses userid=status list of attendeesColumn.
+func parseAttendees(attendees string) []entities.Attendee {
+	if attendees == "" {
+		return nil
+	}
+	parsed := []entities.Attendee{}
+	for _, a := range strings.Split(attendees, ",") {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		parsed = append(parsed, entities.Attendee{UserID: kv[0], Status: entities.AttendeeStatus(kv[1])})
+	}
+	return parsed
+}
+
+// parseReminders parses seconds=channel list of remindersColumn.
+func parseReminders(reminders string) []entities.Reminder {
+	parsed := []entities.Reminder{}
+	if reminders == "" {
+		return parsed
+	}
+	for _, r := range strings.Split(reminders, ",") {
+		kv := strings.SplitN(r, "=", 2)
+		secs, err := strconv.Atoi(kv[0])
+		if len(kv) != 2 || err != nil {
+			continue
+		}
+		parsed = append(parsed, entities.Reminder{Offset: time.Duration(secs) * time.Second, Channel: entities.Channel(kv[1])})
+	}
+	return parsed
+}
+
+// formatReminders returns reminders as seconds=channel list of remindersColumn.
+func formatReminders(reminders []entities.Reminder) string {
+	formatted := make([]string, 0, len(reminders))
+	for _, r := range reminders {
+		formatted = append(formatted, strconv.Itoa(int(r.Offset.Seconds()))+"="+string(r.Channel))
+	}
+	return strings.Join(formatted, ",")
+}
+
+// parseACL parses userid=role list of aclColumn.
+func parseACL(acl string) []entities.ACLEntry {
+	parsed := []entities.ACLEntry{}
+	if acl == "" {
+		return parsed
+	}
+	for _, a := range strings.Split(acl, ",") {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		parsed = append(parsed, entities.ACLEntry{UserID: kv[0], Role: entities.Role(kv[1])})
+	}
+	return parsed
+}
+
+// nullTime returns nil for zero time, so it is passed to database as null.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// likePattern returns ilike pattern of substring search, empty search is empty pattern.
+func likePattern(search string) string {
+	if search == "" {
+		return ""
+	}
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(search) + "%"
+}
+
 func SQLError(err error, message string) error {
 	switch err {
 	case sql.ErrNoRows:
@@ -33,15 +33,14 @@ func TestDBEventRepo_GetById(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timeNotify"}).
-			AddRow(testid, "title", testdt, 360, "text", testid, 360)
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
 
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify 
-												from`).
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WithArgs(testid, testid).
 			WillReturnRows(rows)
 
-		expectedEvent := addID(newFakeEvent(), testid)
+		expectedEvent := storedEvent(newFakeEvent(), testid)
 
 		event, err := dbe.GetByID(ctx, testid, testid)
 
@@ -61,8 +60,8 @@ func TestDBEventRepo_GetById(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timeNotify"})
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"})
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WithArgs(testid, testid).
 			WillReturnRows(rows)
 
@@ -85,7 +84,7 @@ func TestDBEventRepo_GetById(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WillReturnError(sql.ErrConnDone)
 
 		dbe := EventRepo{db: db, logger: nil}
@@ -111,15 +110,15 @@ func TestDBEventRepo_GetForPeriod(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timeNotify"}).
-			AddRow(testid, "title", testdt, 360, "text", testid, 360)
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
 
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WithArgs(testdt, testdt, testid).
 			WillReturnRows(rows)
 
 		var expectedEvents []*entities.Event
-		expectedEvent := addID(newFakeEvent(), testid)
+		expectedEvent := storedEvent(newFakeEvent(), testid)
 		expectedEvents = append(expectedEvents, &expectedEvent)
 
 		events, err := dbe.GetForPeriodByUserID(ctx, testid, testdt, testdt)
@@ -140,8 +139,8 @@ func TestDBEventRepo_GetForPeriod(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timeNotify"})
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"})
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WithArgs(testdt, testdt, testid).
 			WillReturnRows(rows)
 
@@ -165,7 +164,7 @@ func TestDBEventRepo_GetForPeriod(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
 			WithArgs(testdt, testdt, testid).
 			WillReturnError(sql.ErrConnDone)
 
@@ -183,8 +182,10 @@ func TestDBEventRepo_GetForPeriod(t *testing.T) {
 
 	})
 }
-func TestDBEventRepo_GetbyNotifyDate(t *testing.T) {
-	t.Run("good test: get event by notify date", func(t *testing.T) {
+func TestDBEventRepo_GetDueReminders(t *testing.T) {
+	from := testdt.Add(-time.Hour)
+	to := testdt
+	t.Run("good test: get reminders by exact notify time", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -195,27 +196,30 @@ func TestDBEventRepo_GetbyNotifyDate(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timenotify"}).
-			AddRow(testid, "title", testdt, 360, "text", testid, 172800)
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "172800=email,360=", "", "", "UTC", "", "", 1).
+			AddRow(testid2, "title", testdt, 360, "text", testid, "1800=webhook", "", "", "UTC", "", "", 1)
 
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
-			WithArgs(testdt.AddDate(0, 0, -2)).
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+) from public.events where exists (.+)make_interval(.+) <= \$2`).
+			WithArgs(from, to).
 			WillReturnRows(rows)
 
-		var expectedEvents []*entities.Event
-		expectedEvent := addID(newFakeEvent("172800s"), testid)
-		expectedEvents = append(expectedEvents, &expectedEvent)
+		event := storedEvent(newFakeEvent("48h:email,6m"), testid)
+		event2 := storedEvent(newFakeEvent("30m:webhook"), testid2)
 
-		events, err := dbe.GetByNotifyDate(ctx, testdt.AddDate(0, 0, -2))
+		due, err := dbe.GetDueReminders(ctx, from, to)
 
 		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
 			t.Errorf("there were unfulfilled expectations: %s", mockerr)
 		}
 
-		require.Equal(t, expectedEvents, events)
 		require.Nil(t, err)
+		require.Equal(t, []*entities.DueReminder{
+			{Event: &event2, Reminder: event2.Reminders[0]},
+			{Event: &event, Reminder: event.Reminders[1]},
+		}, due, "only reminders with notify time in period are returned by notify time")
 	})
-	t.Run("no rows: get event for period", func(t *testing.T) {
+	t.Run("no rows: get reminders", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -224,22 +228,21 @@ func TestDBEventRepo_GetbyNotifyDate(t *testing.T) {
 
 		ctx := context.TODO()
 
-		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "timeNotify"})
-		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, timenotify from`).
-			WithArgs(testdt.AddDate(0, 0, -2)).
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"})
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce`).
+			WithArgs(from, to).
 			WillReturnRows(rows)
 
 		dbe := EventRepo{db: db, logger: nil}
-		expectedEvents := []*entities.Event{}
 
-		events, err := dbe.GetByNotifyDate(ctx, testdt.AddDate(0, 0, -2))
+		due, err := dbe.GetDueReminders(ctx, from, to)
 
 		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
 			t.Errorf("there were unfulfilled expectations: %s", mockerr)
 		}
 
-		require.Equalf(t, expectedEvents, events, "")
-
+		require.Nil(t, err)
+		require.Empty(t, due)
 	})
 }
 func TestDBEventRepo_Add(t *testing.T) {
@@ -256,9 +259,20 @@ func TestDBEventRepo_Add(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id"}).
 			AddRow(testid)
 
+		mock.ExpectBegin()
 		mock.ExpectQuery(`INSERT INTO public.events`).
-			WithArgs("title", testdt, 360, "text", testid, 360).
+			WithArgs("title", testdt, 360, "text", testid, "", "", "UTC", "").
 			WillReturnRows(rows)
+		mock.ExpectExec(`DELETE FROM public.reminders`).
+			WithArgs(testid).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO public.reminders`).
+			WithArgs(testid, 360, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO public.outbox`).
+			WithArgs(sqlmock.AnyArg(), string(entities.NotifyCreated), testid, "title", testdt, testid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		event := newFakeEvent()
 		id, err := dbe.Add(ctx, event)
@@ -281,9 +295,11 @@ func TestDBEventRepo_Add(t *testing.T) {
 
 		ctx := context.TODO()
 
+		mock.ExpectBegin()
 		mock.ExpectQuery(`INSERT INTO public.events`).
-			WithArgs("title", testdt, 360, "text", testid, 360).
+			WithArgs("title", testdt, 360, "text", testid, "", "", "UTC", "").
 			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
 
 		event := newFakeEvent()
 		id, err := dbe.Add(ctx, event)
@@ -306,9 +322,14 @@ func TestDBEventRepo_Add(t *testing.T) {
 
 		ctx := context.TODO()
 
+		mock.ExpectBegin()
 		mock.ExpectQuery(`INSERT INTO public.events`).
-			WithArgs("title", testdt, 360, "text", testid, 360).
+			WithArgs("title", testdt, 360, "text", testid, "", "", "UTC", "").
 			WillReturnError(errors.New(uniqueViolation))
+		mock.ExpectQuery(`select id from public.events`).
+			WithArgs(testid, "", testdt, 360).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
 
 		event := newFakeEvent()
 		id, err := dbe.Add(ctx, event)
@@ -321,32 +342,849 @@ func TestDBEventRepo_Add(t *testing.T) {
 		require.True(t, errors.Is(err, entities.ErrDateBusy))
 		require.Containsf(t, err.Error(), entities.ErrDateBusy.Error(), "")
 	})
+	t.Run("return overlapping events: add event", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO public.events`).
+			WithArgs("title", testdt, 360, "text", testid, "", "", "UTC", "").
+			WillReturnError(errors.New(exclusionViolation))
+		mock.ExpectQuery(`select id from public.events`).
+			WithArgs(testid, "", testdt, 360).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testid).AddRow(testid2))
+		mock.ExpectRollback()
+
+		event := newFakeEvent()
+		id, err := dbe.Add(ctx, event)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+
+		require.Equal(t, "", id, "generated id must be empty")
+		require.True(t, errors.Is(err, entities.ErrDateBusy))
+		require.Equal(t, []string{testid, testid2}, entities.ConflictingEventIDs(err))
+	})
+}
+
+func TestDBEventRepo_UpdateByID(t *testing.T) {
+	eventRows := func(dt time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid2, "title", dt, 360, "text", testid, "360=", "", "", "UTC", "", testid3+"=accepted", 1)
+	}
+	t.Run("good test: reschedule event", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(eventRows(testdt.Add(time.Hour)))
+		mock.ExpectExec(`UPDATE public.events(.+)version=version\+1`).
+			WithArgs(testid, testid2, "title", testdt, 360, "text", testid, "", "", "UTC").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO public.outbox`).
+			WithArgs(sqlmock.AnyArg(), string(entities.NotifyRescheduled), testid2, "title", testdt, testid+","+testid3).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err = dbe.UpdateByID(ctx, testid, testid2, newFakeEvent())
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+	})
+	t.Run("good test: update without rescheduling", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(eventRows(testdt))
+		mock.ExpectExec(`UPDATE public.events`).
+			WithArgs(testid, testid2, "title", testdt, 360, "text", testid, "", "", "UTC").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = dbe.UpdateByID(ctx, testid, testid2, newFakeEvent())
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+	})
+	t.Run("stale version: update event", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(eventRows(testdt))
+		mock.ExpectRollback()
+
+		event := newFakeEvent()
+		event.Version = 2
+		err = dbe.UpdateByID(ctx, testid, testid2, event)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Truef(t, errors.Is(err, entities.ErrVersionConflict), "return error must be: %q", entities.ErrVersionConflict)
+	})
+	t.Run("not found: update event", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err = dbe.UpdateByID(ctx, testid, testid2, newFakeEvent())
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Equal(t, entities.ErrEventNotFound, err)
+	})
+	t.Run("return overlapping events: update event", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(eventRows(testdt))
+		mock.ExpectExec(`UPDATE public.events`).
+			WithArgs(testid, testid2, "title", testdt, 360, "text", testid, "", "", "UTC").
+			WillReturnError(errors.New(exclusionViolation))
+		mock.ExpectQuery(`select id from public.events`).
+			WithArgs(testid, testid2, testdt, 360).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testid))
+		mock.ExpectRollback()
+
+		err = dbe.UpdateByID(ctx, testid, testid2, newFakeEvent())
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+
+		require.True(t, errors.Is(err, entities.ErrDateBusy))
+		require.Equal(t, []string{testid}, entities.ConflictingEventIDs(err))
+	})
+}
+
+func TestDBEventRepo_Outbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+	future := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+
+	t.Run("delete writes cancelled message", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+				AddRow(testid2, "title", future, 360, "text", testid, "360=", "", "", "UTC", "", "", 1))
+		mock.ExpectExec(`DELETE FROM public.events`).
+			WithArgs(testid2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO public.outbox`).
+			WithArgs(sqlmock.AnyArg(), string(entities.NotifyCancelled), testid2, "title", future, testid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.Nil(t, dbe.DeleteByID(ctx, testid2))
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+	})
+	t.Run("delete of ended event writes nothing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+				AddRow(testid2, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1))
+		mock.ExpectExec(`DELETE FROM public.events`).
+			WithArgs(testid2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.Nil(t, dbe.DeleteByUserID(ctx, testid, testid2, 0))
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+	})
+	t.Run("delete unknown event", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		require.Equal(t, entities.ErrEventNotFound, dbe.DeleteByID(ctx, testid2))
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+	})
+	t.Run("delete stale version", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid, testid2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+				AddRow(testid2, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 2))
+		mock.ExpectRollback()
+
+		err := dbe.DeleteByUserID(ctx, testid, testid2, 1)
+		require.Truef(t, errors.Is(err, entities.ErrVersionConflict), "return error must be: %q", entities.ErrVersionConflict)
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+	})
+	t.Run("relay stops at failed message", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, messageid, kind, eventid, title, datetime, recipients from public.outbox order by id limit (.+) for update skip locked`).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "messageid", "kind", "eventid", "title", "datetime", "recipients"}).
+				AddRow(1, testid3, "created", testid2, "title", testdt, testid+","+testid3).
+				AddRow(2, testid, "cancelled", testid2, "title", testdt, testid))
+		mock.ExpectExec(`DELETE FROM public.outbox`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		relayed := []entities.OutboxMessage{}
+		published, err := dbe.RelayOutbox(ctx, 10, func(m entities.OutboxMessage) error {
+			if m.Kind == entities.NotifyCancelled {
+				return errors.New("queue is unavailable")
+			}
+			relayed = append(relayed, m)
+			return nil
+		})
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.NotNil(t, err)
+		require.Equal(t, 1, published)
+		require.Equal(t, []entities.OutboxMessage{{
+			ID: 1, MessageID: testid3, Kind: entities.NotifyCreated, EventID: testid2, Title: "title", DateTime: testdt, Recipients: []string{testid, testid3},
+		}}, relayed)
+	})
 }
+
+func TestDBEventRepo_GetOverlapping(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+
+	ctx := context.TODO()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+		AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
+
+	mock.ExpectQuery(`where \(userid = any`).
+		WithArgs(testid+","+testid2, testdt, testdt.Add(time.Hour)).
+		WillReturnRows(rows)
+
+	events, err := dbe.GetOverlapping(ctx, []string{testid, testid2}, testdt, testdt.Add(time.Hour))
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+	require.Nil(t, err)
+	expectedE := storedEvent(newFakeEvent(), testid)
+	require.Equal(t, []*entities.Event{&expectedE}, events)
+}
+
+func TestDBEventRepo_FetchDueUnnotified(t *testing.T) {
+	now := testdt.Add(-time.Minute)
+	from := now.Add(-time.Hour)
+	t.Run("good test: claim due occurrences", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "3600=email,360=", "", "", "UTC", "", "", 1).
+			AddRow(testid2, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`rrule <> '' and coalesce\(\(to_timestamp\(substring\(rrule from 'UNTIL(.+) order by id`).
+			WithArgs(from, now).
+			WillReturnRows(rows)
+		mock.ExpectExec(`INSERT INTO public.notifications`).
+			WithArgs(testid, testdt, 3600, "email", now, now.Add(-entities.NotifyClaimTTL)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO public.notifications`).
+			WithArgs(testid, testdt, 360, "", now, now.Add(-entities.NotifyClaimTTL)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		//already claimed by other scheduler
+		mock.ExpectExec(`INSERT INTO public.notifications`).
+			WithArgs(testid2, testdt, 360, "", now, now.Add(-entities.NotifyClaimTTL)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		expectedEvent := storedEvent(newFakeEvent("1h:email,6m"), testid)
+
+		due, err := dbe.FetchDueUnnotified(ctx, from, now)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Equal(t, []*entities.DueReminder{
+			{Event: &expectedEvent, Reminder: expectedEvent.Reminders[0], ClaimedAt: now},
+			{Event: &expectedEvent, Reminder: expectedEvent.Reminders[1], ClaimedAt: now},
+		}, due)
+	})
+	t.Run("bad test: rollback on claim error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+
+		ctx := context.TODO()
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`rrule <> '' and coalesce\(\(to_timestamp\(substring\(rrule from 'UNTIL(.+) order by id`).
+			WithArgs(from, now).
+			WillReturnRows(rows)
+		mock.ExpectExec(`INSERT INTO public.notifications`).
+			WillReturnError(errors.New("connection lost"))
+		mock.ExpectRollback()
+
+		due, err := dbe.FetchDueUnnotified(ctx, from, now)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, due)
+		require.NotNil(t, err)
+	})
+}
+
+func TestDBEventRepo_RenewClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+	event := storedEvent(newFakeEvent(), testid)
+	claimedAt := testdt.Add(-time.Hour)
+	d := &entities.DueReminder{Event: &event, Reminder: entities.Reminder{Offset: 6 * time.Minute}, ClaimedAt: claimedAt}
+	now := claimedAt.Add(time.Minute + time.Nanosecond)
+
+	mock.ExpectExec(`UPDATE public.notifications SET claimedat=\$6 (.+) and notifiedat is null and claimedat=\$5`).
+		WithArgs(testid, testdt, 360, "", claimedAt, now.Truncate(time.Microsecond)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE public.notifications SET claimedat`).
+		WithArgs(testid, testdt, 360, "", now.Truncate(time.Microsecond), now.Add(time.Minute).Truncate(time.Microsecond)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	renewed, err := dbe.RenewClaim(ctx, d, now)
+	require.Nil(t, err)
+	require.True(t, renewed)
+	require.Equal(t, now.Truncate(time.Microsecond), d.ClaimedAt)
+
+	renewed, err = dbe.RenewClaim(ctx, d, now.Add(time.Minute))
+	require.Nil(t, err)
+	require.False(t, renewed, "claim is lost")
+	require.Equal(t, now.Truncate(time.Microsecond), d.ClaimedAt)
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+}
+
+func TestDBEventRepo_MarkNotified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+
+	mock.ExpectExec(`INSERT INTO public.notifications`).
+		WithArgs(testid, testdt, 360, "email", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = dbe.MarkNotified(context.TODO(), testid, testdt, entities.Reminder{Offset: 6 * time.Minute, Channel: entities.ChannelEmail})
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+	require.Nil(t, err)
+}
+
+func TestDBEventRepo_Watermark(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+
+	mock.ExpectQuery(`select watermark from public.watermarks`).
+		WithArgs("alerts").
+		WillReturnRows(sqlmock.NewRows([]string{"watermark"}))
+	mock.ExpectExec(`INSERT INTO public.watermarks`).
+		WithArgs("alerts", testdt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`select watermark from public.watermarks`).
+		WithArgs("alerts").
+		WillReturnRows(sqlmock.NewRows([]string{"watermark"}).AddRow(testdt))
+
+	watermark, err := dbe.GetWatermark(ctx, "alerts")
+	require.Nil(t, err)
+	require.True(t, watermark.IsZero(), "scan never ran")
+	require.Nil(t, dbe.SetWatermark(ctx, "alerts", testdt))
+	watermark, err = dbe.GetWatermark(ctx, "alerts")
+	require.Nil(t, err)
+	require.Equal(t, testdt, watermark)
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+}
+
+func TestDBEventRepo_Idempotency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+	record := entities.Idempotency{UserID: testid, Key: "key", Fingerprint: "add", ExpiresAt: testdt.Add(time.Minute)}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM public.idempotency where expiresat`).
+		WithArgs(testdt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO public.idempotency`).
+		WithArgs(testid, "key", "add", "", record.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE public.idempotency SET expiresat=(.+) and response=''`).
+		WithArgs(testid, "key", testdt.Add(2*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE public.idempotency SET response`).
+		WithArgs(testid, "key", "id", testdt.Add(time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM public.idempotency where expiresat`).
+		WithArgs(testdt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO public.idempotency`).
+		WithArgs(testid, "key", "update", "", record.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`select fingerprint, response, expiresat from public.idempotency`).
+		WithArgs(testid, "key").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "response", "expiresat"}).AddRow("add", "id", testdt.Add(time.Hour)))
+	mock.ExpectCommit()
+	mock.ExpectExec(`DELETE FROM public.idempotency where userid`).
+		WithArgs(testid, "key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE public.idempotency SET response`).
+		WithArgs(testid, "key", "id", testdt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE public.idempotency SET expiresat`).
+		WithArgs(testid, "key", testdt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	existing, err := dbe.ReserveIdempotency(ctx, record, testdt)
+	require.Nil(t, err)
+	require.Nil(t, existing)
+	require.Nil(t, dbe.RenewIdempotency(ctx, testid, "key", testdt.Add(2*time.Minute)))
+	require.Nil(t, dbe.CompleteIdempotency(ctx, testid, "key", "id", testdt.Add(time.Hour)))
+	record.Fingerprint = "update"
+	existing, err = dbe.ReserveIdempotency(ctx, record, testdt)
+	require.Nil(t, err)
+	require.Equal(t, &entities.Idempotency{UserID: testid, Key: "key", Fingerprint: "add", Response: "id", ExpiresAt: testdt.Add(time.Hour)}, existing)
+	require.Nil(t, dbe.ReleaseIdempotency(ctx, testid, "key"))
+	require.True(t, errors.Is(dbe.CompleteIdempotency(ctx, testid, "key", "id", testdt), entities.ErrIdempotencyMissed))
+	require.True(t, errors.Is(dbe.RenewIdempotency(ctx, testid, "key", testdt), entities.ErrIdempotencyMissed))
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+}
+
+func TestDBEventRepo_Attendees(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+
+	t.Run("add attendees", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO public.attendees`).
+			WithArgs(testid, testid2, string(entities.NeedsAction)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dbe.AddAttendees(ctx, testid, []string{testid2})
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+	})
+	t.Run("add attendees to unknown event", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO public.attendees`).
+			WithArgs(testid, testid2, string(entities.NeedsAction)).
+			WillReturnError(errors.New(foreignKeyViolation))
+
+		err := dbe.AddAttendees(ctx, testid, []string{testid2})
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.True(t, errors.Is(err, entities.ErrEventNotFound))
+	})
+	t.Run("set status", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE public.attendees SET status`).
+			WithArgs(testid, testid2, string(entities.Accepted)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dbe.SetAttendeeStatus(ctx, testid, testid2, entities.Accepted)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+	})
+	t.Run("set status of not invited user", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE public.attendees SET status`).
+			WithArgs(testid, testid2, string(entities.Declined)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dbe.SetAttendeeStatus(ctx, testid, testid2, entities.Declined)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.True(t, errors.Is(err, entities.ErrNotInvited))
+	})
+	t.Run("get invitations", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", testid2+"=tentative", 1)
+		mock.ExpectQuery(`from public.attendees a where a.eventid = events.id and a.userid = \$1\)`).
+			WithArgs(testid2).
+			WillReturnRows(rows)
+
+		events, err := dbe.GetInvitations(ctx, testid2)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		expectedE := storedEvent(newFakeEvent(), testid)
+		expectedE.Attendees = []entities.Attendee{{UserID: testid2, Status: entities.Tentative}}
+		require.Equal(t, []*entities.Event{&expectedE}, events)
+	})
+}
+
+func TestDBEventRepo_Calendars(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+
+	t.Run("add calendar with owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO public.calendars`).
+			WithArgs("team").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testid))
+		mock.ExpectExec(`INSERT INTO public.calendar_acl`).
+			WithArgs(testid, testid2, string(entities.RoleOwner)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		calendar, err := entities.NewCalendar("team", testid2)
+		require.Nil(t, err)
+		id, err := dbe.AddCalendar(ctx, *calendar)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Equal(t, testid, id)
+	})
+	t.Run("get calendar", func(t *testing.T) {
+		mock.ExpectQuery(`select id, title, coalesce\(\(select string_agg`).
+			WithArgs(testid).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "acl"}).AddRow(testid, "team", testid2+"=owner,"+testid+"=viewer"))
+
+		calendar, err := dbe.GetCalendar(ctx, testid)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Equal(t, entities.RoleOwner, calendar.RoleOf(testid2))
+		require.Equal(t, entities.RoleViewer, calendar.RoleOf(testid))
+	})
+	t.Run("get unknown calendar", func(t *testing.T) {
+		mock.ExpectQuery(`select id, title`).
+			WithArgs(testid).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := dbe.GetCalendar(ctx, testid)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.True(t, errors.Is(err, entities.ErrCalendarNotFound))
+	})
+	t.Run("set role of unknown calendar", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO public.calendar_acl(.+)ON CONFLICT`).
+			WithArgs(testid, testid2, string(entities.RoleEditor)).
+			WillReturnError(errors.New(foreignKeyViolation))
+
+		err := dbe.SetRole(ctx, testid, testid2, entities.RoleEditor)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.True(t, errors.Is(err, entities.ErrCalendarNotFound))
+	})
+	t.Run("remove role", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM public.calendar_acl`).
+			WithArgs(testid, testid2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dbe.SetRole(ctx, testid, testid2, "")
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+	})
+	t.Run("get calendar events", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid2, "title", testdt, 360, "text", testid2, "360=", "", "", "UTC", testid, "", 1)
+		mock.ExpectQuery(`from public.events where calendarid = \$1`).
+			WithArgs(testid, testdt, testdt.Add(time.Hour)).
+			WillReturnRows(rows)
+
+		events, err := dbe.GetForPeriodByCalendarID(ctx, testid, testdt, testdt.Add(time.Hour))
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, testid, events[0].CalendarID)
+	})
+	t.Run("delete unknown calendar", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`select id, title, datetime, duration, text, userid, coalesce(.+), rrule, exdate, timezone, coalesce(.+) for update`).
+			WithArgs(testid).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(`DELETE FROM public.calendars`).
+			WithArgs(testid).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := dbe.DeleteCalendar(ctx, testid)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.True(t, errors.Is(err, entities.ErrCalendarNotFound))
+	})
+}
+
+func TestDBEventRepo_ListEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+	columns := []string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}
+
+	t.Run("first page", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(testid, "title", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1).
+			AddRow(testid2, "title", testdt.Add(time.Hour), 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
+		mock.ExpectQuery(`\(select id from public.events where userid = \$1 (.+)and \(\$4 = '' or title ilike \$4 or text ilike \$4\) order by datetime, id limit \$5\)
+		union all
+		\(select id from public.events where userid <> \$1(.+)a.userid = \$1 and a.status = 'accepted'\) (.+) order by datetime, id limit \$5\)
+		\) listed using \(id\) order by datetime, id limit \$5`).
+			WithArgs(testid, nil, testdt.Add(24*time.Hour), `%50\%%`, 2).
+			WillReturnRows(rows)
+
+		page, err := dbe.ListEvents(ctx, entities.ListQuery{UserID: testid, End: testdt.Add(24 * time.Hour), Search: "50%", Sort: entities.SortDateTime, Limit: 1})
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Len(t, page.Events, 1)
+		cursor, err := entities.DecodeCursor(page.Next, entities.SortDateTime)
+		require.Nil(t, err)
+		require.Equal(t, testid, cursor.ID)
+		require.True(t, testdt.Equal(cursor.DateTime))
+	})
+	t.Run("next page by title", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(testid2, "alpha", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1)
+		mock.ExpectQuery(`\(title COLLATE "C", id\) < \(\$6, \$7\) order by title COLLATE "C" desc, id desc limit \$5\)
+		\) listed using \(id\) order by title COLLATE "C" desc, id desc limit \$5`).
+			WithArgs(testid, nil, nil, "", 3, "beta", testid).
+			WillReturnRows(rows)
+
+		after := &entities.Cursor{Sort: entities.SortTitleDesc, Title: "beta", ID: testid}
+		page, err := dbe.ListEvents(ctx, entities.ListQuery{UserID: testid, Sort: entities.SortTitleDesc, Limit: 2, After: after})
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Len(t, page.Events, 1)
+		require.Empty(t, page.Next)
+	})
+}
+
+func TestDBEventRepo_SearchEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	dbe := EventRepo{db: db, logger: nil}
+	ctx := context.TODO()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version", "rank"}).
+		AddRow(testid, "retro", testdt, 360, "text", testid, "360=", "", "", "UTC", "", "", 1, 0.6)
+	mock.ExpectQuery(`when search @@ to_tsquery\('simple', t.term \|\| ':\*A'\) then \$4::float8
+			when search @@ to_tsquery\('simple', t.term \|\| ':\*B'\) then \$5::float8(.+)
+		where search @@ to_tsquery\('simple', \$2\)(.+)order by rank desc, datetime desc, id limit \$3`).
+		WithArgs(testid, "retro:* | mar:*", 10, entities.TitleWeight, entities.TextWeight, "retro,mar", 2).
+		WillReturnRows(rows)
+
+	hits, err := dbe.SearchEvents(ctx, testid, []string{"retro", "mar"}, 10)
+
+	if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+		t.Errorf("there were unfulfilled expectations: %s", mockerr)
+	}
+	require.Nil(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, testid, hits[0].Event.ID)
+	require.Equal(t, 0.6, hits[0].Rank)
+}
+
 func TestEventConversion(t *testing.T) {
 	t.Run("DB Event to Domain Event conversion", func(t *testing.T) {
 		dbE := Event{
-			ID:         uuid.FromStringOrNil(testid),
-			Title:      "title",
-			DateTime:   testdt,
-			Duration:   360,
-			Text:       "text",
-			UserID:     uuid.FromStringOrNil(testid),
-			TimeNotify: 360,
-		}
-		expectedE := addID(newFakeEvent(), testid)
-		e := toDomainEvent(dbE)
+			ID:        uuid.FromStringOrNil(testid),
+			Title:     "title",
+			DateTime:  testdt,
+			Duration:  360,
+			Text:      "text",
+			UserID:    uuid.FromStringOrNil(testid),
+			Reminders: "360=",
+			TimeZone:  "UTC",
+			Version:   1,
+		}
+		expectedE := storedEvent(newFakeEvent(), testid)
+		e, err := toDomainEvent(dbE)
+		require.Nil(t, err, "error must be nil")
 		require.Equal(t, &expectedE, e, "domain event not equal db event after conversion")
 	})
 	t.Run("event to DB Event conversion", func(t *testing.T) {
-		event := addID(newFakeEvent(), testid)
+		event := storedEvent(newFakeEvent(), testid)
 		expectedDBE := &Event{
-			ID:         uuid.FromStringOrNil(testid),
-			Title:      "title",
-			DateTime:   testdt,
-			Duration:   360,
-			Text:       "text",
-			UserID:     uuid.FromStringOrNil(testid),
-			TimeNotify: 360,
+			ID:        uuid.FromStringOrNil(testid),
+			Title:     "title",
+			DateTime:  testdt,
+			Duration:  360,
+			Text:      "text",
+			UserID:    uuid.FromStringOrNil(testid),
+			Reminders: "360=",
+			TimeZone:  "UTC",
 		}
 		dbe, err := fromDomainEvent(event)
 		require.Nil(t, err, "error must be nil")
@@ -354,16 +1192,101 @@ func TestEventConversion(t *testing.T) {
 	})
 }
 
-func newFakeEvent(timeNotify ...string) entities.Event {
+func newFakeEvent(reminders ...string) entities.Event {
 	tn := "6m"
-	if len(timeNotify) > 0 {
-		tn = timeNotify[0]
+	if len(reminders) > 0 {
+		tn = reminders[0]
 	}
 	e, _ := entities.NewEvent("title", testdt_str, "6m", "text", testid, tn)
 	return *e
 }
 
-func addID(e entities.Event, id string) entities.Event {
+// storedEvent returns event as it is read from database after Add.
+func storedEvent(e entities.Event, id string) entities.Event {
 	e.ID = id
+	e.Version = entities.InitialVersion
 	return e
 }
+
+func TestDBEventRepo_DeleteOlderThan(t *testing.T) {
+	before := testdt.AddDate(1, 0, 0)
+	t.Run("good test: delete by batches", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+		ctx := context.TODO()
+		query := entities.CleanQuery{Before: before, UserID: testid, ExceptCalendarIDs: []string{testid2, testid3}, Mode: entities.RetentionDelete, Batch: 2}
+
+		mock.ExpectExec(`DELETE FROM public.events where id in \(select id from public.events where rrule = '' and datetime < \$1 (.+) order by datetime limit \$6 for update skip locked\)`).
+			WithArgs(before, testid, "", "", testid2+","+testid3, 2).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM public.events`).
+			WithArgs(before, testid, "", "", testid2+","+testid3, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		recurring := sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}).
+			AddRow(testid, "title", testdt, 360, "text", testid, "", "FREQ=DAILY;COUNT=3", "", "UTC", "", "", 1).
+			AddRow(testid2, "title", testdt, 360, "text", testid, "", "FREQ=DAILY", "", "UTC", "", "", 1)
+		mock.ExpectQuery(`where rrule <> '' and datetime < \$1 (.+) and id > \$6::uuid order by id limit \$7`).
+			WithArgs(before, testid, "", "", testid2+","+testid3, uuid.Nil.String(), 2).
+			WillReturnRows(recurring)
+		mock.ExpectExec(`DELETE FROM public.events where id in \(select id from public.events where id::text = any`).
+			WithArgs(testid).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`where rrule <> ''`).
+			WithArgs(before, testid, "", "", testid2+","+testid3, testid2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}))
+
+		removed, err := dbe.DeleteOlderThan(ctx, query)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Equal(t, 4, removed, "endless recurring event is kept")
+	})
+	t.Run("good test: archive", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+		ctx := context.TODO()
+		query := entities.CleanQuery{Before: before, Mode: entities.RetentionArchive}
+
+		mock.ExpectExec(`WITH removed AS \(DELETE FROM public.events (.+) RETURNING (.+)\)(.+)INSERT INTO public.events_archive`).
+			WithArgs(before, "", "", "", "", defaultCleanBatch).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`where rrule <> ''`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "dateTime", "duration", "text", "userId", "reminders", "rrule", "exdate", "timezone", "calendarid", "attendees", "version"}))
+
+		removed, err := dbe.DeleteOlderThan(ctx, query)
+
+		if mockerr := mock.ExpectationsWereMet(); mockerr != nil {
+			t.Errorf("there were unfulfilled expectations: %s", mockerr)
+		}
+		require.Nil(t, err)
+		require.Equal(t, 1, removed)
+	})
+	t.Run("bad test: delete error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		dbe := EventRepo{db: db, logger: nil}
+		mock.ExpectExec(`DELETE FROM public.events`).
+			WillReturnError(sql.ErrConnDone)
+
+		removed, err := dbe.DeleteOlderThan(context.TODO(), entities.CleanQuery{Before: before})
+
+		require.NotNil(t, err)
+		require.Equal(t, 0, removed)
+	})
+}
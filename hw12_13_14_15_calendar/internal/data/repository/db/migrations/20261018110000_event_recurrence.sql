@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE public.events
+    ADD COLUMN rrule character varying(255) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    ADD COLUMN exdate text COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+CREATE INDEX "Ix_EventsRecurring"
+    ON public.events USING btree
+    (userid, datetime)
+    TABLESPACE pg_default
+    WHERE rrule <> '';
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsRecurring";
+ALTER TABLE public.events
+    DROP COLUMN rrule,
+    DROP COLUMN exdate;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE public.notifications
+(
+    eventid uuid NOT NULL,
+    occurrence timestamp without time zone NOT NULL,
+    claimedat timestamp without time zone NOT NULL,
+    notifiedat timestamp without time zone,
+    CONSTRAINT "PK_Notifications" PRIMARY KEY (eventid, occurrence),
+    CONSTRAINT "FK_NotificationsEvents" FOREIGN KEY (eventid)
+        REFERENCES public.events (id) ON DELETE CASCADE
+)
+TABLESPACE pg_default;
+
+CREATE INDEX "Ix_EventsNotifyTime"
+    ON public.events USING btree
+    ((datetime - make_interval(secs => timenotify)))
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsNotifyTime";
+DROP TABLE public.notifications;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- indexes on expressions over timestamp aren't immutable for timestamptz, so they are recreated in UTC
+DROP INDEX IF EXISTS public."Ix_EventsDate";
+DROP INDEX IF EXISTS public."Ix_EventsNotifyTime";
+
+ALTER TABLE public.events
+    ALTER COLUMN datetime TYPE timestamp with time zone USING datetime AT TIME ZONE 'UTC',
+    ADD COLUMN timezone character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE public.notifications
+    ALTER COLUMN occurrence TYPE timestamp with time zone USING occurrence AT TIME ZONE 'UTC',
+    ALTER COLUMN claimedat TYPE timestamp with time zone USING claimedat AT TIME ZONE 'UTC',
+    ALTER COLUMN notifiedat TYPE timestamp with time zone USING notifiedat AT TIME ZONE 'UTC';
+
+CREATE UNIQUE INDEX "Ix_EventsDate"
+    ON public.events USING btree
+    (userid, date_trunc('second'::text, (datetime AT TIME ZONE 'UTC')))
+    TABLESPACE pg_default;
+
+CREATE INDEX "Ix_EventsNotifyTime"
+    ON public.events USING btree
+    (((datetime AT TIME ZONE 'UTC') - make_interval(secs => timenotify)))
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsDate";
+DROP INDEX IF EXISTS public."Ix_EventsNotifyTime";
+
+ALTER TABLE public.notifications
+    ALTER COLUMN occurrence TYPE timestamp without time zone USING occurrence AT TIME ZONE 'UTC',
+    ALTER COLUMN claimedat TYPE timestamp without time zone USING claimedat AT TIME ZONE 'UTC',
+    ALTER COLUMN notifiedat TYPE timestamp without time zone USING notifiedat AT TIME ZONE 'UTC';
+
+ALTER TABLE public.events
+    ALTER COLUMN datetime TYPE timestamp without time zone USING datetime AT TIME ZONE 'UTC',
+    DROP COLUMN timezone;
+
+CREATE UNIQUE INDEX "Ix_EventsDate"
+    ON public.events USING btree
+    (userid,date_part('year'::text, datetime) ASC NULLS LAST, date_part('month'::text, datetime) ASC NULLS LAST, date_part('day'::text, datetime) ASC NULLS LAST, date_part('hour'::text, datetime) ASC NULLS LAST, date_part('minute'::text, datetime) ASC NULLS LAST, date_part('second'::text, datetime) ASC NULLS LAST)
+    TABLESPACE pg_default;
+
+CREATE INDEX "Ix_EventsNotifyTime"
+    ON public.events USING btree
+    ((datetime - make_interval(secs => timenotify)))
+    TABLESPACE pg_default;
@@ -0,0 +1,56 @@
+-- +goose Up
+-- busy date is any overlap of [datetime, datetime+duration) of user events, event without duration is a point in time.
+-- Constraint compares the first occurrences of recurring events, later occurrences are compared by calendar before save.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- timestamptz + interval depends on TimeZone setting, so duration is added to UTC timestamp and the function is immutable.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION public.event_period(datetime timestamp with time zone, duration integer)
+    RETURNS tstzrange
+    LANGUAGE sql
+    IMMUTABLE
+AS $$
+    SELECT tstzrange(datetime, ((datetime AT TIME ZONE 'UTC') + make_interval(secs => coalesce(duration, 0))) AT TIME ZONE 'UTC',
+        CASE WHEN coalesce(duration, 0) = 0 THEN '[]' ELSE '[)' END)
+$$;
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS public."Ix_EventsDate";
+
+CREATE INDEX "Ix_EventsDate"
+    ON public.events USING btree
+    (userid, datetime)
+    TABLESPACE pg_default;
+
+-- Events saved before the constraint may overlap, pre-flight query lists them to be moved or deleted before migration:
+--   SELECT a.userid, a.id, a.datetime, b.id, b.datetime FROM public.events a JOIN public.events b
+--   ON a.userid = b.userid AND a.id < b.id AND public.event_period(a.datetime, a.duration) && public.event_period(b.datetime, b.duration);
+-- +goose StatementBegin
+DO $$
+DECLARE
+    overlapping bigint;
+BEGIN
+    SELECT count(*) INTO overlapping FROM public.events a JOIN public.events b
+    ON a.userid = b.userid AND a.id < b.id AND public.event_period(a.datetime, a.duration) && public.event_period(b.datetime, b.duration);
+    IF overlapping > 0 THEN
+        RAISE EXCEPTION '% pairs of events overlap, Ex_EventsPeriod can''t be added', overlapping
+            USING HINT = 'move or delete events listed by pre-flight query of migration 20261018140000_event_overlap.sql';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE public.events
+    ADD CONSTRAINT "Ex_EventsPeriod" EXCLUDE USING gist (userid WITH =, public.event_period(datetime, duration) WITH &&);
+-- +goose Down
+ALTER TABLE public.events
+    DROP CONSTRAINT IF EXISTS "Ex_EventsPeriod";
+
+DROP INDEX IF EXISTS public."Ix_EventsDate";
+
+CREATE UNIQUE INDEX "Ix_EventsDate"
+    ON public.events USING btree
+    (userid, date_trunc('second'::text, (datetime AT TIME ZONE 'UTC')))
+    TABLESPACE pg_default;
+
+DROP FUNCTION IF EXISTS public.event_period(timestamp with time zone, integer);
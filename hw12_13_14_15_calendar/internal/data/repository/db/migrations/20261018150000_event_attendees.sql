@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE public.attendees
+(
+    eventid uuid NOT NULL,
+    userid uuid NOT NULL,
+    status character varying(16) COLLATE pg_catalog."default" NOT NULL DEFAULT 'needs-action',
+    CONSTRAINT "PK_Attendees" PRIMARY KEY (eventid, userid),
+    CONSTRAINT "FK_Attendees_Events" FOREIGN KEY (eventid)
+        REFERENCES public.events (id) ON DELETE CASCADE,
+    CONSTRAINT "CK_Attendees_Status" CHECK (status IN ('needs-action', 'accepted', 'declined', 'tentative'))
+)
+TABLESPACE pg_default;
+
+CREATE INDEX "Ix_AttendeesUser"
+    ON public.attendees USING btree
+    (userid, status)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP TABLE public.attendees;
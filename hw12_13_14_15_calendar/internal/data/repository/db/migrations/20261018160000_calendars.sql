@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE public.calendars
+(
+    id uuid NOT NULL,
+    title character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT "PK_Calendars" PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+CREATE TABLE public.calendar_acl
+(
+    calendarid uuid NOT NULL,
+    userid uuid NOT NULL,
+    role character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT "PK_CalendarACL" PRIMARY KEY (calendarid, userid),
+    CONSTRAINT "FK_CalendarACL_Calendars" FOREIGN KEY (calendarid)
+        REFERENCES public.calendars (id) ON DELETE CASCADE,
+    CONSTRAINT "CK_CalendarACL_Role" CHECK (role IN ('owner', 'editor', 'viewer', 'freebusy'))
+)
+TABLESPACE pg_default;
+
+CREATE INDEX "Ix_CalendarACLUser"
+    ON public.calendar_acl USING btree
+    (userid)
+    TABLESPACE pg_default;
+
+-- events without calendar belong to personal calendar of their user
+ALTER TABLE public.events
+    ADD COLUMN calendarid uuid,
+    ADD CONSTRAINT "FK_Events_Calendars" FOREIGN KEY (calendarid)
+        REFERENCES public.calendars (id) ON DELETE CASCADE;
+
+CREATE INDEX "Ix_EventsCalendar"
+    ON public.events USING btree
+    (calendarid, datetime)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsCalendar";
+
+ALTER TABLE public.events
+    DROP COLUMN calendarid;
+
+DROP TABLE public.calendar_acl;
+
+DROP TABLE public.calendars;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- keyset pagination of ListEvents reads these indexes in order of (key, id) from the cursor
+CREATE INDEX "Ix_EventsUserDateTime"
+    ON public.events USING btree
+    (userid, datetime, id)
+    TABLESPACE pg_default;
+
+CREATE INDEX "Ix_EventsUserTitle"
+    ON public.events USING btree
+    (userid, (title COLLATE "C"), id)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsUserTitle";
+
+DROP INDEX IF EXISTS public."Ix_EventsUserDateTime";
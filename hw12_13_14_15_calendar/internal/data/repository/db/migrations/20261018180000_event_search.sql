@@ -0,0 +1,16 @@
+-- +goose Up
+-- simple configuration doesn't stem words, so search by prefix works the same for any language
+ALTER TABLE public.events
+    ADD COLUMN search tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', text), 'B')
+    ) STORED;
+
+CREATE INDEX "Ix_EventsSearch"
+    ON public.events USING gin
+    (search)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsSearch";
+
+ALTER TABLE public.events
+    DROP COLUMN search;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- outbox keeps lifecycle messages of events until relay publishes them, so it has no foreign key to deleted events.
+-- id keeps order of messages, messageid is unique across databases and restarts, consumers skip duplicates by it
+CREATE TABLE public.outbox
+(
+    id bigserial NOT NULL,
+    messageid uuid NOT NULL,
+    kind text NOT NULL,
+    eventid uuid NOT NULL,
+    title text NOT NULL,
+    datetime timestamp with time zone NOT NULL,
+    recipients text NOT NULL,
+    createdat timestamp without time zone NOT NULL DEFAULT (now() at time zone 'UTC'),
+    CONSTRAINT "PK_Outbox" PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+-- +goose Down
+DROP TABLE public.outbox;
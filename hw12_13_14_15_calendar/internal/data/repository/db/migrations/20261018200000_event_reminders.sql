@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE public.reminders
+(
+    eventid uuid NOT NULL,
+    remindbefore integer NOT NULL,
+    channel text NOT NULL DEFAULT '',
+    CONSTRAINT "PK_Reminders" PRIMARY KEY (eventid, remindbefore, channel),
+    CONSTRAINT "FK_RemindersEvents" FOREIGN KEY (eventid)
+        REFERENCES public.events (id) ON DELETE CASCADE
+)
+TABLESPACE pg_default;
+
+INSERT INTO public.reminders(eventid, remindbefore)
+    SELECT id, timenotify FROM public.events WHERE timenotify IS NOT NULL;
+
+-- existing notifications belong to the single reminder of event
+ALTER TABLE public.notifications
+    ADD COLUMN remindbefore integer NOT NULL DEFAULT 0,
+    ADD COLUMN channel text NOT NULL DEFAULT '';
+UPDATE public.notifications n SET remindbefore = e.timenotify
+    FROM public.events e WHERE e.id = n.eventid AND e.timenotify IS NOT NULL;
+ALTER TABLE public.notifications
+    DROP CONSTRAINT "PK_Notifications",
+    ADD CONSTRAINT "PK_Notifications" PRIMARY KEY (eventid, occurrence, remindbefore, channel);
+
+DROP INDEX IF EXISTS public."Ix_EventsNotifyTime";
+ALTER TABLE public.events
+    DROP COLUMN timenotify;
+-- +goose Down
+-- only the earliest reminder of event is kept
+ALTER TABLE public.events
+    ADD COLUMN timenotify integer;
+UPDATE public.events e SET timenotify = (SELECT max(r.remindbefore) FROM public.reminders r WHERE r.eventid = e.id);
+
+CREATE INDEX "Ix_EventsNotifyTime"
+    ON public.events USING btree
+    (((datetime AT TIME ZONE 'UTC') - make_interval(secs => timenotify)))
+    TABLESPACE pg_default;
+
+DELETE FROM public.notifications n
+    WHERE n.channel <> '' OR n.remindbefore IS DISTINCT FROM (SELECT e.timenotify FROM public.events e WHERE e.id = n.eventid);
+ALTER TABLE public.notifications
+    DROP CONSTRAINT "PK_Notifications",
+    ADD CONSTRAINT "PK_Notifications" PRIMARY KEY (eventid, occurrence),
+    DROP COLUMN remindbefore,
+    DROP COLUMN channel;
+
+DROP TABLE public.reminders;
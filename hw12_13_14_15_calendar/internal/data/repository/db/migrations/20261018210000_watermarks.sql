@@ -0,0 +1,11 @@
+-- +goose Up
+-- watermarks keep times which named scans of scheduler have processed everything before
+CREATE TABLE public.watermarks
+(
+    name text NOT NULL,
+    watermark timestamp with time zone NOT NULL,
+    CONSTRAINT "PK_Watermarks" PRIMARY KEY (name)
+)
+TABLESPACE pg_default;
+-- +goose Down
+DROP TABLE public.watermarks;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- events_archive keeps old events removed by cleaner in archive mode, reminders and attendees are kept as lists
+CREATE TABLE public.events_archive
+(
+    id uuid NOT NULL,
+    title character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    datetime timestamp with time zone NOT NULL,
+    duration integer,
+    text text COLLATE pg_catalog."default" NOT NULL,
+    userid uuid NOT NULL,
+    reminders text NOT NULL DEFAULT '',
+    rrule character varying(255) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    exdate text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    timezone character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT 'UTC',
+    calendarid uuid,
+    attendees text NOT NULL DEFAULT '',
+    archivedat timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT "PK_EventsArchive" PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+-- cleaner removes the oldest events first
+CREATE INDEX "Ix_EventsDateTime"
+    ON public.events USING btree
+    (datetime)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_EventsDateTime";
+
+DROP TABLE public.events_archive;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- idempotency keeps responses of requests with idempotency key until they expire
+CREATE TABLE public.idempotency
+(
+    userid uuid NOT NULL,
+    key text COLLATE pg_catalog."default" NOT NULL,
+    fingerprint text COLLATE pg_catalog."default" NOT NULL,
+    response text COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    expiresat timestamp with time zone NOT NULL,
+    CONSTRAINT "PK_Idempotency" PRIMARY KEY (userid, key)
+)
+TABLESPACE pg_default;
+
+CREATE INDEX "Ix_IdempotencyExpiresAt"
+    ON public.idempotency USING btree
+    (expiresat)
+    TABLESPACE pg_default;
+-- +goose Down
+DROP TABLE public.idempotency;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- version is incremented by every update of event, stale updates and deletes are rejected by it
+ALTER TABLE public.events
+    ADD COLUMN version bigint NOT NULL DEFAULT 1;
+-- +goose Down
+ALTER TABLE public.events
+    DROP COLUMN version;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- scheduler scans again from the oldest claimed but not notified reminder, so reminder which push failed isn't lost
+CREATE INDEX "Ix_NotificationsPending"
+    ON public.notifications USING btree
+    (((occurrence AT TIME ZONE 'UTC') - make_interval(secs => remindbefore)))
+    TABLESPACE pg_default
+    WHERE notifiedat IS NULL;
+-- +goose Down
+DROP INDEX IF EXISTS public."Ix_NotificationsPending";
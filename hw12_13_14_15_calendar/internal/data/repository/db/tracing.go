@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// queryer is implemented by sql.DB and sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func tracedQuery(ctx context.Context, q queryer, statement string, args ...interface{}) (rows *sql.Rows, err error) {
+	ctx, span := startQuerySpan(ctx, statement)
+	defer func() { tracing.End(span, err) }()
+	return q.QueryContext(ctx, statement, args...)
+}
+
+func tracedExec(ctx context.Context, q queryer, statement string, args ...interface{}) (result sql.Result, err error) {
+	ctx, span := startQuerySpan(ctx, statement)
+	defer func() { tracing.End(span, err) }()
+	return q.ExecContext(ctx, statement, args...)
+}
+
+// tracedQueryRow ends span before scanning, error of query is returned by Scan.
+func tracedQueryRow(ctx context.Context, q queryer, statement string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, statement)
+	defer span.End()
+	return q.QueryRowContext(ctx, statement, args...)
+}
+
+// startQuerySpan starts client span named by sql operation of statement.
+func startQuerySpan(ctx context.Context, statement string) (context.Context, trace.Span) {
+	operation := "QUERY"
+	if fields := strings.Fields(statement); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	return tracing.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBStatementKey.String(statement),
+		))
+}
@@ -2,6 +2,8 @@ package inmemory
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,17 +17,54 @@ import (
 var _ entities.EventRepo = (*EventRepo)(nil)
 
 type dates map[time.Time]*entities.Event
+
+// notification is notify state of reminder of event occurrence.
+type notification struct {
+	claimedAt  time.Time
+	notifiedAt time.Time
+}
+
+// reminded is reminder of event occurrence.
+type reminded struct {
+	occurrence time.Time
+	reminder   entities.Reminder
+}
+type occurrences map[reminded]*notification
 type MapRepo struct {
-	rwmux  *sync.RWMutex
-	users  map[string]dates
-	events map[string]*entities.Event
+	rwmux         *sync.RWMutex
+	users         map[string]dates
+	events        map[string]*entities.Event
+	notifications map[string]occurrences
+	calendars     map[string]*entities.Calendar
+	// words is inverted index of event title and text, it maps word to IDs of events.
+	words map[string]map[string]bool
+	// outbox keeps lifecycle messages until relay publishes them, relayMux serializes relays instead of rwmux.
+	outbox   []entities.OutboxMessage
+	relayMux *sync.Mutex
+	// watermarks are times which named scans have processed everything before.
+	watermarks map[string]time.Time
+	// archive keeps events removed by DeleteOlderThan in archive mode.
+	archive map[string]*entities.Event
+	// idempotency keeps records of idempotency keys by user and key.
+	idempotency map[idempotencyKey]*entities.Idempotency
+}
+
+type idempotencyKey struct {
+	userID, key string
 }
 
 func NewMapRepo() *MapRepo {
 	return &MapRepo{
-		rwmux:  &sync.RWMutex{},
-		users:  make(map[string]dates),
-		events: make(map[string]*entities.Event),
+		rwmux:         &sync.RWMutex{},
+		users:         make(map[string]dates),
+		events:        make(map[string]*entities.Event),
+		notifications: make(map[string]occurrences),
+		calendars:     make(map[string]*entities.Calendar),
+		words:         make(map[string]map[string]bool),
+		watermarks:    make(map[string]time.Time),
+		archive:       make(map[string]*entities.Event),
+		idempotency:   make(map[idempotencyKey]*entities.Idempotency),
+		relayMux:      &sync.Mutex{},
 	}
 }
 func (m *MapRepo) Clear() {
@@ -33,6 +72,56 @@ func (m *MapRepo) Clear() {
 	defer m.rwmux.Unlock()
 	m.users = make(map[string]dates)
 	m.events = make(map[string]*entities.Event)
+	m.notifications = make(map[string]occurrences)
+	m.calendars = make(map[string]*entities.Calendar)
+	m.words = make(map[string]map[string]bool)
+	m.outbox = nil
+	m.watermarks = make(map[string]time.Time)
+	m.archive = make(map[string]*entities.Event)
+	m.idempotency = make(map[idempotencyKey]*entities.Idempotency)
+}
+
+// index adds words of event to inverted index, caller must hold write lock.
+func (m *MapRepo) index(event *entities.Event) {
+	for _, word := range entities.Tokenize(event.Title + " " + event.Text) {
+		if _, ok := m.words[word]; !ok {
+			m.words[word] = make(map[string]bool)
+		}
+		m.words[word][event.ID] = true
+	}
+}
+
+// unindex removes words of event from inverted index, caller must hold write lock.
+func (m *MapRepo) unindex(event *entities.Event) {
+	for _, word := range entities.Tokenize(event.Title + " " + event.Text) {
+		delete(m.words[word], event.ID)
+		if len(m.words[word]) == 0 {
+			delete(m.words, word)
+		}
+	}
+}
+
+// prefixed returns IDs of events having word prefixed by term, caller must hold read lock.
+func (m *MapRepo) prefixed(term string) map[string]bool {
+	ids := make(map[string]bool)
+	for word, events := range m.words {
+		if strings.HasPrefix(word, term) {
+			for id := range events {
+				ids[id] = true
+			}
+		}
+	}
+	return ids
+}
+
+// writeOutbox adds lifecycle message of event change to outbox, caller must hold write lock.
+func (m *MapRepo) writeOutbox(kind entities.NotifyKind, old *entities.Event, event entities.Event) {
+	msg, ok := entities.NewOutboxMessage(kind, old, event, time.Now())
+	if !ok {
+		return
+	}
+	msg.MessageID = uuid.NewV4().String()
+	m.outbox = append(m.outbox, msg)
 }
 
 type EventRepo struct {
@@ -50,17 +139,21 @@ func NewInMemoryEventRepo(m *MapRepo, logger usecases.Logger) (*EventRepo, error
 func (i EventRepo) Add(ctx context.Context, event entities.Event) (string, error) {
 	i.m.rwmux.Lock()
 	defer i.m.rwmux.Unlock()
-	if dates, ok := i.m.users[event.UserID]; !ok {
+	if _, ok := i.m.users[event.UserID]; !ok {
 		//user not exist
 		i.m.users[event.UserID] = make(map[time.Time]*entities.Event)
-	} else if _, ok := dates[event.DateTime]; ok {
+	} else if conflicts := i.conflicts(event, ""); len(conflicts) > 0 {
 		//date is busy
-		return "", entities.ErrDateBusy
+		return "", entities.NewDateBusyError(conflicts...)
 	}
 	id := uuid.NewV4().String()
 	event.ID = id
+	event.Version = entities.InitialVersion
+	event.Recurrence = event.Recurrence.Copy()
 	i.m.users[event.UserID][event.DateTime] = &event
 	i.m.events[event.ID] = &event
+	i.m.index(&event)
+	i.m.writeOutbox(entities.NotifyCreated, nil, event)
 	return id, nil
 }
 
@@ -95,34 +188,32 @@ func (i EventRepo) GetByDate(ctx context.Context, userID string, date time.Time)
 		return nil, errors.Wrapf(entities.ErrUnknownUser, "can't get event by date: %v", date)
 	}
 	for datetime, event := range dates {
-		if dateCompare(datetime, date) {
+		//recurring event may have occurrence at this date if it started before
+		if dateCompare(datetime, date) || (event.IsRecurring() && datetime.Before(nextDate(date))) {
 			events = append(events, eventCreateSafely(event))
 		}
 	}
 	if len(events) == 0 {
 		return nil, entities.ErrEventNotFound
 	}
+	sortEvents(events)
 	return events, nil
 }
 
-func (i EventRepo) GetByNotifyDate(ctx context.Context, date time.Time) ([]*entities.Event, error) {
+func (i EventRepo) GetDueReminders(ctx context.Context, from, to time.Time) ([]*entities.DueReminder, error) {
 	i.m.rwmux.RLock()
 	defer i.m.rwmux.RUnlock()
-	events := []*entities.Event{}
-
-	for _, dates := range i.m.users {
-		for datetime, event := range dates {
-			if dateCompare(datetime.Add(-event.TimeNotify), date) {
-				events = append(events, eventCreateSafely(event))
-			}
-		}
+	due := []*entities.DueReminder{}
+	for _, event := range i.m.events {
+		due = append(due, event.DueReminders(from, to)...)
 	}
-
-	if len(events) == 0 {
+	if len(due) == 0 {
 		return nil, entities.ErrEventNotFound
 	}
-	return events, nil
+	entities.SortDueReminders(due)
+	return due, nil
 }
+
 func (i EventRepo) GetForPeriod(ctx context.Context, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
 	i.m.rwmux.RLock()
 	defer i.m.rwmux.RUnlock()
@@ -137,6 +228,7 @@ func (i EventRepo) GetForPeriod(ctx context.Context, dateStart time.Time, dateEn
 	if len(events) == 0 {
 		return nil, entities.ErrEventNotFound
 	}
+	sortEvents(events)
 	return events, nil
 }
 
@@ -144,18 +236,48 @@ func (i EventRepo) GetForPeriodByUserID(ctx context.Context, userID string, date
 	i.m.rwmux.RLock()
 	defer i.m.rwmux.RUnlock()
 	events := []*entities.Event{}
-	dates, ok := i.m.users[userID]
-	if !ok {
-		return nil, errors.Wrapf(entities.ErrUnknownUser, "can't get event by period: %v-%v", dateStart, dateEnd)
+	inPeriod := func(datetime time.Time, event *entities.Event) bool {
+		return datetime == dateStart || datetime == dateEnd || (datetime.After(dateStart) && datetime.Before(dateEnd)) ||
+			(event.IsRecurring() && datetime.Before(dateEnd))
 	}
+	dates, ok := i.m.users[userID]
 	for datetime, event := range dates {
-		if datetime == dateStart || datetime == dateEnd || (datetime.After(dateStart) && datetime.Before(dateEnd)) {
+		if inPeriod(datetime, event) {
 			events = append(events, eventCreateSafely(event))
 		}
 	}
+	//events of other users which the user accepted
+	for _, event := range i.m.events {
+		if event.UserID != userID && event.Participates(userID) && inPeriod(event.DateTime, event) {
+			ok = true
+			events = append(events, eventCreateSafely(event))
+		}
+	}
+	if !ok {
+		return nil, errors.Wrapf(entities.ErrUnknownUser, "can't get event by period: %v-%v", dateStart, dateEnd)
+	}
 	if len(events) == 0 {
 		return nil, entities.ErrEventNotFound
 	}
+	sortEvents(events)
+	return events, nil
+}
+
+// GetOverlapping doesn't return error for unknown users, they have no events.
+func (i EventRepo) GetOverlapping(ctx context.Context, userIDs []string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	events := []*entities.Event{}
+	period := entities.Event{DateTime: dateStart, Duration: dateEnd.Sub(dateStart)}
+	for _, event := range i.m.events {
+		if !participatesAny(event, userIDs) {
+			continue
+		}
+		if event.Overlaps(period) || (event.IsRecurring() && event.DateTime.Before(dateEnd)) {
+			events = append(events, eventCreateSafely(event))
+		}
+	}
+	sortEvents(events)
 	return events, nil
 }
 
@@ -170,42 +292,449 @@ func (i EventRepo) UpdateByID(ctx context.Context, userID, eventID string, event
 	if !ok {
 		return entities.ErrEventNotFound
 	}
+	if err := e.CheckVersion(event.Version); err != nil {
+		return errors.Wrapf(err, "can't update event by id %v and userid %v", eventID, userID)
+	}
+	if conflicts := i.conflicts(event, eventID); len(conflicts) > 0 {
+		return entities.NewDateBusyError(conflicts...)
+	}
+	old := e.Copy()
+	//event is indexed by its date, so it is moved to the new one
+	delete(i.m.users[e.UserID], e.DateTime)
+	i.m.unindex(e)
+	if _, ok := i.m.users[event.UserID]; !ok {
+		i.m.users[event.UserID] = make(map[time.Time]*entities.Event)
+	}
+	i.m.users[event.UserID][event.DateTime] = e
 	e.Title = event.Title
 	e.DateTime = event.DateTime
 	e.UserID = event.UserID
-	e.TimeNotify = event.TimeNotify
+	e.Reminders = append([]entities.Reminder{}, event.Reminders...)
 	e.Duration = event.Duration
 	e.Text = event.Text
+	e.Recurrence = event.Recurrence.Copy()
+	e.TimeZone = event.TimeZone
+	e.Version++
+	i.m.index(e)
+	i.m.writeOutbox(entities.NotifyRescheduled, old, *e)
 	return nil
 }
 
-func (i EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string) error {
-	e, err := i.GetByID(ctx, userID, eventID)
-	if err != nil {
+func (i EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string, version int64) error {
+	if _, err := i.GetByID(ctx, userID, eventID); err != nil {
 		return errors.Wrapf(err, "can't delete event id:%v", eventID)
 	}
 	i.m.rwmux.Lock()
 	defer i.m.rwmux.Unlock()
+	//event may be updated or deleted after it is read
+	stored, ok := i.m.events[eventID]
+	if !ok {
+		return entities.ErrEventNotFound
+	}
+	if err := stored.CheckVersion(version); err != nil {
+		return errors.Wrapf(err, "can't delete event id:%v", eventID)
+	}
 
 	delete(i.m.events, eventID)
-	delete(i.m.users[userID], e.DateTime)
+	delete(i.m.users[stored.UserID], stored.DateTime)
+	delete(i.m.notifications, eventID)
+	i.m.unindex(stored)
+	i.m.writeOutbox(entities.NotifyCancelled, nil, *stored)
 	return nil
 }
 
 func (i EventRepo) DeleteByID(ctx context.Context, eventID string) error {
-	e, err := i.getByEventID(eventID)
-	if err != nil {
-		return errors.Wrapf(err, "can't delete event id:%v", eventID)
-	}
 	i.m.rwmux.Lock()
 	defer i.m.rwmux.Unlock()
+	e, ok := i.m.events[eventID]
+	if !ok {
+		return errors.Wrapf(entities.ErrEventNotFound, "can't delete event id:%v", eventID)
+	}
 
 	delete(i.m.events, eventID)
 	delete(i.m.users[e.UserID], e.DateTime)
+	delete(i.m.notifications, eventID)
+	i.m.unindex(e)
+	i.m.writeOutbox(entities.NotifyCancelled, nil, *e)
+	return nil
+}
+
+func (i EventRepo) DeleteOlderThan(ctx context.Context, query entities.CleanQuery) (int, error) {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	removed := 0
+	for id, e := range i.m.events {
+		if !query.Match(*e) {
+			continue
+		}
+		delete(i.m.events, id)
+		delete(i.m.users[e.UserID], e.DateTime)
+		delete(i.m.notifications, id)
+		i.m.unindex(e)
+		if query.Mode == entities.RetentionArchive {
+			i.m.archive[id] = e
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// FetchDueUnnotified claims reminders under write lock, it is the same as row locking of db repository.
+func (i EventRepo) FetchDueUnnotified(ctx context.Context, from, now time.Time) ([]*entities.DueReminder, error) {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	due := []*entities.DueReminder{}
+	for _, event := range i.m.events {
+		for _, d := range event.DueReminders(from, now) {
+			if i.claim(d, now) {
+				due = append(due, d)
+			}
+		}
+	}
+	entities.SortDueReminders(due)
+	return due, nil
+}
+
+func (i EventRepo) claim(d *entities.DueReminder, now time.Time) bool {
+	if _, ok := i.m.notifications[d.Event.ID]; !ok {
+		i.m.notifications[d.Event.ID] = make(occurrences)
+	}
+	key := reminded{occurrence: d.Event.DateTime, reminder: d.Reminder}
+	n, ok := i.m.notifications[d.Event.ID][key]
+	if !ok {
+		i.m.notifications[d.Event.ID][key] = &notification{claimedAt: now}
+		d.ClaimedAt = now
+		return true
+	}
+	if !n.notifiedAt.IsZero() || !n.claimedAt.Before(now.Add(-entities.NotifyClaimTTL)) {
+		return false
+	}
+	n.claimedAt = now
+	d.ClaimedAt = now
+	return true
+}
+
+func (i EventRepo) RenewClaim(ctx context.Context, d *entities.DueReminder, now time.Time) (bool, error) {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	n, ok := i.m.notifications[d.Event.ID][reminded{occurrence: d.Event.DateTime, reminder: d.Reminder}]
+	if !ok || !n.notifiedAt.IsZero() || !n.claimedAt.Equal(d.ClaimedAt) {
+		return false, nil
+	}
+	n.claimedAt = now
+	d.ClaimedAt = now
+	return true, nil
+}
+
+func (i EventRepo) MarkNotified(ctx context.Context, eventID string, occurrence time.Time, reminder entities.Reminder) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	if _, ok := i.m.events[eventID]; !ok {
+		return errors.Wrapf(entities.ErrEventNotFound, "can't mark event %v notified", eventID)
+	}
+	if _, ok := i.m.notifications[eventID]; !ok {
+		i.m.notifications[eventID] = make(occurrences)
+	}
+	now := time.Now()
+	i.m.notifications[eventID][reminded{occurrence: occurrence, reminder: reminder}] = &notification{claimedAt: now, notifiedAt: now}
+	return nil
+}
+
+func (i EventRepo) GetOldestUnnotified(ctx context.Context, from time.Time) (time.Time, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	var oldest time.Time
+	for _, occurrences := range i.m.notifications {
+		for key, n := range occurrences {
+			notifyTime := key.occurrence.Add(-key.reminder.Offset)
+			if !n.notifiedAt.IsZero() || notifyTime.Before(from) {
+				continue
+			}
+			if oldest.IsZero() || notifyTime.Before(oldest) {
+				oldest = notifyTime
+			}
+		}
+	}
+	return oldest, nil
+}
+
+func (i EventRepo) GetWatermark(ctx context.Context, name string) (time.Time, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	return i.m.watermarks[name], nil
+}
+
+func (i EventRepo) SetWatermark(ctx context.Context, name string, watermark time.Time) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	i.m.watermarks[name] = watermark
+	return nil
+}
+
+func (i EventRepo) ReserveIdempotency(ctx context.Context, record entities.Idempotency, now time.Time) (*entities.Idempotency, error) {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	for k, r := range i.m.idempotency {
+		if !r.ExpiresAt.After(now) {
+			delete(i.m.idempotency, k)
+		}
+	}
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	if existing, ok := i.m.idempotency[k]; ok {
+		r := *existing
+		return &r, nil
+	}
+	i.m.idempotency[k] = &record
+	return nil, nil
+}
+
+func (i EventRepo) CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	r, ok := i.m.idempotency[idempotencyKey{userID: userID, key: key}]
+	if !ok {
+		return errors.Wrapf(entities.ErrIdempotencyMissed, "can't complete idempotency key %v", key)
+	}
+	r.Response = response
+	r.ExpiresAt = expiresAt
 	return nil
 }
 
+func (i EventRepo) RenewIdempotency(ctx context.Context, userID, key string, expiresAt time.Time) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	r, ok := i.m.idempotency[idempotencyKey{userID: userID, key: key}]
+	if !ok || !r.InProgress() {
+		return errors.Wrapf(entities.ErrIdempotencyMissed, "can't renew idempotency key %v", key)
+	}
+	r.ExpiresAt = expiresAt
+	return nil
+}
+
+func (i EventRepo) ReleaseIdempotency(ctx context.Context, userID, key string) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	delete(i.m.idempotency, idempotencyKey{userID: userID, key: key})
+	return nil
+}
+
+// conflicts returns sorted IDs of user events which overlap the event except event with exceptID.
+func (i EventRepo) conflicts(event entities.Event, exceptID string) []string {
+	var ids []string
+	for _, e := range i.m.users[event.UserID] {
+		if e.ID != exceptID && e.Overlaps(event) {
+			ids = append(ids, e.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (i EventRepo) AddAttendees(ctx context.Context, eventID string, userIDs []string) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	e, ok := i.m.events[eventID]
+	if !ok {
+		return errors.Wrapf(entities.ErrEventNotFound, "can't invite users to event %v", eventID)
+	}
+	for _, userID := range userIDs {
+		if _, ok := e.Attendee(userID); !ok {
+			e.Attendees = append(e.Attendees, entities.Attendee{UserID: userID, Status: entities.NeedsAction})
+		}
+	}
+	return nil
+}
+
+func (i EventRepo) SetAttendeeStatus(ctx context.Context, eventID, userID string, status entities.AttendeeStatus) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	e, ok := i.m.events[eventID]
+	if !ok {
+		return errors.Wrapf(entities.ErrEventNotFound, "can't set status of user %v to event %v", userID, eventID)
+	}
+	for n := range e.Attendees {
+		if e.Attendees[n].UserID == userID {
+			e.Attendees[n].Status = status
+			return nil
+		}
+	}
+	return entities.ErrNotInvited
+}
+
+func (i EventRepo) GetInvitations(ctx context.Context, userID string) ([]*entities.Event, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	events := []*entities.Event{}
+	for _, event := range i.m.events {
+		if _, ok := event.Attendee(userID); ok {
+			events = append(events, eventCreateSafely(event))
+		}
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+func (i EventRepo) GetByEventID(ctx context.Context, eventID string) (*entities.Event, error) {
+	return i.getByEventID(eventID)
+}
+
+func (i EventRepo) GetForPeriodByCalendarID(ctx context.Context, calendarID string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	events := []*entities.Event{}
+	for _, event := range i.m.events {
+		if event.CalendarID != calendarID {
+			continue
+		}
+		if event.DateTime == dateStart || event.DateTime == dateEnd || (event.DateTime.After(dateStart) && event.DateTime.Before(dateEnd)) ||
+			(event.IsRecurring() && event.DateTime.Before(dateEnd)) {
+			events = append(events, eventCreateSafely(event))
+		}
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+func (i EventRepo) AddCalendar(ctx context.Context, calendar entities.Calendar) (string, error) {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	calendar.ID = uuid.NewV4().String()
+	i.m.calendars[calendar.ID] = calendarCreateSafely(&calendar)
+	return calendar.ID, nil
+}
+
+func (i EventRepo) GetCalendar(ctx context.Context, calendarID string) (*entities.Calendar, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	if calendar, ok := i.m.calendars[calendarID]; ok {
+		return calendarCreateSafely(calendar), nil
+	}
+	return nil, entities.ErrCalendarNotFound
+}
+
+func (i EventRepo) GetCalendarsByUserID(ctx context.Context, userID string) ([]*entities.Calendar, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	calendars := []*entities.Calendar{}
+	for _, calendar := range i.m.calendars {
+		if calendar.RoleOf(userID) != "" {
+			calendars = append(calendars, calendarCreateSafely(calendar))
+		}
+	}
+	sort.Slice(calendars, func(i, j int) bool {
+		return calendars[i].Title < calendars[j].Title
+	})
+	return calendars, nil
+}
+
+func (i EventRepo) SetRole(ctx context.Context, calendarID, userID string, role entities.Role) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	calendar, ok := i.m.calendars[calendarID]
+	if !ok {
+		return entities.ErrCalendarNotFound
+	}
+	acl := make([]entities.ACLEntry, 0, len(calendar.ACL)+1)
+	for _, entry := range calendar.ACL {
+		if entry.UserID != userID {
+			acl = append(acl, entry)
+		}
+	}
+	if role != "" {
+		acl = append(acl, entities.ACLEntry{UserID: userID, Role: role})
+	}
+	calendar.ACL = acl
+	return nil
+}
+
+func (i EventRepo) DeleteCalendar(ctx context.Context, calendarID string) error {
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	if _, ok := i.m.calendars[calendarID]; !ok {
+		return entities.ErrCalendarNotFound
+	}
+	for id, event := range i.m.events {
+		if event.CalendarID == calendarID {
+			delete(i.m.users[event.UserID], event.DateTime)
+			delete(i.m.notifications, id)
+			delete(i.m.events, id)
+			i.m.unindex(event)
+			i.m.writeOutbox(entities.NotifyCancelled, nil, *event)
+		}
+	}
+	delete(i.m.calendars, calendarID)
+	return nil
+}
+
+// ListEvents scans all events, it is consistent with keyset pagination of database repository.
+func (i EventRepo) ListEvents(ctx context.Context, query entities.ListQuery) (*entities.EventPage, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	events := []*entities.Event{}
+	for _, event := range i.m.events {
+		if event.Participates(query.UserID) && query.Matches(*event) && query.IsAfterCursor(*event) {
+			events = append(events, eventCreateSafely(event))
+		}
+	}
+	sort.Slice(events, func(a, b int) bool {
+		return query.Less(*events[a], *events[b])
+	})
+	if len(events) > query.Limit+1 {
+		events = events[:query.Limit+1]
+	}
+	return entities.NewEventPage(events, query), nil
+}
+
+// SearchEvents joins events of every term from inverted index and ranks them like database repository.
+func (i EventRepo) SearchEvents(ctx context.Context, userID string, terms []string, limit int) ([]*entities.SearchHit, error) {
+	i.m.rwmux.RLock()
+	defer i.m.rwmux.RUnlock()
+	found := make(map[string]bool)
+	for _, term := range terms {
+		for id := range i.m.prefixed(term) {
+			found[id] = true
+		}
+	}
+	hits := []*entities.SearchHit{}
+	for id := range found {
+		event := i.m.events[id]
+		if event.Participates(userID) {
+			hits = append(hits, &entities.SearchHit{Event: eventCreateSafely(event), Rank: event.Rank(terms)})
+		}
+	}
+	entities.SortHits(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func calendarCreateSafely(calendar *entities.Calendar) *entities.Calendar {
+	return &entities.Calendar{
+		ID:    calendar.ID,
+		Title: calendar.Title,
+		ACL:   append([]entities.ACLEntry{}, calendar.ACL...),
+	}
+}
+
+func participatesAny(event *entities.Event, userIDs []string) bool {
+	for _, userID := range userIDs {
+		if event.Participates(userID) {
+			return true
+		}
+	}
+	return false
+}
+
 func eventCreateSafely(event *entities.Event) *entities.Event {
+	var attendees []entities.Attendee
+	if event.Attendees != nil {
+		attendees = append([]entities.Attendee{}, event.Attendees...)
+	}
+	var reminders []entities.Reminder
+	if event.Reminders != nil {
+		reminders = append([]entities.Reminder{}, event.Reminders...)
+	}
 	return &entities.Event{
 		ID:         event.ID,
 		Title:      event.Title,
@@ -213,7 +742,12 @@ func eventCreateSafely(event *entities.Event) *entities.Event {
 		Duration:   event.Duration,
 		Text:       event.Text,
 		UserID:     event.UserID,
-		TimeNotify: event.TimeNotify,
+		Reminders:  reminders,
+		Recurrence: event.Recurrence.Copy(),
+		TimeZone:   event.TimeZone,
+		Attendees:  attendees,
+		CalendarID: event.CalendarID,
+		Version:    event.Version,
 	}
 }
 
@@ -223,3 +757,46 @@ func dateCompare(dt1, dt2 time.Time) bool {
 	y2, m2, d2 := dt2.Date()
 	return (y1 == y2 && m1 == m2 && d1 == d2)
 }
+
+// sortEvents make result of map iteration predictable.
+func sortEvents(events []*entities.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+}
+
+func nextDate(dt time.Time) time.Time {
+	y, m, d := dt.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, dt.Location())
+}
+
+// RelayOutbox publishes copy of pending messages without lock of events, relays are serialized by own lock, it is
+// the same as row locking of db repository.
+func (i EventRepo) RelayOutbox(ctx context.Context, limit int, publish entities.OutboxPublisher) (published int, err error) {
+	i.m.relayMux.Lock()
+	defer i.m.relayMux.Unlock()
+
+	i.m.rwmux.RLock()
+	pending := make([]entities.OutboxMessage, 0, limit)
+	for n := 0; n < limit && n < len(i.m.outbox); n++ {
+		pending = append(pending, i.m.outbox[n])
+	}
+	i.m.rwmux.RUnlock()
+
+	for _, m := range pending {
+		if err = publish(m); err != nil {
+			err = errors.Wrap(err, "can't relay outbox")
+			break
+		}
+		published++
+	}
+
+	//messages are only appended by changes of events, so published ones are still the first
+	i.m.rwmux.Lock()
+	defer i.m.rwmux.Unlock()
+	if published > len(i.m.outbox) {
+		published = len(i.m.outbox)
+	}
+	i.m.outbox = i.m.outbox[published:]
+	return published, err
+}
@@ -16,13 +16,13 @@ var (
 	testdt, _ = time.Parse(entities.LayoutISO, "2020-06-25 14:14:14")
 	testndt   = testdt.Add(-360000000000)
 	testEvent = entities.Event{
-		ID:         testid,
-		Title:      "title",
-		DateTime:   testdt,
-		Duration:   360000000000,
-		Text:       "text",
-		UserID:     testid,
-		TimeNotify: 360000000000,
+		ID:        testid,
+		Title:     "title",
+		DateTime:  testdt,
+		Duration:  360000000000,
+		Text:      "text",
+		UserID:    testid,
+		Reminders: []entities.Reminder{{Offset: 360000000000}},
 	}
 )
 
@@ -47,6 +47,41 @@ func TestInMemoryEventRepo(t *testing.T) {
 		require.Equal(t, "", id, "generated id must be empty")
 		require.Truef(t, errors.Is(err, entities.ErrDateBusy), "return error must be: %q", entities.ErrDateBusy)
 	})
+	t.Run("add over later occurrence", func(t *testing.T) {
+		repo.m.Clear()
+		recurring := testEvent
+		recurring.Recurrence, _ = entities.ParseRecurrence("FREQ=DAILY", "")
+		outsideAddMapRepo(repo.m, recurring)
+		overlapping := testEvent
+		overlapping.DateTime = testdt.AddDate(0, 0, 1).Add(2 * time.Minute)
+
+		_, err := repo.Add(ctx, overlapping)
+		require.Truef(t, errors.Is(err, entities.ErrDateBusy), "return error must be: %q", entities.ErrDateBusy)
+		require.Equal(t, []string{testid}, entities.ConflictingEventIDs(err))
+
+		overlapping.DateTime = testdt.AddDate(0, 0, -1).Add(2 * time.Minute)
+		id, err := repo.Add(ctx, overlapping)
+		require.Nil(t, err, "event before the series start is free")
+		require.NotEqual(t, "", id)
+	})
+	t.Run("add overlapping", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		overlapping := testEvent
+		overlapping.DateTime = testdt.Add(2 * time.Minute)
+
+		id, err := repo.Add(ctx, overlapping)
+		require.Equal(t, "", id, "generated id must be empty")
+		require.Truef(t, errors.Is(err, entities.ErrDateBusy), "return error must be: %q", entities.ErrDateBusy)
+		require.Equal(t, []string{testid}, entities.ConflictingEventIDs(err))
+
+		//period is half-open, so the next event may start at the end of previous one
+		next := testEvent
+		next.DateTime = testEvent.End()
+		id, err = repo.Add(ctx, next)
+		require.Nil(t, err)
+		require.NotEqual(t, "", id, "generated id must be not empty")
+	})
 	t.Run("get good", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
@@ -92,45 +127,55 @@ func TestInMemoryEventRepo(t *testing.T) {
 		require.Nil(t, e)
 		require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "return error must be: %q", entities.ErrEventNotFound)
 	})
-	t.Run("get by notify date good", func(t *testing.T) {
+	t.Run("get due reminders good", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
 
-		e, err := repo.GetByNotifyDate(ctx, testndt)
+		due, err := repo.GetDueReminders(ctx, testndt.Add(-time.Minute), testndt)
 
 		require.Nil(t, err)
-		var es []*entities.Event
-		es = append(es, &testEvent)
-		require.Equal(t, es, e, "events array not expected")
+		require.Equal(t, []*entities.DueReminder{{Event: &testEvent, Reminder: testEvent.Reminders[0]}}, due)
 	})
-	t.Run("get by notify date bad", func(t *testing.T) {
+	t.Run("get due reminders bad", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
-		e, err := repo.GetByNotifyDate(ctx, time.Now())
+		//reminder of the same day but minute later than period isn't due
+		due, err := repo.GetDueReminders(ctx, testndt.Add(-time.Hour), testndt.Add(-time.Minute))
 
-		require.Nil(t, e)
+		require.Nil(t, due)
 		require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "return error must be: %q", entities.ErrEventNotFound)
 	})
+	t.Run("watermark", func(t *testing.T) {
+		repo.m.Clear()
+		watermark, err := repo.GetWatermark(ctx, "alerts")
+		require.Nil(t, err)
+		require.True(t, watermark.IsZero())
+
+		require.Nil(t, repo.SetWatermark(ctx, "alerts", testdt))
+		watermark, err = repo.GetWatermark(ctx, "alerts")
+		require.Nil(t, err)
+		require.Equal(t, testdt, watermark)
+	})
 	t.Run("get by period good", func(t *testing.T) {
 		repo.m.Clear()
 
 		testEvent2 := entities.Event{
-			ID:         "11112233-4455-6677-8899-aabbccddeeff",
-			Title:      "title",
-			DateTime:   testdt.AddDate(0, 0, 5),
-			Duration:   360000000000,
-			Text:       "text",
-			UserID:     testid,
-			TimeNotify: 360000000000,
+			ID:        "11112233-4455-6677-8899-aabbccddeeff",
+			Title:     "title",
+			DateTime:  testdt.AddDate(0, 0, 5),
+			Duration:  360000000000,
+			Text:      "text",
+			UserID:    testid,
+			Reminders: []entities.Reminder{{Offset: 360000000000}},
 		}
 		testEvent3 := entities.Event{
-			ID:         "22112233-4455-6677-8899-aabbccddeeff",
-			Title:      "title",
-			DateTime:   testdt.AddDate(0, 1, 5),
-			Duration:   360000000000,
-			Text:       "text",
-			UserID:     testid,
-			TimeNotify: 360000000000,
+			ID:        "22112233-4455-6677-8899-aabbccddeeff",
+			Title:     "title",
+			DateTime:  testdt.AddDate(0, 1, 5),
+			Duration:  360000000000,
+			Text:      "text",
+			UserID:    testid,
+			Reminders: []entities.Reminder{{Offset: 360000000000}},
 		}
 		outsideAddMapRepo(repo.m, testEvent)
 		outsideAddMapRepo(repo.m, testEvent2)
@@ -149,22 +194,22 @@ func TestInMemoryEventRepo(t *testing.T) {
 	t.Run("get by period bad", func(t *testing.T) {
 		repo.m.Clear()
 		testEvent2 := entities.Event{
-			ID:         "11112233-4455-6677-8899-aabbccddeeff",
-			Title:      "title",
-			DateTime:   testdt.AddDate(0, 0, 5),
-			Duration:   360000000000,
-			Text:       "text",
-			UserID:     testid,
-			TimeNotify: 360000000000,
+			ID:        "11112233-4455-6677-8899-aabbccddeeff",
+			Title:     "title",
+			DateTime:  testdt.AddDate(0, 0, 5),
+			Duration:  360000000000,
+			Text:      "text",
+			UserID:    testid,
+			Reminders: []entities.Reminder{{Offset: 360000000000}},
 		}
 		testEvent3 := entities.Event{
-			ID:         "22112233-4455-6677-8899-aabbccddeeff",
-			Title:      "title",
-			DateTime:   testdt.AddDate(0, 1, 5),
-			Duration:   360000000000,
-			Text:       "text",
-			UserID:     testid,
-			TimeNotify: 360000000000,
+			ID:        "22112233-4455-6677-8899-aabbccddeeff",
+			Title:     "title",
+			DateTime:  testdt.AddDate(0, 1, 5),
+			Duration:  360000000000,
+			Text:      "text",
+			UserID:    testid,
+			Reminders: []entities.Reminder{{Offset: 360000000000}},
 		}
 		outsideAddMapRepo(repo.m, testEvent)
 		outsideAddMapRepo(repo.m, testEvent2)
@@ -175,31 +220,362 @@ func TestInMemoryEventRepo(t *testing.T) {
 		require.Nil(t, actualEvents)
 		require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "return error must be: %q", entities.ErrEventNotFound)
 	})
+	t.Run("get recurring by period", func(t *testing.T) {
+		repo.m.Clear()
+		recurring := testEvent
+		recurring.Recurrence, _ = entities.ParseRecurrence("FREQ=WEEKLY", "")
+		outsideAddMapRepo(repo.m, recurring)
+
+		actualEvents, err := repo.GetForPeriodByUserID(ctx, testid, testdt.AddDate(0, 1, 0), testdt.AddDate(0, 1, 1))
+
+		require.Nil(t, err)
+		require.Equal(t, []*entities.Event{&recurring}, actualEvents, "recurring event started before period must be returned")
+	})
+	t.Run("get overlapping", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		other := testEvent
+		other.ID = "11112233-4455-6677-8899-aabbccddeeff"
+		other.UserID = other.ID
+		outsideAddMapRepo(repo.m, other)
+
+		//the event lasts 6 minutes from testdt
+		events, err := repo.GetOverlapping(ctx, []string{testid, "unknown"}, testdt.Add(5*time.Minute), testdt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Equal(t, []*entities.Event{&testEvent}, events)
+
+		events, err = repo.GetOverlapping(ctx, []string{testid}, testdt.Add(6*time.Minute), testdt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Empty(t, events)
+	})
+	t.Run("invite attendees", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		guest := "11112233-4455-6677-8899-aabbccddeeff"
+
+		require.True(t, errors.Is(repo.AddAttendees(ctx, "unknown", []string{guest}), entities.ErrEventNotFound))
+		require.True(t, errors.Is(repo.SetAttendeeStatus(ctx, testid, guest, entities.Accepted), entities.ErrNotInvited))
+		require.Nil(t, repo.AddAttendees(ctx, testid, []string{guest, guest}))
+
+		events, err := repo.GetInvitations(ctx, guest)
+		require.Nil(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, []entities.Attendee{{UserID: guest, Status: entities.NeedsAction}}, events[0].Attendees)
+
+		_, err = repo.GetForPeriodByUserID(ctx, guest, testdt, testdt.Add(time.Hour))
+		require.True(t, errors.Is(err, entities.ErrUnknownUser), "not accepted event isn't in calendar of attendee")
+
+		require.Nil(t, repo.SetAttendeeStatus(ctx, testid, guest, entities.Accepted))
+		events, err = repo.GetForPeriodByUserID(ctx, guest, testdt, testdt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, testid, events[0].ID)
+
+		events, err = repo.GetOverlapping(ctx, []string{guest}, testdt, testdt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Len(t, events, 1)
+	})
+	t.Run("list events page by page", func(t *testing.T) {
+		repo.m.Clear()
+		for i := 0; i < 5; i++ {
+			_, err := repo.Add(ctx, newFakeEvent(i))
+			require.Nil(t, err)
+		}
+		query := entities.ListQuery{UserID: testid, Sort: entities.SortDateTimeDesc, Limit: 2}
+		listed := []*entities.Event{}
+		for {
+			page, err := repo.ListEvents(ctx, query)
+			require.Nil(t, err)
+			require.LessOrEqual(t, len(page.Events), 2)
+			listed = append(listed, page.Events...)
+			if page.Next == "" {
+				break
+			}
+			query.After, err = entities.DecodeCursor(page.Next, query.Sort)
+			require.Nil(t, err)
+		}
+		require.Len(t, listed, 5)
+		for i := 1; i < len(listed); i++ {
+			require.True(t, listed[i].DateTime.Before(listed[i-1].DateTime))
+		}
+
+		page, err := repo.ListEvents(ctx, entities.ListQuery{UserID: testid, Sort: entities.SortDateTime, Limit: 10, End: listed[2].DateTime})
+		require.Nil(t, err)
+		require.Len(t, page.Events, 2)
+		page, err = repo.ListEvents(ctx, entities.ListQuery{UserID: testid, Sort: entities.SortDateTime, Limit: 10, Search: "no such text"})
+		require.Nil(t, err)
+		require.Empty(t, page.Events)
+	})
+	t.Run("list recurring event as series", func(t *testing.T) {
+		repo.m.Clear()
+		recurring := testEvent
+		recurring.Recurrence, _ = entities.ParseRecurrence("FREQ=DAILY", "")
+		outsideAddMapRepo(repo.m, recurring)
+
+		page, err := repo.ListEvents(ctx, entities.ListQuery{UserID: testid, Sort: entities.SortDateTime, Limit: 10,
+			Start: testdt.AddDate(0, 0, 1), End: testdt.AddDate(0, 0, 7)})
+
+		require.Nil(t, err)
+		require.Len(t, page.Events, 1, "occurrences aren't expanded")
+		require.Equal(t, testdt, page.Events[0].DateTime, "series starts at its first occurrence")
+	})
+	t.Run("search events", func(t *testing.T) {
+		repo.m.Clear()
+		retro, _ := entities.NewEvent("Sprint retro", "2020-03-10 10:00:00", "1h", "march retrospective", testid, "")
+		review, _ := entities.NewEvent("Review", "2020-03-11 10:00:00", "1h", "retro items", testid, "")
+		other, _ := entities.NewEvent("Retro", "2020-03-12 10:00:00", "1h", "text", "11112233-4455-6677-8899-aabbccddeeff", "")
+		retroID, err := repo.Add(ctx, *retro)
+		require.Nil(t, err)
+		reviewID, err := repo.Add(ctx, *review)
+		require.Nil(t, err)
+		_, err = repo.Add(ctx, *other)
+		require.Nil(t, err)
+
+		hits, err := repo.SearchEvents(ctx, testid, []string{"retro"}, 10)
+		require.Nil(t, err)
+		require.Len(t, hits, 2, "events of other users aren't found")
+		require.Equal(t, retroID, hits[0].Event.ID, "title match goes first")
+		require.Equal(t, reviewID, hits[1].Event.ID)
+
+		hits, err = repo.SearchEvents(ctx, testid, []string{"retro", "mar"}, 10)
+		require.Nil(t, err)
+		require.Len(t, hits, 2)
+		require.Greater(t, hits[0].Rank, hits[1].Rank)
+
+		renamed := *review
+		renamed.Text = "planning"
+		require.Nil(t, repo.UpdateByID(ctx, testid, reviewID, renamed))
+		hits, err = repo.SearchEvents(ctx, testid, []string{"retro"}, 10)
+		require.Nil(t, err)
+		require.Len(t, hits, 1, "updated event is reindexed")
+		hits, err = repo.SearchEvents(ctx, testid, []string{"planning"}, 10)
+		require.Nil(t, err)
+		require.Len(t, hits, 1)
+
+		require.Nil(t, repo.DeleteByID(ctx, retroID))
+		hits, err = repo.SearchEvents(ctx, testid, []string{"retro"}, 10)
+		require.Nil(t, err)
+		require.Empty(t, hits)
+	})
+	t.Run("calendars", func(t *testing.T) {
+		repo.m.Clear()
+		member := "11112233-4455-6677-8899-aabbccddeeff"
+		calendar, err := entities.NewCalendar("team", testid)
+		require.Nil(t, err)
+		calendarID, err := repo.AddCalendar(ctx, *calendar)
+		require.Nil(t, err)
+
+		require.Nil(t, repo.SetRole(ctx, calendarID, member, entities.RoleViewer))
+		require.Nil(t, repo.SetRole(ctx, calendarID, member, entities.RoleEditor))
+		stored, err := repo.GetCalendar(ctx, calendarID)
+		require.Nil(t, err)
+		require.Equal(t, entities.RoleEditor, stored.RoleOf(member))
+		require.Len(t, stored.ACL, 2)
+
+		calendars, err := repo.GetCalendarsByUserID(ctx, member)
+		require.Nil(t, err)
+		require.Len(t, calendars, 1)
+
+		event := testEvent
+		event.CalendarID = calendarID
+		eventID, err := repo.Add(ctx, event)
+		require.Nil(t, err)
+		outsideAddMapRepo(repo.m, newFakeEvent(3))
+
+		events, err := repo.GetForPeriodByCalendarID(ctx, calendarID, testdt, testdt.Add(time.Hour))
+		require.Nil(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, eventID, events[0].ID)
+
+		require.Nil(t, repo.SetRole(ctx, calendarID, member, ""))
+		calendars, err = repo.GetCalendarsByUserID(ctx, member)
+		require.Nil(t, err)
+		require.Empty(t, calendars)
+
+		require.Nil(t, repo.DeleteCalendar(ctx, calendarID))
+		_, err = repo.GetByEventID(ctx, eventID)
+		require.True(t, errors.Is(err, entities.ErrEventNotFound), "events are deleted with calendar")
+		require.True(t, errors.Is(repo.SetRole(ctx, calendarID, member, entities.RoleViewer), entities.ErrCalendarNotFound))
+	})
+	t.Run("outbox", func(t *testing.T) {
+		repo.m.Clear()
+		event := newFakeEvent(1)
+		id, err := repo.Add(ctx, event)
+		require.Nil(t, err)
+		event.Title = "renamed"
+		require.Nil(t, repo.UpdateByID(ctx, testid, id, event))
+		event.DateTime = event.DateTime.Add(time.Hour)
+		require.Nil(t, repo.UpdateByID(ctx, testid, id, event))
+		require.Nil(t, repo.DeleteByID(ctx, id))
+
+		kinds := []entities.NotifyKind{}
+		relay := func(m entities.OutboxMessage) error {
+			if m.Kind == entities.NotifyCancelled && len(kinds) < 3 {
+				kinds = append(kinds, "failed")
+				return errors.New("queue is unavailable")
+			}
+			require.Equal(t, id, m.EventID)
+			require.Equal(t, []string{testid}, m.Recipients)
+			kinds = append(kinds, m.Kind)
+			return nil
+		}
+		published, err := repo.RelayOutbox(ctx, 10, relay)
+		require.NotNil(t, err)
+		require.Equal(t, 2, published)
+		published, err = repo.RelayOutbox(ctx, 10, relay)
+		require.Nil(t, err)
+		require.Equal(t, 1, published)
+		require.Equal(t, []entities.NotifyKind{entities.NotifyCreated, entities.NotifyRescheduled, "failed", entities.NotifyCancelled}, kinds,
+			"renaming doesn't reschedule event, failed message is relayed again")
+	})
+	t.Run("outbox doesn't lock events", func(t *testing.T) {
+		repo.m.Clear()
+		_, err := repo.Add(ctx, newFakeEvent(1))
+		require.Nil(t, err)
+
+		ids := []string{}
+		relay := func(m entities.OutboxMessage) error {
+			ids = append(ids, m.EventID)
+			if len(ids) == 1 {
+				// event is changed while relay publishes, its message waits for the next relay.
+				_, err := repo.Add(ctx, newFakeEvent(2))
+				require.Nil(t, err)
+			}
+			return nil
+		}
+		published, err := repo.RelayOutbox(ctx, 10, relay)
+		require.Nil(t, err)
+		require.Equal(t, 1, published)
+		published, err = repo.RelayOutbox(ctx, 10, relay)
+		require.Nil(t, err)
+		require.Equal(t, 1, published)
+		require.Len(t, ids, 2)
+		require.NotEqual(t, ids[0], ids[1])
+	})
+	t.Run("fetch due unnotified", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		now := testndt.Add(time.Minute)
+		from := now.Add(-time.Hour)
+
+		due, err := repo.FetchDueUnnotified(ctx, from, now)
+		require.Nil(t, err)
+		require.Equal(t, []*entities.DueReminder{{Event: &testEvent, Reminder: testEvent.Reminders[0], ClaimedAt: now}}, due)
+
+		due, err = repo.FetchDueUnnotified(ctx, from, now)
+		require.Nil(t, err)
+		require.Empty(t, due, "claimed occurrence must be skipped")
+
+		due, err = repo.FetchDueUnnotified(ctx, from, now.Add(entities.NotifyClaimTTL+time.Second))
+		require.Nil(t, err)
+		require.Len(t, due, 1, "expired claim must be available again")
+
+		require.Nil(t, repo.MarkNotified(ctx, testid, testdt, testEvent.Reminders[0]))
+		due, err = repo.FetchDueUnnotified(ctx, from, now.Add(2*entities.NotifyClaimTTL+time.Second))
+		require.Nil(t, err)
+		require.Empty(t, due, "notified occurrence must be skipped")
+	})
+	t.Run("renew claim", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		now := testndt.Add(time.Minute)
+		from := now.Add(-time.Hour)
+
+		due, err := repo.FetchDueUnnotified(ctx, from, now)
+		require.Nil(t, err)
+		require.Len(t, due, 1)
+		renewed, err := repo.RenewClaim(ctx, due[0], now.Add(entities.NotifyClaimTTL-time.Second))
+		require.Nil(t, err)
+		require.True(t, renewed)
+		require.Equal(t, now.Add(entities.NotifyClaimTTL-time.Second), due[0].ClaimedAt)
+
+		lost, err := repo.FetchDueUnnotified(ctx, from, now.Add(entities.NotifyClaimTTL+time.Second))
+		require.Nil(t, err)
+		require.Empty(t, lost, "renewed claim must be held")
+
+		stale := *due[0]
+		stale.ClaimedAt = now
+		renewed, err = repo.RenewClaim(ctx, &stale, now.Add(entities.NotifyClaimTTL))
+		require.Nil(t, err)
+		require.False(t, renewed, "claim renewed by other caller must be lost")
+
+		require.Nil(t, repo.MarkNotified(ctx, testid, testdt, testEvent.Reminders[0]))
+		renewed, err = repo.RenewClaim(ctx, due[0], now.Add(entities.NotifyClaimTTL))
+		require.Nil(t, err)
+		require.False(t, renewed, "notified reminder can't be renewed")
+	})
+	t.Run("fetch due reminders of one occurrence", func(t *testing.T) {
+		repo.m.Clear()
+		reminded := testEvent
+		reminded.Reminders = []entities.Reminder{{Offset: time.Hour, Channel: entities.ChannelEmail}, testEvent.Reminders[0]}
+		outsideAddMapRepo(repo.m, reminded)
+		now := testndt.Add(time.Minute)
+
+		due, err := repo.FetchDueUnnotified(ctx, now.Add(-2*time.Hour), now)
+		require.Nil(t, err)
+		require.Len(t, due, 2)
+		require.Equal(t, reminded.Reminders[0], due[0].Reminder, "earlier reminder goes first")
+		require.Equal(t, reminded.Reminders[1], due[1].Reminder)
+
+		require.Nil(t, repo.MarkNotified(ctx, testid, testdt, reminded.Reminders[0]))
+		due, err = repo.FetchDueUnnotified(ctx, now.Add(-2*time.Hour), now.Add(entities.NotifyClaimTTL+time.Second))
+		require.Nil(t, err)
+		require.Len(t, due, 1, "only notified reminder must be skipped")
+		require.Equal(t, reminded.Reminders[1], due[0].Reminder)
+	})
+	t.Run("fetch due recurring occurrences", func(t *testing.T) {
+		repo.m.Clear()
+		recurring := testEvent
+		recurring.Recurrence, _ = entities.ParseRecurrence("FREQ=DAILY", "")
+		outsideAddMapRepo(repo.m, recurring)
+		now := testndt.AddDate(0, 0, 2).Add(time.Minute)
+
+		//missed alerts of previous days are picked up too
+		due, err := repo.FetchDueUnnotified(ctx, now.AddDate(0, 0, -2), now)
+
+		require.Nil(t, err)
+		require.Len(t, due, 2)
+		require.Equal(t, testdt.AddDate(0, 0, 1), due[0].Event.DateTime)
+		require.Equal(t, testdt.AddDate(0, 0, 2), due[1].Event.DateTime)
+	})
 	t.Run("delete good", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
 
-		err := repo.DeleteByUserID(ctx, testid, testid)
+		err := repo.DeleteByUserID(ctx, testid, testid, 0)
 
 		require.Nil(t, err)
 	})
 	t.Run("delete bad", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
-		err := repo.DeleteByUserID(ctx, testid, "not id")
+		err := repo.DeleteByUserID(ctx, testid, "not id", 0)
 
 		require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "return error must be: %q", entities.ErrEventNotFound)
 	})
+	t.Run("delete moved event", func(t *testing.T) {
+		repo.m.Clear()
+		event := newFakeEvent(1)
+		id, err := repo.Add(ctx, event)
+		require.Nil(t, err)
+		event.DateTime = event.DateTime.Add(time.Hour)
+		require.Nil(t, repo.UpdateByID(ctx, testid, id, event))
+
+		require.Nil(t, repo.DeleteByUserID(ctx, testid, id, 2))
+
+		require.Empty(t, repo.m.users[testid], "stored date must be removed from user index")
+		require.Empty(t, repo.m.words, "stored title must be removed from search index")
+	})
 	t.Run("update good", func(t *testing.T) {
 		repo.m.Clear()
 		outsideAddMapRepo(repo.m, testEvent)
 		updateEvent := entities.Event{
-			Title:      "new title",
-			DateTime:   testdt,
-			Duration:   360000000000,
-			Text:       "text",
-			UserID:     testid,
-			TimeNotify: 360000000000,
+			Title:     "new title",
+			DateTime:  testdt,
+			Duration:  360000000000,
+			Text:      "text",
+			UserID:    testid,
+			Reminders: []entities.Reminder{{Offset: 360000000000}},
 		}
 
 		err := repo.UpdateByID(ctx, testid, testid, updateEvent)
@@ -214,6 +590,47 @@ func TestInMemoryEventRepo(t *testing.T) {
 
 		require.Truef(t, errors.Is(err, entities.ErrEventNotFound), "return error must be: %q", entities.ErrEventNotFound)
 	})
+	t.Run("update overlapping", func(t *testing.T) {
+		repo.m.Clear()
+		outsideAddMapRepo(repo.m, testEvent)
+		later := testEvent
+		later.DateTime = testdt.Add(time.Hour)
+		id, err := repo.Add(ctx, later)
+		require.Nil(t, err)
+
+		later.DateTime = testdt.Add(time.Minute)
+		err = repo.UpdateByID(ctx, testid, id, later)
+		require.Truef(t, errors.Is(err, entities.ErrDateBusy), "return error must be: %q", entities.ErrDateBusy)
+		require.Equal(t, []string{testid}, entities.ConflictingEventIDs(err))
+
+		later.DateTime = testdt.Add(2 * time.Hour)
+		require.Nil(t, repo.UpdateByID(ctx, testid, id, later))
+		//the old date is free after moving of event
+		moved := testEvent
+		moved.DateTime = testdt.Add(time.Hour)
+		_, err = repo.Add(ctx, moved)
+		require.Nil(t, err)
+	})
+	t.Run("stale version", func(t *testing.T) {
+		repo.m.Clear()
+		id, err := repo.Add(ctx, newFakeEvent(1))
+		require.Nil(t, err)
+		event, err := repo.GetByID(ctx, testid, id)
+		require.Nil(t, err)
+		require.Equal(t, entities.InitialVersion, event.Version)
+
+		event.Title = "first"
+		require.Nil(t, repo.UpdateByID(ctx, testid, id, *event))
+		event.Title = "second"
+		err = repo.UpdateByID(ctx, testid, id, *event)
+		require.Truef(t, errors.Is(err, entities.ErrVersionConflict), "return error must be: %q", entities.ErrVersionConflict)
+		require.Equal(t, "first", repo.m.events[id].Title, "stale write is rejected")
+		require.Equal(t, int64(2), repo.m.events[id].Version)
+
+		err = repo.DeleteByUserID(ctx, testid, id, entities.InitialVersion)
+		require.Truef(t, errors.Is(err, entities.ErrVersionConflict), "return error must be: %q", entities.ErrVersionConflict)
+		require.Nil(t, repo.DeleteByUserID(ctx, testid, id, 2))
+	})
 	t.Run("concurrently using", func(t *testing.T) {
 		repo.m.Clear()
 
@@ -237,7 +654,7 @@ func TestInMemoryEventRepo(t *testing.T) {
 			go func() {
 				defer wg.Done()
 				for id := range echan {
-					repo.DeleteByUserID(ctx, testid, id)
+					repo.DeleteByUserID(ctx, testid, id, 0)
 				}
 			}()
 		}
@@ -245,6 +662,60 @@ func TestInMemoryEventRepo(t *testing.T) {
 		l, _ := repo.GetForPeriodByUserID(ctx, testid, time.Now().AddDate(-10, 0, 0), time.Now().AddDate(10, 0, 0))
 		require.Equal(t, 0, len(l), "events list must be empty")
 	})
+	t.Run("idempotency", func(t *testing.T) {
+		repo.m.Clear()
+		now := time.Now()
+		record := entities.Idempotency{UserID: testid, Key: "key", Fingerprint: "add", ExpiresAt: now.Add(time.Minute)}
+		existing, err := repo.ReserveIdempotency(ctx, record, now)
+		require.Nil(t, err)
+		require.Nil(t, existing, "key is reserved")
+
+		existing, err = repo.ReserveIdempotency(ctx, entities.Idempotency{UserID: testid, Key: "key", Fingerprint: "update"}, now)
+		require.Nil(t, err)
+		require.True(t, existing.InProgress())
+		require.Equal(t, "add", existing.Fingerprint, "existing record isn't replaced")
+
+		require.Nil(t, repo.RenewIdempotency(ctx, testid, "key", now.Add(2*time.Minute)))
+		existing, err = repo.ReserveIdempotency(ctx, record, now.Add(90*time.Second))
+		require.Nil(t, err)
+		require.NotNil(t, existing, "renewed key is still reserved")
+
+		require.Nil(t, repo.CompleteIdempotency(ctx, testid, "key", "id", now.Add(time.Hour)))
+		require.True(t, errors.Is(repo.RenewIdempotency(ctx, testid, "key", now), entities.ErrIdempotencyMissed), "completed key isn't renewed")
+		existing, err = repo.ReserveIdempotency(ctx, record, now.Add(30*time.Minute))
+		require.Nil(t, err)
+		require.Equal(t, "id", existing.Response)
+
+		existing, err = repo.ReserveIdempotency(ctx, record, now.Add(time.Hour))
+		require.Nil(t, err)
+		require.Nil(t, existing, "expired key is reserved again")
+		require.Nil(t, repo.ReleaseIdempotency(ctx, testid, "key"))
+		require.True(t, errors.Is(repo.CompleteIdempotency(ctx, testid, "key", "id", now), entities.ErrIdempotencyMissed))
+
+		existing, err = repo.ReserveIdempotency(ctx, entities.Idempotency{UserID: "other", Key: "key", ExpiresAt: now.Add(time.Minute)}, now)
+		require.Nil(t, err)
+		require.Nil(t, existing, "keys of users are separated")
+	})
+	t.Run("delete older than", func(t *testing.T) {
+		repo.m.Clear()
+		old := testEvent
+		outsideAddMapRepo(repo.m, old)
+		recent := testEvent
+		recent.ID = "ffeeddcc-4455-6677-8899-aabbccddeeff"
+		recent.DateTime = time.Now()
+		outsideAddMapRepo(repo.m, recent)
+
+		removed, err := repo.DeleteOlderThan(ctx, entities.CleanQuery{Before: time.Now().Add(-time.Hour), Mode: entities.RetentionArchive})
+		require.Nil(t, err)
+		require.Equal(t, 1, removed)
+		require.Len(t, repo.m.events, 1)
+		require.Contains(t, repo.m.events, recent.ID)
+		require.Contains(t, repo.m.archive, old.ID, "event is moved to archive")
+
+		removed, err = repo.DeleteOlderThan(ctx, entities.CleanQuery{Before: time.Now().Add(time.Hour), UserID: "other"})
+		require.Nil(t, err)
+		require.Equal(t, 0, removed, "events of other users are kept")
+	})
 }
 
 func newFakeEvent(d int) entities.Event {
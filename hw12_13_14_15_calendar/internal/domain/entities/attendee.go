@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"github.com/pkg/errors"
+)
+
+// AttendeeStatus is RFC 5545 PARTSTAT of the invited user.
+type AttendeeStatus string
+
+const (
+	NeedsAction AttendeeStatus = "needs-action"
+	Accepted    AttendeeStatus = "accepted"
+	Declined    AttendeeStatus = "declined"
+	Tentative   AttendeeStatus = "tentative"
+)
+
+type Attendee struct {
+	UserID string
+	Status AttendeeStatus
+}
+
+// ParseAttendeeStatus returns status of the attendee response, needs-action isn't a response.
+func ParseAttendeeStatus(status string) (AttendeeStatus, error) {
+	switch s := AttendeeStatus(status); s {
+	case Accepted, Declined, Tentative:
+		return s, nil
+	}
+	return "", errors.Wrapf(ErrAttendeeStatus, "error parse status: %v", status)
+}
+
+// Attendee returns invitation of the user.
+func (e Event) Attendee(userID string) (Attendee, bool) {
+	for _, a := range e.Attendees {
+		if a.UserID == userID {
+			return a, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// Recipients returns users who must be notified about the event: owner and accepted attendees.
+func (e Event) Recipients() []string {
+	recipients := []string{e.UserID}
+	for _, a := range e.Attendees {
+		if a.Status == Accepted && a.UserID != e.UserID {
+			recipients = append(recipients, a.UserID)
+		}
+	}
+	return recipients
+}
+
+// Participates reports whether user is the owner or accepted attendee of the event.
+func (e Event) Participates(userID string) bool {
+	if e.UserID == userID {
+		return true
+	}
+	a, ok := e.Attendee(userID)
+	return ok && a.Status == Accepted
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttendees(t *testing.T) {
+	event := Event{
+		UserID: "owner",
+		Attendees: []Attendee{
+			{UserID: "accepted", Status: Accepted},
+			{UserID: "declined", Status: Declined},
+			{UserID: "tentative", Status: Tentative},
+			{UserID: "invited", Status: NeedsAction},
+		},
+	}
+
+	require.Equal(t, []string{"owner", "accepted"}, event.Recipients())
+	require.True(t, event.Participates("owner"))
+	require.True(t, event.Participates("accepted"))
+	require.False(t, event.Participates("tentative"))
+	require.False(t, event.Participates("stranger"))
+
+	for _, status := range []string{"needs-action", "maybe", ""} {
+		_, err := ParseAttendeeStatus(status)
+		require.Truef(t, errors.Is(err, ErrAttendeeStatus), "status %v must be invalid", status)
+	}
+	status, err := ParseAttendeeStatus("declined")
+	require.Nil(t, err)
+	require.Equal(t, Declined, status)
+}
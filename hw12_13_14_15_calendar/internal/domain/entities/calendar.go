@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Role is access level of the user to the calendar, every role allows everything of lower ones.
+type Role string
+
+const (
+	RoleOwner    Role = "owner"
+	RoleEditor   Role = "editor"
+	RoleViewer   Role = "viewer"
+	RoleFreeBusy Role = "freebusy"
+)
+
+var roleLevels = map[Role]int{
+	RoleFreeBusy: 1,
+	RoleViewer:   2,
+	RoleEditor:   3,
+	RoleOwner:    4,
+}
+
+// ParseRole returns role by its name.
+func ParseRole(role string) (Role, error) {
+	r := Role(role)
+	if _, ok := roleLevels[r]; !ok {
+		return "", errors.Wrapf(ErrRole, "error parse role: %v", role)
+	}
+	return r, nil
+}
+
+// Allows reports whether role gives access required by other role, empty role allows nothing.
+func (r Role) Allows(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+// ACLEntry gives role in the calendar to the user.
+type ACLEntry struct {
+	UserID string
+	Role   Role
+}
+
+// Calendar owns events which are shared with users of its ACL.
+// Event without calendar belongs to the personal calendar of its UserID, nobody else has access to it.
+type Calendar struct {
+	ID    string
+	Title string
+	ACL   []ACLEntry
+}
+
+// NewCalendar creates calendar owned by the user.
+func NewCalendar(title, ownerID string) (*Calendar, error) {
+	if title == "" {
+		return nil, fmt.Errorf(ErrNoField, "title")
+	}
+	if ownerID == "" {
+		return nil, fmt.Errorf(ErrNoField, "userID")
+	}
+	if len(title) > 100 {
+		return nil, ErrTitleLen
+	}
+	return &Calendar{
+		Title: title,
+		ACL:   []ACLEntry{{UserID: ownerID, Role: RoleOwner}},
+	}, nil
+}
+
+// RoleOf returns role of the user, empty role means no access.
+func (c Calendar) RoleOf(userID string) Role {
+	for _, entry := range c.ACL {
+		if entry.UserID == userID {
+			return entry.Role
+		}
+	}
+	return ""
+}
+
+// Owners returns count of calendar owners, calendar must always have one.
+func (c Calendar) Owners() int {
+	owners := 0
+	for _, entry := range c.ACL {
+		if entry.Role == RoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+// Busy returns copy of event which shows only time when it takes place, it is view of free-busy-only role.
+func (e Event) Busy() *Event {
+	return &Event{
+		ID:         e.ID,
+		Title:      "busy",
+		DateTime:   e.DateTime,
+		Duration:   e.Duration,
+		Recurrence: e.Recurrence.Copy(),
+		TimeZone:   e.TimeZone,
+		CalendarID: e.CalendarID,
+	}
+}
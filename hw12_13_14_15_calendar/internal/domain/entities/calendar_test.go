@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar(t *testing.T) {
+	calendar, err := NewCalendar("team", "owner")
+	require.Nil(t, err)
+	calendar.ACL = append(calendar.ACL, ACLEntry{UserID: "viewer", Role: RoleViewer}, ACLEntry{UserID: "freebusy", Role: RoleFreeBusy})
+
+	require.Equal(t, RoleOwner, calendar.RoleOf("owner"))
+	require.Equal(t, Role(""), calendar.RoleOf("stranger"))
+	require.Equal(t, 1, calendar.Owners())
+
+	require.True(t, calendar.RoleOf("owner").Allows(RoleEditor))
+	require.True(t, calendar.RoleOf("viewer").Allows(RoleFreeBusy))
+	require.False(t, calendar.RoleOf("viewer").Allows(RoleEditor))
+	require.False(t, calendar.RoleOf("freebusy").Allows(RoleViewer))
+	require.False(t, calendar.RoleOf("stranger").Allows(RoleFreeBusy))
+
+	_, err = ParseRole("admin")
+	require.True(t, errors.Is(err, ErrRole))
+	_, err = NewCalendar("", "owner")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,71 @@
+package entities
+
+// ChangeKind is kind of event change.
+type ChangeKind string
+
+const (
+	ChangeCreated ChangeKind = "created"
+	ChangeUpdated ChangeKind = "updated"
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// Change is change of event seen by its watchers, feed assigns increasing revisions to changes.
+type Change struct {
+	Revision uint64
+	Kind     ChangeKind
+	// Event is state of event after change, deleted event has its last state.
+	Event *Event
+	// UserIDs are users who see the event: owner, attendees and users who can view its shared calendar.
+	UserIDs []string
+}
+
+// ChangeFeed delivers changes of events to users watching them.
+type ChangeFeed interface {
+	// Publish assigns the next revision to change and sends it to subscribers of its users.
+	Publish(change Change)
+	// Subscribe returns subscription of user to changes after revision, zero revision means changes from now.
+	// It returns ErrRevisionGone if changes after revision aren't kept anymore.
+	Subscribe(userID string, after uint64) (*Subscription, error)
+}
+
+// Subscription delivers missed changes in Backlog and new ones in Changes.
+// Changes is closed if subscriber is too slow or feed is stopped, the client resumes from the last received revision.
+type Subscription struct {
+	Backlog []Change
+	Changes <-chan Change
+	Cancel  func()
+}
+
+// NewChange returns change of event, calendar is shared calendar of event or nil.
+func NewChange(kind ChangeKind, event *Event, calendar *Calendar) Change {
+	users := []string{event.UserID}
+	seen := map[string]bool{event.UserID: true}
+	add := func(userID string) {
+		if !seen[userID] {
+			seen[userID] = true
+			users = append(users, userID)
+		}
+	}
+	for _, a := range event.Attendees {
+		add(a.UserID)
+	}
+	if calendar != nil {
+		//free-busy-only users must not see details of events
+		for _, entry := range calendar.ACL {
+			if entry.Role.Allows(RoleViewer) {
+				add(entry.UserID)
+			}
+		}
+	}
+	return Change{Kind: kind, Event: event.Copy(), UserIDs: users}
+}
+
+// VisibleTo reports whether user watches the changed event.
+func (c Change) VisibleTo(userID string) bool {
+	for _, id := range c.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChange(t *testing.T) {
+	event := &Event{ID: "e", UserID: "owner", Attendees: []Attendee{{UserID: "guest"}, {UserID: "owner"}}}
+	calendar := &Calendar{ID: "c", ACL: []ACLEntry{
+		{UserID: "owner", Role: RoleOwner},
+		{UserID: "viewer", Role: RoleViewer},
+		{UserID: "busy", Role: RoleFreeBusy},
+	}}
+
+	change := NewChange(ChangeUpdated, event, calendar)
+	require.Equal(t, ChangeUpdated, change.Kind)
+	require.Equal(t, []string{"owner", "guest", "viewer"}, change.UserIDs)
+	require.True(t, change.VisibleTo("viewer"))
+	require.False(t, change.VisibleTo("busy"), "free-busy users don't see details of events")
+
+	event.Title = "changed"
+	require.Empty(t, change.Event.Title, "change keeps state of event")
+
+	require.Equal(t, []string{"owner"}, NewChange(ChangeDeleted, &Event{UserID: "owner"}, nil).UserIDs)
+}
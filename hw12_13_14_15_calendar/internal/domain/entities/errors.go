@@ -1,20 +1,75 @@
 package entities
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
 // ErrorEntity is business error.
 var (
-	ErrDateBusy       = errors.New("date for this event is busy")
-	ErrUnknownUser    = errors.New("user not found")
-	ErrEventNotFound  = errors.New("event not found")
-	ErrTitleLen       = errors.New("event title field is to long. It should be no more than 100 letters")
-	ErrDateFormat     = errors.New("date of event is in the incorrect format. format is " + LayoutDateISO)
-	ErrDatetimeFormat = errors.New("date of event is in the incorrect format. format is " + LayoutISO)
-	ErrDurationFormat = errors.New("duration of the event is in the incorrect format. format is: XXs,XXm,XXh,XXd, where XX-decimal")
-	ErrNotifyFormat   = errors.New("time to notify about event is in the incorrect format. format is: XXs,XXm,XXh,XXd, where XX-decimal")
-	ErrEventFormat    = errors.New("not correct event")
+	ErrDateBusy           = errors.New("date for this event is busy")
+	ErrUnknownUser        = errors.New("user not found")
+	ErrEventNotFound      = errors.New("event not found")
+	ErrTitleLen           = errors.New("event title field is to long. It should be no more than 100 letters")
+	ErrDateFormat         = errors.New("date of event is in the incorrect format. format is " + LayoutDateISO)
+	ErrDatetimeFormat     = errors.New("date of event is in the incorrect format. format is " + LayoutISO)
+	ErrDurationFormat     = errors.New("duration of the event is in the incorrect format. format is: XXs,XXm,XXh,XXd, where XX-decimal")
+	ErrNotifyFormat       = errors.New("reminders of event are in the incorrect format. format is comma separated list of OFFSET[:CHANNEL], where OFFSET is XXs,XXm,XXh and CHANNEL is email or webhook, e.g. 24h:email,10m:webhook")
+	ErrEventFormat        = errors.New("not correct event")
+	ErrRRuleFormat        = errors.New("recurrence rule of the event is in the incorrect format. format is RFC 5545 RRULE with FREQ(DAILY,WEEKLY,MONTHLY,YEARLY),INTERVAL,BYDAY,COUNT,UNTIL parts")
+	ErrExDateFormat       = errors.New("recurrence exceptions of the event are in the incorrect format. format is comma separated list of " + LayoutISO)
+	ErrTimeZone           = errors.New("time zone is in the incorrect format. format is IANA time zone name, eg. Europe/Moscow")
+	ErrWorkingHoursFormat = errors.New("working hours are in the incorrect format. format is HH:MM-HH:MM")
+	ErrPeriod             = errors.New("end of the period must be after its start")
+	ErrSlotsFormat        = errors.New("count of slots must be positive decimal number")
+	ErrAttendeeStatus     = errors.New("status of the attendee is incorrect. status is one of: accepted, declined, tentative")
+	ErrNotInvited         = errors.New("user is not invited to the event")
+	ErrCalendarNotFound   = errors.New("calendar not found")
+	ErrAccessDenied       = errors.New("user has no access to the calendar")
+	ErrRole               = errors.New("role is incorrect. role is one of: owner, editor, viewer, freebusy")
+	ErrLastOwner          = errors.New("calendar must have an owner")
+	ErrSortOrder          = errors.New("sort order is incorrect. sort is one of: datetime, -datetime, title, -title")
+	ErrCursor             = errors.New("cursor is incorrect, it must be taken from the previous page of the same listing")
+	ErrPageSize           = errors.New("page size must be decimal number from 1 to 500")
+	ErrRevisionFormat     = errors.New("revision must be decimal number")
+	ErrRevisionGone       = errors.New("changes after the revision are gone, events must be reloaded and watched from now")
+	ErrRetentionMode      = errors.New("retention mode is incorrect. mode is one of: delete, archive")
+	ErrIdempotencyKey     = errors.New("idempotency key is invalid. It should be no more than 255 characters and not start with \"delivery:\"")
+	ErrIdempotencyReused  = errors.New("idempotency key is already used for another request")
+	ErrIdempotencyPending = errors.New("request with the idempotency key is in progress, retry it later")
+	ErrIdempotencyMissed  = errors.New("idempotency key is not reserved")
+	ErrVersionFormat      = errors.New("version of the event must be positive decimal number")
+	ErrVersionConflict    = errors.New("event is changed by another request, it must be reloaded and changed again")
 )
 
 var ErrNoField = "field %v is necessary"
+
+// DateBusyError is ErrDateBusy with IDs of events which overlap the event.
+type DateBusyError struct {
+	EventIDs []string
+}
+
+func NewDateBusyError(eventIDs ...string) error {
+	return &DateBusyError{EventIDs: eventIDs}
+}
+
+func (e *DateBusyError) Error() string {
+	if len(e.EventIDs) == 0 {
+		return ErrDateBusy.Error()
+	}
+	return ErrDateBusy.Error() + ", conflicts with events: " + strings.Join(e.EventIDs, ",")
+}
+
+func (e *DateBusyError) Unwrap() error {
+	return ErrDateBusy
+}
+
+// ConflictingEventIDs returns IDs of overlapping events if err is DateBusyError.
+func ConflictingEventIDs(err error) []string {
+	var busy *DateBusyError
+	if errors.As(err, &busy) {
+		return busy.EventIDs
+	}
+	return nil
+}
@@ -13,29 +13,101 @@ const (
 	LayoutDateISO = "2006-01-02"
 )
 
+// OverlapHorizon bounds comparison of recurring events, so overlap of endless series is checked in finite time.
+const OverlapHorizon = 366 * 24 * time.Hour
+
 type EventRepo interface {
+	// Add and UpdateByID return ErrDateBusy if the event overlaps other event of the user, see Event.Overlaps.
 	Add(ctx context.Context, event Event) (ID string, err error)
 	GetByID(ctx context.Context, userID, eventID string) (*Event, error)
 	GetByDate(ctx context.Context, userID string, date time.Time) ([]*Event, error)
-	GetByNotifyDate(ctx context.Context, date time.Time) ([]*Event, error)
+	// GetDueReminders returns reminders of event occurrences which notify time is in [from, to] sorted by notify time.
+	GetDueReminders(ctx context.Context, from, to time.Time) ([]*DueReminder, error)
+	// GetForPeriodByUserID returns events of the user and events which the user accepted invitation to.
 	GetForPeriodByUserID(ctx context.Context, userID string, dateStart time.Time, dateEnd time.Time) ([]*Event, error)
 	GetForPeriod(ctx context.Context, dateStart time.Time, dateEnd time.Time) ([]*Event, error)
+	// GetOverlapping returns events of users or accepted by them which period overlaps [dateStart,dateEnd) and recurring events started before dateEnd.
+	GetOverlapping(ctx context.Context, userIDs []string, dateStart time.Time, dateEnd time.Time) ([]*Event, error)
+	// UpdateByID saves event with the next version, it returns ErrVersionConflict if event.Version isn't version of the
+	// stored event. Zero version isn't checked.
 	UpdateByID(ctx context.Context, userID, eventID string, event Event) error
-	DeleteByUserID(ctx context.Context, userID, eventID string) error
+	// DeleteByUserID returns ErrVersionConflict if version isn't version of the stored event, zero version isn't checked.
+	DeleteByUserID(ctx context.Context, userID, eventID string, version int64) error
 	DeleteByID(ctx context.Context, eventID string) error
+	// FetchDueUnnotified claims reminders of event occurrences which notify time is in [from, now] and which are not notified yet.
+	// Claimed reminder is hidden from other callers until it is marked notified or NotifyClaimTTL expires.
+	FetchDueUnnotified(ctx context.Context, from, now time.Time) ([]*DueReminder, error)
+	// RenewClaim extends claim of not notified reminder till now if it is still held since d.ClaimedAt, it returns false
+	// if claim is lost, i.e. reminder is notified or claimed by other caller after claim expired.
+	RenewClaim(ctx context.Context, d *DueReminder, now time.Time) (bool, error)
+	MarkNotified(ctx context.Context, eventID string, occurrence time.Time, reminder Reminder) error
+	// GetOldestUnnotified returns notify time of the oldest claimed but not notified reminder which notify time isn't
+	// before from, it is zero time if there is no such reminder.
+	GetOldestUnnotified(ctx context.Context, from time.Time) (time.Time, error)
+	// GetWatermark returns time which named scan has processed everything before, it is zero time if scan never ran.
+	GetWatermark(ctx context.Context, name string) (time.Time, error)
+	SetWatermark(ctx context.Context, name string, watermark time.Time) error
+	// AddAttendees invites users to the event, invitation of already invited user is kept.
+	AddAttendees(ctx context.Context, eventID string, userIDs []string) error
+	// SetAttendeeStatus saves response of invited user, it returns ErrNotInvited if user isn't invited.
+	SetAttendeeStatus(ctx context.Context, eventID, userID string, status AttendeeStatus) error
+	// GetInvitations returns events which the user is invited to.
+	GetInvitations(ctx context.Context, userID string) ([]*Event, error)
+	// GetByEventID returns event regardless of its owner, access must be checked by caller.
+	GetByEventID(ctx context.Context, eventID string) (*Event, error)
+	// GetForPeriodByCalendarID returns events of the calendar, it returns empty list for calendar without events.
+	GetForPeriodByCalendarID(ctx context.Context, calendarID string, dateStart time.Time, dateEnd time.Time) ([]*Event, error)
+	AddCalendar(ctx context.Context, calendar Calendar) (ID string, err error)
+	GetCalendar(ctx context.Context, calendarID string) (*Calendar, error)
+	// GetCalendarsByUserID returns calendars which the user has any role in.
+	GetCalendarsByUserID(ctx context.Context, userID string) ([]*Calendar, error)
+	// SetRole adds the user to calendar ACL or replaces role of the user, empty role removes the user from ACL.
+	SetRole(ctx context.Context, calendarID, userID string, role Role) error
+	// DeleteCalendar deletes calendar with all its events.
+	DeleteCalendar(ctx context.Context, calendarID string) error
+	// ListEvents returns page of events of the user and events which the user accepted, see ListQuery.
+	// Recurring event is returned once as the whole series.
+	ListEvents(ctx context.Context, query ListQuery) (*EventPage, error)
+	// SearchEvents returns at most limit events of the user and accepted by the user which title or text contain words
+	// prefixed by any term, the most relevant events go first.
+	SearchEvents(ctx context.Context, userID string, terms []string, limit int) ([]*SearchHit, error)
+	// DeleteOlderThan removes events selected by query by batches, every batch in own transaction, and returns number
+	// of removed events. Archived events are moved to archive, lifecycle messages aren't written for removed events.
+	DeleteOlderThan(ctx context.Context, query CleanQuery) (removed int, err error)
+	// RelayOutbox passes at most limit the oldest outbox messages to publish in order of changes and removes published ones.
+	// It stops at the first failed message, so it is published by the next call. Add, UpdateByID and deletes write outbox
+	// in the same transaction as the event.
+	RelayOutbox(ctx context.Context, limit int, publish OutboxPublisher) (published int, err error)
+	IdempotencyRepo
 }
 
 type Event struct {
-	ID         string
-	Title      string
-	DateTime   time.Time
-	Duration   time.Duration
-	Text       string
-	UserID     string
-	TimeNotify time.Duration
+	ID       string
+	Title    string
+	DateTime time.Time
+	Duration time.Duration
+	Text     string
+	UserID   string
+	// Reminders are sorted from the earliest one, see ParseReminders.
+	Reminders  []Reminder
+	Recurrence *Recurrence
+	// TimeZone is IANA name of the zone where event is planned, DateTime is stored in UTC.
+	TimeZone string
+	// Attendees are users invited to the event by its owner UserID.
+	Attendees []Attendee
+	// CalendarID is shared calendar of the event, empty id means personal calendar of UserID.
+	CalendarID string
+	// Version is InitialVersion for added event and it is incremented by every update, stale writes are rejected by it.
+	Version int64
 }
 
-func (e Event) Update(title, dateTime, duration, text, timeNotify string) (Event, error) {
+func (e Event) Update(title, dateTime, duration, text, reminders, rrule, exdate, timeZone string) (Event, error) {
+	//update time zone if not empty, local time of event is kept
+	if timeZone != "" {
+		if err := e.SetTimeZone(timeZone); err != nil {
+			return e, err
+		}
+	}
 	//update duration if not empty
 	if duration != "" {
 		dr, err := time.ParseDuration(duration)
@@ -44,21 +116,21 @@ func (e Event) Update(title, dateTime, duration, text, timeNotify string) (Event
 		}
 		e.Duration = dr
 	}
-	//update timeNotify if not empty
-	if timeNotify != "" {
-		tn, err := time.ParseDuration(timeNotify)
+	//update reminders if not empty, NoReminders removes all of them
+	if reminders != "" {
+		rs, err := ParseReminders(reminders)
 		if err != nil {
-			return e, errors.Wrapf(ErrNotifyFormat, "error update timeNotify: %v", timeNotify)
+			return e, err
 		}
-		e.TimeNotify = tn
+		e.Reminders = rs
 	}
 	//update dateTime if not empty
 	if dateTime != "" {
-		dt, err := time.Parse(LayoutISO, dateTime)
+		dt, err := time.ParseInLocation(LayoutISO, dateTime, e.Location())
 		if err != nil {
 			return e, errors.Wrapf(ErrDatetimeFormat, "error update dateTime: %v", dateTime)
 		}
-		e.DateTime = dt
+		e.DateTime = dt.UTC()
 	}
 	//update title
 	if title != "" && title != e.Title {
@@ -68,11 +140,182 @@ func (e Event) Update(title, dateTime, duration, text, timeNotify string) (Event
 	if text != "" && text != e.Text {
 		e.Text = text
 	}
+	//update recurrence if not empty, exdate without rrule replaces exceptions of current rule
+	if rrule != "" || exdate != "" {
+		if rrule == "" {
+			rrule = e.Recurrence.RRule()
+		}
+		if err := e.SetRecurrence(rrule, exdate); err != nil {
+			return e, err
+		}
+	}
 	return e, nil
 }
 
-// NewEvent create Event entity and validating business logic of event.
-func NewEvent(title, dateTime, duration, text, userID, timeNotify string) (*Event, error) {
+// SetRecurrence make event recurring by RFC 5545 rrule with exdate exceptions in the event time zone.
+func (e *Event) SetRecurrence(rrule, exdate string) error {
+	r, err := ParseRecurrenceInLocation(rrule, exdate, e.Location())
+	if err != nil {
+		return errors.Wrapf(err, "error set recurrence: %v", rrule)
+	}
+	e.Recurrence = r
+	return nil
+}
+
+// ExDate returns recurrence exceptions in the event time zone.
+func (e Event) ExDate() string {
+	return e.Recurrence.ExDateInLocation(e.Location())
+}
+
+// SetTimeZone moves event to the time zone keeping its local date and time.
+func (e *Event) SetTimeZone(timeZone string) error {
+	loc, err := LoadLocation(timeZone)
+	if err != nil {
+		return err
+	}
+	old := e.Location()
+	move := func(t time.Time) time.Time {
+		l := t.In(old)
+		return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), l.Nanosecond(), loc).UTC()
+	}
+	e.DateTime = move(e.DateTime)
+	if e.Recurrence != nil {
+		for i, ex := range e.Recurrence.ExDates {
+			e.Recurrence.ExDates[i] = move(ex)
+		}
+	}
+	e.TimeZone = loc.String()
+	return nil
+}
+
+// Location returns location of the event time zone.
+func (e Event) Location() *time.Location {
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (e Event) IsRecurring() bool {
+	return e.Recurrence != nil
+}
+
+// Occurrences returns copies of event for every occurrence started in [start,end).
+func (e Event) Occurrences(start, end time.Time) []*Event {
+	if !e.IsRecurring() {
+		if e.DateTime.Before(start) || !e.DateTime.Before(end) {
+			return nil
+		}
+		return []*Event{e.Copy()}
+	}
+	//series is expanded in the event time zone, so local time of occurrences is the same across DST transitions
+	dates := e.Recurrence.Between(e.DateTime.In(e.Location()), start, end)
+	occurrences := make([]*Event, 0, len(dates))
+	for _, dt := range dates {
+		o := e.Copy()
+		o.DateTime = dt.In(e.DateTime.Location())
+		occurrences = append(occurrences, o)
+	}
+	return occurrences
+}
+
+// NotifyTime returns time when user must be notified about the event by the reminder.
+func (e Event) NotifyTime(r Reminder) time.Time {
+	return e.DateTime.Add(-r.Offset)
+}
+
+// DueReminders returns reminders of occurrences which notify time is in [from, to].
+func (e Event) DueReminders(from, to time.Time) []*DueReminder {
+	due := []*DueReminder{}
+	for _, r := range e.Reminders {
+		for _, occurrence := range e.Occurrences(from.Add(r.Offset), to.Add(r.Offset).Add(time.Nanosecond)) {
+			due = append(due, &DueReminder{Event: occurrence, Reminder: r})
+		}
+	}
+	return due
+}
+
+// EndsBefore reports whether the last occurrence of event started before date.
+func (e Event) EndsBefore(date time.Time) bool {
+	if !e.IsRecurring() {
+		return e.DateTime.Before(date)
+	}
+	last, ok := e.Recurrence.Last(e.DateTime.In(e.Location()))
+	return ok && last.Before(date)
+}
+
+// End returns time when the event is over.
+func (e Event) End() time.Time {
+	return e.DateTime.Add(e.Duration)
+}
+
+// Overlaps reports whether [DateTime, DateTime+Duration) periods of events intersect, event without duration is a point in time.
+// Occurrences of recurring events are compared during OverlapHorizon since the later event started.
+func (e Event) Overlaps(other Event) bool {
+	if !e.IsRecurring() && !other.IsRecurring() {
+		return e.periodOverlaps(other)
+	}
+	//occurrence started before the later event may last till it
+	longest := e.Duration
+	if other.Duration > longest {
+		longest = other.Duration
+	}
+	start := e.DateTime
+	if other.DateTime.After(start) {
+		start = other.DateTime
+	}
+	start = start.Add(-longest)
+	end := start.Add(longest + OverlapHorizon)
+	a, b := e.Occurrences(start, end), other.Occurrences(start, end)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if a[i].periodOverlaps(*b[j]) {
+			return true
+		}
+		//occurrence which is over earlier can't overlap the next occurrences of another event
+		if a[i].End().Before(b[j].End()) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return false
+}
+
+func (e Event) periodOverlaps(other Event) bool {
+	switch {
+	case e.Duration == 0 && other.Duration == 0:
+		return e.DateTime.Equal(other.DateTime)
+	case e.Duration == 0:
+		return !e.DateTime.Before(other.DateTime) && e.DateTime.Before(other.End())
+	case other.Duration == 0:
+		return !other.DateTime.Before(e.DateTime) && other.DateTime.Before(e.End())
+	}
+	return e.DateTime.Before(other.End()) && other.DateTime.Before(e.End())
+}
+
+// In returns copy of event with DateTime rendered in the location.
+func (e Event) In(loc *time.Location) *Event {
+	c := e.Copy()
+	c.DateTime = c.DateTime.In(loc)
+	return c
+}
+
+func (e Event) Copy() *Event {
+	c := e
+	c.Recurrence = e.Recurrence.Copy()
+	if e.Attendees != nil {
+		c.Attendees = append([]Attendee{}, e.Attendees...)
+	}
+	if e.Reminders != nil {
+		c.Reminders = append([]Reminder{}, e.Reminders...)
+	}
+	return &c
+}
+
+// NewEvent create Event entity and validating business logic of event. DateTime is parsed in UTC, use SetTimeZone to move event to another zone.
+// Event without reminders is reminded at its start by default channel, NoReminders makes event without reminders.
+func NewEvent(title, dateTime, duration, text, userID, reminders string) (*Event, error) {
 	if title == "" {
 		return nil, fmt.Errorf(ErrNoField, "title")
 	}
@@ -104,20 +347,33 @@ func NewEvent(title, dateTime, duration, text, userID, timeNotify string) (*Even
 		return nil, errors.Wrapf(ErrDurationFormat, "error set duration: %v", duration)
 	}
 
-	tn := time.Duration(0)
-	if timeNotify != "" {
-		tn, err = time.ParseDuration(timeNotify)
+	rs := []Reminder{{}}
+	if reminders != "" {
+		rs, err = ParseReminders(reminders)
 		if err != nil {
-			return nil, errors.Wrapf(ErrNotifyFormat, "error set timeNotify:  %v", timeNotify)
+			return nil, err
 		}
 	}
 
 	return &Event{
-		Title:      tl,
-		DateTime:   dt,
-		Duration:   dr,
-		Text:       text,
-		UserID:     userID,
-		TimeNotify: tn,
+		Title:     tl,
+		DateTime:  dt,
+		TimeZone:  time.UTC.String(),
+		Duration:  dr,
+		Text:      text,
+		UserID:    userID,
+		Reminders: rs,
 	}, nil
 }
+
+// LoadLocation returns location by IANA time zone name, empty name is UTC.
+func LoadLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(ErrTimeZone, "unknown time zone: %v", timeZone)
+	}
+	return loc, nil
+}
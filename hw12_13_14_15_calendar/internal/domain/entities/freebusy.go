@@ -0,0 +1,180 @@
+package entities
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const layoutClock = "15:04"
+
+// Interval is half-open period of time [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// UserBusy is busy time of the user.
+type UserBusy struct {
+	UserID string
+	Busy   []Interval
+}
+
+// FreeBusy is busy time of every user and slots when all of them are free.
+type FreeBusy struct {
+	Users []UserBusy
+	Slots []Interval
+}
+
+// WorkingHours is daily period of time when user is available, it is local time of Location.
+type WorkingHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseWorkingHours parses hours in format 09:00-18:00 in time zone, empty hours means user is available all day.
+func ParseWorkingHours(hours, timeZone string) (*WorkingHours, error) {
+	if hours == "" {
+		return nil, nil
+	}
+	loc, err := LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return nil, errors.Wrapf(ErrWorkingHoursFormat, "error parse working hours: %v", hours)
+	}
+	start, err := time.Parse(layoutClock, strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, errors.Wrapf(ErrWorkingHoursFormat, "error parse working hours: %v", hours)
+	}
+	end, err := time.Parse(layoutClock, strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, errors.Wrapf(ErrWorkingHoursFormat, "error parse working hours: %v", hours)
+	}
+	wh := &WorkingHours{
+		Start:    time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+		End:      time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute,
+		Location: loc,
+	}
+	if wh.End <= wh.Start {
+		return nil, errors.Wrapf(ErrWorkingHoursFormat, "working hours must end after start: %v", hours)
+	}
+	return wh, nil
+}
+
+// OffHours returns periods of [start,end) which are out of working hours.
+func (w WorkingHours) OffHours(start, end time.Time) []Interval {
+	var working []Interval
+	local := start.In(w.Location)
+	//the previous day is included because its working hours may last after midnight of start in other time zone
+	for day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, w.Location); day.Before(end); day = day.AddDate(0, 0, 1) {
+		//nanoseconds are normalized as wall clock, so working hours keep local time across DST transitions
+		working = append(working, Interval{
+			Start: time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(w.Start), w.Location),
+			End:   time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(w.End), w.Location),
+		})
+	}
+	return Complement(working, start, end)
+}
+
+// BusyIntervals returns merged periods of [start,end) occupied by events, events without duration don't occupy time.
+func BusyIntervals(events []*Event, start, end time.Time) []Interval {
+	var busy []Interval
+	for _, event := range events {
+		if event.Duration <= 0 {
+			continue
+		}
+		//occurrence started before start may last till the period
+		for _, o := range event.Occurrences(start.Add(-event.Duration), end) {
+			if !o.End().After(start) {
+				continue
+			}
+			busy = append(busy, clip(Interval{Start: o.DateTime, End: o.End()}, start, end))
+		}
+	}
+	return MergeIntervals(busy)
+}
+
+// MergeIntervals sorts intervals and joins overlapping or adjacent ones.
+func MergeIntervals(intervals []Interval) []Interval {
+	if len(intervals) == 0 {
+		return []Interval{}
+	}
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	merged := []Interval{sorted[0]}
+	for _, in := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if in.Start.After(last.End) {
+			merged = append(merged, in)
+			continue
+		}
+		if in.End.After(last.End) {
+			last.End = in.End
+		}
+	}
+	return merged
+}
+
+// Complement returns periods of [start,end) which are not covered by merged intervals.
+func Complement(merged []Interval, start, end time.Time) []Interval {
+	free := []Interval{}
+	cursor := start
+	for _, in := range merged {
+		if !in.End.After(cursor) {
+			continue
+		}
+		if !in.Start.Before(end) {
+			break
+		}
+		if in.Start.After(cursor) {
+			free = append(free, Interval{Start: cursor, End: in.Start})
+		}
+		cursor = in.End
+	}
+	if cursor.Before(end) {
+		free = append(free, Interval{Start: cursor, End: end})
+	}
+	return free
+}
+
+// FreeSlots returns at most count earliest slots of duration in [start,end) which don't intersect busy intervals.
+// Slots follow one another inside of every free period.
+func FreeSlots(busy []Interval, start, end time.Time, duration time.Duration, count int) []Interval {
+	slots := []Interval{}
+	if duration <= 0 {
+		return slots
+	}
+	for _, free := range Complement(MergeIntervals(busy), start, end) {
+		for s := free.Start; !s.Add(duration).After(free.End); s = s.Add(duration) {
+			if len(slots) == count {
+				return slots
+			}
+			slots = append(slots, Interval{Start: s, End: s.Add(duration)})
+		}
+	}
+	return slots
+}
+
+// In returns interval rendered in the location.
+func (i Interval) In(loc *time.Location) Interval {
+	return Interval{Start: i.Start.In(loc), End: i.End.In(loc)}
+}
+
+func clip(i Interval, start, end time.Time) Interval {
+	if i.Start.Before(start) {
+		i.Start = start
+	}
+	if i.End.After(end) {
+		i.End = end
+	}
+	return i
+}
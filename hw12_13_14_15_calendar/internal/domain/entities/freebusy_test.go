@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func interval(t *testing.T, start, end string) Interval {
+	return Interval{Start: mustParse(t, start), End: mustParse(t, end)}
+}
+
+func TestFreeBusy(t *testing.T) {
+	t.Run("merge intervals", func(t *testing.T) {
+		merged := MergeIntervals([]Interval{
+			interval(t, "2020-07-06 12:00:00", "2020-07-06 13:00:00"),
+			interval(t, "2020-07-06 10:00:00", "2020-07-06 11:00:00"),
+			interval(t, "2020-07-06 10:30:00", "2020-07-06 11:30:00"),
+			interval(t, "2020-07-06 11:30:00", "2020-07-06 11:45:00"),
+		})
+
+		require.Equal(t, []Interval{
+			interval(t, "2020-07-06 10:00:00", "2020-07-06 11:45:00"),
+			interval(t, "2020-07-06 12:00:00", "2020-07-06 13:00:00"),
+		}, merged)
+	})
+	t.Run("busy intervals of recurring and long events", func(t *testing.T) {
+		daily, err := NewEvent("daily", "2020-07-01 09:30:00", "30m", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, daily.SetRecurrence("FREQ=DAILY", ""))
+		long, err := NewEvent("long", "2020-07-05 20:00:00", "15h", "text", "user", "")
+		require.Nil(t, err)
+		reminder, err := NewEvent("reminder", "2020-07-06 12:00:00", "0s", "text", "user", "")
+		require.Nil(t, err)
+
+		busy := BusyIntervals([]*Event{daily, long, reminder}, mustParse(t, "2020-07-06 08:00:00"), mustParse(t, "2020-07-07 10:00:00"))
+
+		require.Equal(t, []Interval{
+			interval(t, "2020-07-06 08:00:00", "2020-07-06 11:00:00"),
+			interval(t, "2020-07-07 09:30:00", "2020-07-07 10:00:00"),
+		}, busy)
+	})
+	t.Run("free slots", func(t *testing.T) {
+		busy := []Interval{
+			interval(t, "2020-07-06 09:00:00", "2020-07-06 10:15:00"),
+			interval(t, "2020-07-06 11:00:00", "2020-07-06 12:00:00"),
+		}
+
+		slots := FreeSlots(busy, mustParse(t, "2020-07-06 09:00:00"), mustParse(t, "2020-07-06 18:00:00"), 30*time.Minute, 3)
+
+		require.Equal(t, []Interval{
+			interval(t, "2020-07-06 10:15:00", "2020-07-06 10:45:00"),
+			interval(t, "2020-07-06 12:00:00", "2020-07-06 12:30:00"),
+			interval(t, "2020-07-06 12:30:00", "2020-07-06 13:00:00"),
+		}, slots)
+	})
+	t.Run("working hours across DST", func(t *testing.T) {
+		// DST in Europe/Berlin ends on 2020-10-25, it is sunday.
+		wh, err := ParseWorkingHours("09:00-18:00", "Europe/Berlin")
+		require.Nil(t, err)
+
+		off := wh.OffHours(mustParse(t, "2020-10-24 00:00:00"), mustParse(t, "2020-10-26 00:00:00"))
+		for i := range off {
+			off[i] = off[i].In(time.UTC)
+		}
+
+		require.Equal(t, []Interval{
+			interval(t, "2020-10-24 00:00:00", "2020-10-24 07:00:00"),
+			interval(t, "2020-10-24 16:00:00", "2020-10-25 08:00:00"),
+			interval(t, "2020-10-25 17:00:00", "2020-10-26 00:00:00"),
+		}, off)
+	})
+	t.Run("bad working hours", func(t *testing.T) {
+		for _, hours := range []string{"9-18", "18:00-09:00", "09:00"} {
+			_, err := ParseWorkingHours(hours, "")
+			require.Truef(t, errors.Is(err, ErrWorkingHoursFormat), "hours %v must be invalid", hours)
+		}
+		wh, err := ParseWorkingHours("", "")
+		require.Nil(t, err)
+		require.Nil(t, wh)
+	})
+}
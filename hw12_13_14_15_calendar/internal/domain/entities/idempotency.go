@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// MaxIdempotencyKeyLen limits length of idempotency key.
+const MaxIdempotencyKeyLen = 255
+
+// deliveryKeyPrefix is namespace of records of delivered messages, idempotency keys of requests can't start with it.
+const deliveryKeyPrefix = "delivery:"
+
+// IdempotencyRepo keeps records of idempotency keys, sender uses it to skip duplicates of delivered messages too
+// keeping them under DeliveryKey.
+type IdempotencyRepo interface {
+	// ReserveIdempotency saves record if the user has no record of its key which isn't expired at now, otherwise it
+	// returns the existing record and doesn't save the new one. Saved record is returned as nil.
+	ReserveIdempotency(ctx context.Context, record Idempotency, now time.Time) (existing *Idempotency, err error)
+	// CompleteIdempotency saves response of operation to reserved record and keeps the record until expiresAt.
+	CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) error
+	// RenewIdempotency keeps reserved record of operation in progress until expiresAt, it returns ErrIdempotencyMissed
+	// if the key has no record in progress.
+	RenewIdempotency(ctx context.Context, userID, key string, expiresAt time.Time) error
+	// ReleaseIdempotency removes record of the key, so the key can be used again after failed operation.
+	ReleaseIdempotency(ctx context.Context, userID, key string) error
+}
+
+// Idempotency is record of request with idempotency key, replay of the key returns response of the first request.
+type Idempotency struct {
+	UserID string
+	Key    string
+	// Fingerprint is hash of operation and its parameters, the key can't be reused for another request.
+	Fingerprint string
+	// Response is result of operation, empty response means that operation is in progress.
+	Response  string
+	ExpiresAt time.Time
+}
+
+// InProgress reports that operation of the key isn't finished yet.
+func (i *Idempotency) InProgress() bool {
+	return i.Response == ""
+}
+
+// Fingerprint returns hash of operation with its parameters.
+func Fingerprint(operation string, params ...string) string {
+	sum := sha256.Sum256([]byte(operation + "\x00" + strings.Join(params, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeliveryKey returns key of record of delivered message, it never matches idempotency key of request.
+func DeliveryKey(messageID string) string {
+	return deliveryKeyPrefix + messageID
+}
+
+// ValidateIdempotencyKey checks length and namespace of the key, empty key means request without idempotency.
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLen || strings.HasPrefix(key, deliveryKeyPrefix) {
+		return ErrIdempotencyKey
+	}
+	return nil
+}
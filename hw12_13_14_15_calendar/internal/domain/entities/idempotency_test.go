@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	require.Nil(t, ValidateIdempotencyKey(""))
+	require.Nil(t, ValidateIdempotencyKey("outbox-1-user"))
+	require.True(t, errors.Is(ValidateIdempotencyKey(strings.Repeat("k", MaxIdempotencyKeyLen+1)), ErrIdempotencyKey))
+	require.True(t, errors.Is(ValidateIdempotencyKey(DeliveryKey("outbox-1-user")), ErrIdempotencyKey),
+		"keys of delivered messages can't be used by requests")
+}
@@ -0,0 +1,160 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SortOrder is order of listed events, minus prefix means descending order, ties are broken by event ID.
+type SortOrder string
+
+const (
+	SortDateTime     SortOrder = "datetime"
+	SortDateTimeDesc SortOrder = "-datetime"
+	SortTitle        SortOrder = "title"
+	SortTitleDesc    SortOrder = "-title"
+
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ParseSortOrder returns sort order by its name, empty name is SortDateTime.
+func ParseSortOrder(sort string) (SortOrder, error) {
+	switch s := SortOrder(sort); s {
+	case "":
+		return SortDateTime, nil
+	case SortDateTime, SortDateTimeDesc, SortTitle, SortTitleDesc:
+		return s, nil
+	}
+	return "", errors.Wrapf(ErrSortOrder, "error parse sort: %v", sort)
+}
+
+// Descending reports whether events are listed from the greatest key.
+func (s SortOrder) Descending() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// ByTitle reports whether events are ordered by title instead of date.
+func (s SortOrder) ByTitle() bool {
+	return s == SortTitle || s == SortTitleDesc
+}
+
+// Cursor is position of the last event of the page, the next page starts after it.
+type Cursor struct {
+	Sort     SortOrder `json:"s"`
+	DateTime time.Time `json:"d,omitempty"`
+	Title    string    `json:"t,omitempty"`
+	ID       string    `json:"i"`
+}
+
+// Encode returns cursor as opaque string for clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses opaque cursor, cursor of another sort order can't continue listing.
+func DecodeCursor(cursor string, sort SortOrder) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.Wrap(ErrCursor, err.Error())
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(ErrCursor, err.Error())
+	}
+	if c.Sort != sort || c.ID == "" {
+		return nil, errors.Wrapf(ErrCursor, "cursor is made for sort %v", c.Sort)
+	}
+	return c, nil
+}
+
+// ListQuery selects page of user events which period overlaps [Start,End), zero time means unbounded period.
+// Recurring event started before End is listed once as the whole series.
+type ListQuery struct {
+	UserID string
+	Start  time.Time
+	End    time.Time
+	// Search is case insensitive substring of event title or text.
+	Search string
+	Sort   SortOrder
+	Limit  int
+	After  *Cursor
+}
+
+// Matches reports whether event passes period and search filters of query.
+func (q ListQuery) Matches(e Event) bool {
+	if !q.End.IsZero() && !e.DateTime.Before(q.End) {
+		return false
+	}
+	if !q.Start.IsZero() && !e.IsRecurring() && e.DateTime.Before(q.Start) && !e.End().After(q.Start) {
+		return false
+	}
+	if q.Search == "" {
+		return true
+	}
+	search := strings.ToLower(q.Search)
+	return strings.Contains(strings.ToLower(e.Title), search) || strings.Contains(strings.ToLower(e.Text), search)
+}
+
+// Less reports whether event a is listed before event b.
+func (q ListQuery) Less(a, b Event) bool {
+	ka, kb := q.cursorOf(a), q.cursorOf(b)
+	return q.less(ka, kb)
+}
+
+// IsAfterCursor reports whether event is listed after the cursor of query, every event is after nil cursor.
+func (q ListQuery) IsAfterCursor(e Event) bool {
+	return q.After == nil || q.less(*q.After, q.cursorOf(e))
+}
+
+func (q ListQuery) less(a, b Cursor) bool {
+	var cmp int
+	if q.Sort.ByTitle() {
+		cmp = strings.Compare(a.Title, b.Title)
+	} else {
+		switch {
+		case a.DateTime.Before(b.DateTime):
+			cmp = -1
+		case a.DateTime.After(b.DateTime):
+			cmp = 1
+		}
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.ID, b.ID)
+	}
+	if q.Sort.Descending() {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+func (q ListQuery) cursorOf(e Event) Cursor {
+	c := Cursor{Sort: q.Sort, ID: e.ID}
+	if q.Sort.ByTitle() {
+		c.Title = e.Title
+	} else {
+		c.DateTime = e.DateTime.UTC()
+	}
+	return c
+}
+
+// EventPage is page of listed events, empty Next means the last page.
+type EventPage struct {
+	Events []*Event
+	Next   string
+}
+
+// NewEventPage makes page of events sorted by query, repositories fetch Limit+1 events to know whether the next page exists.
+func NewEventPage(events []*Event, query ListQuery) *EventPage {
+	page := &EventPage{Events: events}
+	if len(events) > query.Limit {
+		page.Events = events[:query.Limit]
+		page.Next = query.cursorOf(*page.Events[len(page.Events)-1]).Encode()
+	}
+	return page
+}
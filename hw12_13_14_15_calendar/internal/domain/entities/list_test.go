@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListQuery(t *testing.T) {
+	dt := time.Date(2020, 7, 7, 8, 0, 0, 0, time.UTC)
+	a := Event{ID: "a", Title: "Beta", Text: "standup", DateTime: dt, Duration: time.Hour}
+	b := Event{ID: "b", Title: "alpha", Text: "review", DateTime: dt, Duration: time.Hour}
+	c := Event{ID: "c", Title: "Alpha", Text: "retro", DateTime: dt.Add(time.Hour)}
+
+	t.Run("sort with ties by id", func(t *testing.T) {
+		q := ListQuery{Sort: SortDateTime}
+		require.True(t, q.Less(a, b))
+		require.True(t, q.Less(b, c))
+		q.Sort = SortDateTimeDesc
+		require.True(t, q.Less(c, b))
+		require.True(t, q.Less(b, a))
+		q.Sort = SortTitle
+		require.True(t, q.Less(c, a), "titles are compared bytewise")
+		require.True(t, q.Less(a, b))
+	})
+	t.Run("filters", func(t *testing.T) {
+		q := ListQuery{Start: dt.Add(30 * time.Minute), End: dt.Add(2 * time.Hour), Search: "RE"}
+		require.False(t, q.Matches(a), "text doesn't contain search")
+		require.True(t, q.Matches(b), "event is in progress at start")
+		require.True(t, q.Matches(c))
+		q.End = dt.Add(time.Hour)
+		require.False(t, q.Matches(c), "event starts at end")
+		require.True(t, ListQuery{}.Matches(a), "zero bounds are unbounded")
+	})
+	t.Run("page and cursor", func(t *testing.T) {
+		q := ListQuery{Sort: SortDateTime, Limit: 2}
+		page := NewEventPage([]*Event{&a, &b, &c}, q)
+		require.Len(t, page.Events, 2)
+		require.NotEmpty(t, page.Next)
+
+		cursor, err := DecodeCursor(page.Next, SortDateTime)
+		require.Nil(t, err)
+		q.After = cursor
+		require.False(t, q.IsAfterCursor(a))
+		require.False(t, q.IsAfterCursor(b))
+		require.True(t, q.IsAfterCursor(c))
+
+		require.Empty(t, NewEventPage([]*Event{&c}, q).Next, "the last page has no cursor")
+
+		_, err = DecodeCursor(page.Next, SortTitle)
+		require.True(t, errors.Is(err, ErrCursor))
+		_, err = DecodeCursor("not a cursor", SortDateTime)
+		require.True(t, errors.Is(err, ErrCursor))
+	})
+	t.Run("parse sort", func(t *testing.T) {
+		sort, err := ParseSortOrder("")
+		require.Nil(t, err)
+		require.Equal(t, SortDateTime, sort)
+		_, err = ParseSortOrder("text")
+		require.True(t, errors.Is(err, ErrSortOrder))
+	})
+}
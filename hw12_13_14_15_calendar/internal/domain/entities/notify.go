@@ -5,14 +5,53 @@ import (
 	"time"
 )
 
+// NotifyClaimTTL is the time after which occurrence claimed for notifying but not marked notified is available again.
+const NotifyClaimTTL = 5 * time.Minute
+
+// NotifyHandler processes pulled notify. Queue must acknowledge the notify only when handler returns nil.
+type NotifyHandler func(ctx context.Context, n Notify) error
+
 type NotifyQueue interface {
-	Pull(ctx context.Context, alert chan<- Notify) error
-	Push(Notify) error
+	Pull(ctx context.Context, handle NotifyHandler) error
+	Push(ctx context.Context, n Notify) error
 }
 
+// DeadLetter is notify which consumer failed to handle, it is kept aside from the queue for inspection and replay.
+type DeadLetter struct {
+	Notify Notify
+	// Body is raw message, Broken reports that it can't be decoded to Notify.
+	Body   string
+	Broken bool
+	// Redeliveries is count of returns of message to the queue before it was dead-lettered.
+	Redeliveries int
+}
+
+// DeadLetterQueue gives access to notifies dead-lettered by NotifyQueue.
+type DeadLetterQueue interface {
+	// DeadLetters returns up to limit dead letters, limit <= 0 means all of them.
+	DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error)
+	// Replay returns up to limit dead letters to the queue, limit <= 0 means all of them.
+	Replay(ctx context.Context, limit int) (replayed int, err error)
+}
+
+// NotifyKind is kind of notify, reminder is the default kind, other kinds are messages about event lifecycle.
+type NotifyKind string
+
+const (
+	NotifyReminder    NotifyKind = ""
+	NotifyCreated     NotifyKind = "created"
+	NotifyRescheduled NotifyKind = "rescheduled"
+	NotifyCancelled   NotifyKind = "cancelled"
+)
+
 type Notify struct {
 	ID       string
 	Title    string
 	UserID   string
 	DateTime time.Time
+	Kind     NotifyKind `json:",omitempty"`
+	// Channel is channel of reminder, lifecycle messages are delivered by default channel.
+	Channel Channel `json:",omitempty"`
+	// MessageID identifies message, consumer skips message with already handled id, as it may be delivered twice.
+	MessageID string `json:",omitempty"`
 }
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// OutboxMessage is lifecycle message of event written in the same transaction as the event change,
+// relay publishes it to notify queue later.
+type OutboxMessage struct {
+	// ID keeps order of messages in outbox, MessageID is globally unique id which deliveries of the message are deduplicated by.
+	ID        int64
+	MessageID string
+	Kind      NotifyKind
+	EventID   string
+	Title     string
+	DateTime  time.Time
+	// Recipients are owner and accepted attendees of the event at the moment of change.
+	Recipients []string
+}
+
+// OutboxPublisher publishes outbox message, message is kept in outbox if it returns error.
+type OutboxPublisher func(m OutboxMessage) error
+
+// NewOutboxMessage returns lifecycle message about the event change, it returns false if the change is not worth a message:
+// update which keeps the schedule of event or cancelling of already ended event.
+func NewOutboxMessage(kind NotifyKind, old *Event, event Event, now time.Time) (OutboxMessage, bool) {
+	switch kind {
+	case NotifyRescheduled:
+		if old != nil && !old.Reschedules(event) {
+			return OutboxMessage{}, false
+		}
+	case NotifyCancelled:
+		if event.EndsBefore(now) {
+			return OutboxMessage{}, false
+		}
+	}
+	return OutboxMessage{
+		Kind:       kind,
+		EventID:    event.ID,
+		Title:      event.Title,
+		DateTime:   event.DateTime,
+		Recipients: event.Recipients(),
+	}, true
+}
+
+// Reschedules reports whether updated event happens at other time than e.
+func (e Event) Reschedules(updated Event) bool {
+	return !e.DateTime.Equal(updated.DateTime) || e.Duration != updated.Duration || FormatReminders(e.Reminders) != FormatReminders(updated.Reminders) ||
+		e.Recurrence.RRule() != updated.Recurrence.RRule() || e.ExDate() != updated.ExDate() || e.Location().String() != updated.Location().String()
+}
+
+// Notifies returns notify of the message for every recipient, message id of the notify is the same for every delivery
+// of the message, even if it is relayed again after restart.
+func (m OutboxMessage) Notifies() []Notify {
+	notifies := make([]Notify, 0, len(m.Recipients))
+	for _, userID := range m.Recipients {
+		notifies = append(notifies, Notify{
+			ID:        m.EventID,
+			Title:     m.Title,
+			UserID:    userID,
+			DateTime:  m.DateTime,
+			Kind:      m.Kind,
+			MessageID: fmt.Sprintf("outbox-%v-%v", m.MessageID, userID),
+		})
+	}
+	return notifies
+}
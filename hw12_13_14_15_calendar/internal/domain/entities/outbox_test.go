@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxMessage(t *testing.T) {
+	now := time.Date(2020, 6, 25, 12, 0, 0, 0, time.UTC)
+	event := Event{ID: "e", Title: "title", DateTime: now.Add(time.Hour), Duration: time.Hour, UserID: "owner",
+		Attendees: []Attendee{{UserID: "guest", Status: Accepted}, {UserID: "invited", Status: NeedsAction}}}
+
+	m, ok := NewOutboxMessage(NotifyCreated, nil, event, now)
+	require.True(t, ok)
+	require.Equal(t, []string{"owner", "guest"}, m.Recipients)
+
+	renamed := event
+	renamed.Title = "renamed"
+	_, ok = NewOutboxMessage(NotifyRescheduled, &event, renamed, now)
+	require.False(t, ok, "schedule isn't changed")
+	moved := event
+	moved.Duration = 2 * time.Hour
+	_, ok = NewOutboxMessage(NotifyRescheduled, &event, moved, now)
+	require.True(t, ok)
+
+	_, ok = NewOutboxMessage(NotifyCancelled, nil, event, now.Add(2*time.Hour))
+	require.False(t, ok, "ended event isn't cancelled")
+
+	m.MessageID = "7"
+	require.Equal(t, []Notify{
+		{ID: "e", Title: "title", UserID: "owner", DateTime: event.DateTime, Kind: NotifyCreated, MessageID: "outbox-7-owner"},
+		{ID: "e", Title: "title", UserID: "guest", DateTime: event.DateTime, Kind: NotifyCreated, MessageID: "outbox-7-guest"},
+	}, m.Notifies())
+}
@@ -0,0 +1,422 @@
+package entities
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	LayoutRRuleUntil     = "20060102T150405Z"
+	LayoutRRuleUntilDate = "20060102"
+	// maxRecurrencePeriods protect us from endless expanding of broken rules.
+	maxRecurrencePeriods = 100000
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is BYDAY rule part, Ordinal is 0 for every weekday in period or +n/-n for n-th weekday from start/end of period.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	day := strings.ToUpper(w.Weekday.String()[:2])
+	if w.Ordinal == 0 {
+		return day
+	}
+	return strconv.Itoa(w.Ordinal) + day
+}
+
+// Recurrence is RFC 5545 RRULE subset (FREQ,INTERVAL,BYDAY,COUNT,UNTIL) with EXDATE exceptions.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+	ExDates  []time.Time
+}
+
+// ParseRecurrence parses rrule like "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10" and exdate like "2020-07-14 12:12:12,2020-07-21 12:12:12" in UTC.
+// It returns nil recurrence for empty rrule.
+func ParseRecurrence(rrule, exdate string) (*Recurrence, error) {
+	return ParseRecurrenceInLocation(rrule, exdate, time.UTC)
+}
+
+// ParseRecurrenceInLocation is ParseRecurrence with exdate in the location.
+func ParseRecurrenceInLocation(rrule, exdate string, loc *time.Location) (*Recurrence, error) {
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	if rrule == "" {
+		if strings.TrimSpace(exdate) != "" {
+			return nil, errors.Wrap(ErrExDateFormat, "exdate can't be set without rrule")
+		}
+		return nil, nil
+	}
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rrule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Wrapf(ErrRRuleFormat, "error parse rule part: %v", part)
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(kv[0])), strings.ToUpper(strings.TrimSpace(kv[1]))
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return nil, errors.Wrapf(ErrRRuleFormat, "unsupported frequency: %v", value)
+			}
+		case "INTERVAL":
+			i, err := strconv.Atoi(value)
+			if err != nil || i < 1 {
+				return nil, errors.Wrapf(ErrRRuleFormat, "error parse interval: %v", value)
+			}
+			r.Interval = i
+		case "COUNT":
+			c, err := strconv.Atoi(value)
+			if err != nil || c < 1 {
+				return nil, errors.Wrapf(ErrRRuleFormat, "error parse count: %v", value)
+			}
+			r.Count = c
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wn, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wn)
+			}
+		default:
+			return nil, errors.Wrapf(ErrRRuleFormat, "unsupported rule part: %v", key)
+		}
+	}
+	if r.Freq == "" {
+		return nil, errors.Wrap(ErrRRuleFormat, "FREQ is necessary")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.Wrap(ErrRRuleFormat, "COUNT and UNTIL can't be used together")
+	}
+	for _, wn := range r.ByDay {
+		if wn.Ordinal != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, errors.Wrapf(ErrRRuleFormat, "numeric BYDAY %v is allowed only for MONTHLY and YEARLY rules", wn)
+		}
+	}
+
+	if strings.TrimSpace(exdate) != "" {
+		for _, d := range strings.Split(exdate, ",") {
+			ex, err := time.ParseInLocation(LayoutISO, strings.TrimSpace(d), loc)
+			if err != nil {
+				return nil, errors.Wrapf(ErrExDateFormat, "error parse exdate: %v", d)
+			}
+			r.ExDates = append(r.ExDates, ex.UTC())
+		}
+	}
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse(LayoutRRuleUntil, value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse(strings.TrimSuffix(LayoutRRuleUntil, "Z"), value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse(LayoutRRuleUntilDate, value); err == nil {
+		//date form of UNTIL includes the whole day
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, errors.Wrapf(ErrRRuleFormat, "error parse until: %v", value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return WeekdayNum{}, errors.Wrapf(ErrRRuleFormat, "error parse weekday: %v", value)
+	}
+	wd, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, errors.Wrapf(ErrRRuleFormat, "error parse weekday: %v", value)
+	}
+	wn := WeekdayNum{Weekday: wd}
+	if ord := value[:len(value)-2]; ord != "" {
+		n, err := strconv.Atoi(ord)
+		if err != nil || n == 0 || n > 53 || n < -53 {
+			return WeekdayNum{}, errors.Wrapf(ErrRRuleFormat, "error parse weekday ordinal: %v", value)
+		}
+		wn.Ordinal = n
+	}
+	return wn, nil
+}
+
+// RRule returns recurrence rule in RFC 5545 format.
+func (r *Recurrence) RRule() string {
+	if r == nil {
+		return ""
+	}
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wn := range r.ByDay {
+			days = append(days, wn.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(LayoutRRuleUntil))
+	}
+	return strings.Join(parts, ";")
+}
+
+// ExDate returns recurrence exceptions in the same format that ParseRecurrence accepts.
+func (r *Recurrence) ExDate() string {
+	return r.ExDateInLocation(time.UTC)
+}
+
+// ExDateInLocation returns recurrence exceptions in the location.
+func (r *Recurrence) ExDateInLocation(loc *time.Location) string {
+	if r == nil {
+		return ""
+	}
+	dates := make([]string, 0, len(r.ExDates))
+	for _, ex := range r.ExDates {
+		dates = append(dates, ex.In(loc).Format(LayoutISO))
+	}
+	return strings.Join(dates, ",")
+}
+
+func (r *Recurrence) Copy() *Recurrence {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	if r.ByDay != nil {
+		c.ByDay = append([]WeekdayNum{}, r.ByDay...)
+	}
+	if r.ExDates != nil {
+		c.ExDates = append([]time.Time{}, r.ExDates...)
+	}
+	return &c
+}
+
+// Between returns start times of all occurrences of series started at dtStart in [start,end).
+func (r *Recurrence) Between(dtStart, start, end time.Time) []time.Time {
+	var occurrences []time.Time
+	r.iterate(dtStart, start, func(occurrence time.Time) bool {
+		if !occurrence.Before(end) {
+			return false
+		}
+		if !occurrence.Before(start) && !r.isExcluded(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Last returns start time of the last occurrence of series started at dtStart, ok is false for endless series.
+func (r *Recurrence) Last(dtStart time.Time) (last time.Time, ok bool) {
+	if r.Count == 0 && r.Until.IsZero() {
+		return time.Time{}, false
+	}
+	r.iterate(dtStart, dtStart, func(occurrence time.Time) bool {
+		last = occurrence
+		return true
+	})
+	return last, true
+}
+
+func (r *Recurrence) isExcluded(occurrence time.Time) bool {
+	for _, ex := range r.ExDates {
+		if ex.Equal(occurrence) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterate calls next for every occurrence in chronological order while next returns true and series is not finished.
+// Periods before from are skipped when series has no COUNT, so next may be called for some occurrences before from.
+// EXDATE is not applied here, because excluded occurrences are still counted by COUNT.
+func (r *Recurrence) iterate(dtStart, from time.Time, next func(time.Time) bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	first := 0
+	if r.Count == 0 {
+		first = r.periodsBefore(dtStart, from) / interval
+	}
+	count := 0
+	for period := first; period < first+maxRecurrencePeriods; period++ {
+		for _, occurrence := range r.periodOccurrences(dtStart, period*interval) {
+			if occurrence.Before(dtStart) {
+				continue
+			}
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return
+			}
+			count++
+			if !next(occurrence) {
+				return
+			}
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// periodsBefore returns number of whole frequency units between periods of dtStart and from minus one,
+// so no occurrence at or after from is in the skipped periods.
+func (r *Recurrence) periodsBefore(dtStart, from time.Time) int {
+	from = from.In(dtStart.Location())
+	if !from.After(dtStart) {
+		return 0
+	}
+	sy, sm, sd := dtStart.Date()
+	fy, fm, fd := from.Date()
+	days := func(y int, m time.Month, d int) int {
+		return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+	}
+	var units int
+	switch r.Freq {
+	case Daily:
+		units = days(fy, fm, fd) - days(sy, sm, sd)
+	case Weekly:
+		//weeks start from monday (WKST=MO)
+		units = (days(fy, fm, fd-(int(from.Weekday())+6)%7) - days(sy, sm, sd-(int(dtStart.Weekday())+6)%7)) / 7
+	case Monthly:
+		units = (fy-sy)*12 + int(fm-sm)
+	case Yearly:
+		units = fy - sy
+	}
+	if units < 1 {
+		return 0
+	}
+	return units - 1
+}
+
+// periodOccurrences returns sorted candidates of the period shifted from the dtStart period by offset units of frequency.
+func (r *Recurrence) periodOccurrences(dtStart time.Time, offset int) []time.Time {
+	y, m, d := dtStart.Date()
+	h, min, s := dtStart.Clock()
+	loc := dtStart.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, h, min, s, dtStart.Nanosecond(), loc)
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+offset)
+		if len(r.ByDay) == 0 || r.hasWeekday(day.Weekday()) {
+			candidates = append(candidates, day)
+		}
+	case Weekly:
+		//weeks start from monday (WKST=MO)
+		weekStart := at(y, m, d-(int(dtStart.Weekday())+6)%7+offset*7)
+		if len(r.ByDay) == 0 {
+			candidates = append(candidates, at(y, m, d+offset*7))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.hasWeekday(day.Weekday()) {
+				candidates = append(candidates, day)
+			}
+		}
+	case Monthly:
+		first := at(y, m+time.Month(offset), 1)
+		if len(r.ByDay) == 0 {
+			//months without such day (eg. 31) are skipped as RFC 5545 requires
+			if day := at(first.Year(), first.Month(), d); day.Month() == first.Month() {
+				candidates = append(candidates, day)
+			}
+			break
+		}
+		candidates = r.byDayInRange(first, first.AddDate(0, 1, 0))
+	case Yearly:
+		if len(r.ByDay) == 0 {
+			if day := at(y+offset, m, d); day.Month() == m {
+				candidates = append(candidates, day)
+			}
+			break
+		}
+		first := at(y+offset, time.January, 1)
+		candidates = r.byDayInRange(first, first.AddDate(1, 0, 0))
+	}
+	return candidates
+}
+
+// byDayInRange returns days in [from,to) matching BYDAY rule part with ordinals.
+func (r *Recurrence) byDayInRange(from, to time.Time) []time.Time {
+	matched := map[time.Time]struct{}{}
+	for _, wn := range r.ByDay {
+		var days []time.Time
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == wn.Weekday {
+				days = append(days, day)
+			}
+		}
+		switch {
+		case wn.Ordinal == 0:
+			for _, day := range days {
+				matched[day] = struct{}{}
+			}
+		case wn.Ordinal > 0 && wn.Ordinal <= len(days):
+			matched[days[wn.Ordinal-1]] = struct{}{}
+		case wn.Ordinal < 0 && -wn.Ordinal <= len(days):
+			matched[days[len(days)+wn.Ordinal]] = struct{}{}
+		}
+	}
+	result := make([]time.Time, 0, len(matched))
+	for day := range matched {
+		result = append(result, day)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+func (r *Recurrence) hasWeekday(wd time.Weekday) bool {
+	for _, wn := range r.ByDay {
+		if wn.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
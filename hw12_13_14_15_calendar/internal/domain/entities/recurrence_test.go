@@ -0,0 +1,167 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, dt string) time.Time {
+	d, err := time.Parse(LayoutISO, dt)
+	require.Nil(t, err)
+	return d
+}
+
+func TestRecurrence(t *testing.T) {
+	// 2020-07-06 is monday.
+	dtStart := "2020-07-06 10:00:00"
+	tCases := []struct {
+		title    string
+		rrule    string
+		exdate   string
+		from, to string
+		expected []string
+	}{
+		{"daily with interval", "FREQ=DAILY;INTERVAL=2", "", "2020-07-06 00:00:00", "2020-07-12 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2020-07-08 10:00:00", "2020-07-10 10:00:00"}},
+		{"weekly by days with count", "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3", "", "2020-07-01 00:00:00", "2020-08-01 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2020-07-08 10:00:00", "2020-07-13 10:00:00"}},
+		{"weekly with exdate", "FREQ=WEEKLY", "2020-07-13 10:00:00", "2020-07-06 00:00:00", "2020-07-27 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2020-07-20 10:00:00"}},
+		{"weekly until", "FREQ=WEEKLY;UNTIL=20200713T100000Z", "", "2020-07-06 00:00:00", "2020-08-01 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2020-07-13 10:00:00"}},
+		{"window in the middle of series", "FREQ=DAILY", "", "2020-08-01 00:00:00", "2020-08-02 00:00:00",
+			[]string{"2020-08-01 10:00:00"}},
+		{"monthly last friday", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2", "", "2020-07-01 00:00:00", "2020-12-01 00:00:00",
+			[]string{"2020-07-31 10:00:00", "2020-08-28 10:00:00"}},
+		{"monthly by month day", "FREQ=MONTHLY;INTERVAL=3", "", "2020-07-01 00:00:00", "2021-02-01 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2020-10-06 10:00:00", "2021-01-06 10:00:00"}},
+		{"yearly", "FREQ=YEARLY;COUNT=2", "", "2020-01-01 00:00:00", "2030-01-01 00:00:00",
+			[]string{"2020-07-06 10:00:00", "2021-07-06 10:00:00"}},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.title, func(t *testing.T) {
+			e, err := NewEvent("title", dtStart, "1h", "text", "user", "")
+			require.Nil(t, err)
+			require.Nil(t, e.SetRecurrence(tCase.rrule, tCase.exdate))
+
+			occurrences := e.Occurrences(mustParse(t, tCase.from), mustParse(t, tCase.to))
+
+			actual := make([]string, 0, len(occurrences))
+			for _, o := range occurrences {
+				actual = append(actual, o.DateTime.Format(LayoutISO))
+			}
+			require.Equal(t, tCase.expected, actual)
+		})
+	}
+	t.Run("periods before window are skipped", func(t *testing.T) {
+		from, to := mustParse(t, "2026-10-01 00:00:00"), mustParse(t, "2026-12-01 00:00:00")
+		for _, rule := range []string{"FREQ=DAILY;INTERVAL=3", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,WE", "FREQ=MONTHLY;INTERVAL=5;BYDAY=-1FR",
+			"FREQ=MONTHLY;INTERVAL=4", "FREQ=YEARLY;INTERVAL=2;BYDAY=44MO"} {
+			r, err := ParseRecurrence(rule, "")
+			require.Nil(t, err)
+			//series with COUNT is expanded from its start
+			counted, err := ParseRecurrence(rule+";COUNT=100000", "")
+			require.Nil(t, err)
+			start := mustParse(t, dtStart)
+
+			require.NotEmpty(t, r.Between(start, from, to), rule)
+			require.Equal(t, counted.Between(start, from, to), r.Between(start, from, to), rule)
+		}
+
+		r, err := ParseRecurrence("FREQ=DAILY", "")
+		require.Nil(t, err)
+		require.Len(t, r.Between(mustParse(t, "1700-01-01 10:00:00"), from, from.AddDate(0, 0, 1)), 1,
+			"series older than maxRecurrencePeriods is expanded")
+	})
+	t.Run("occurrences overlap", func(t *testing.T) {
+		e, err := NewEvent("title", dtStart, "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, e.SetRecurrence("FREQ=DAILY", ""))
+		other, err := NewEvent("title", "2020-07-06 10:30:00", "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.True(t, e.Overlaps(*other))
+		require.True(t, other.Overlaps(*e))
+
+		other.DateTime = other.DateTime.AddDate(0, 0, 1)
+		occurrences := e.Occurrences(mustParse(t, "2020-07-07 00:00:00"), mustParse(t, "2020-07-08 00:00:00"))
+		require.Len(t, occurrences, 1)
+		require.True(t, occurrences[0].Overlaps(*other), "the second occurrence overlaps event")
+		require.True(t, e.Overlaps(*other))
+		require.True(t, other.Overlaps(*e))
+
+		weekly, err := NewEvent("title", "2020-07-07 11:00:00", "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, weekly.SetRecurrence("FREQ=WEEKLY;BYDAY=TU", ""))
+		require.False(t, e.Overlaps(*weekly), "series follow each other")
+		weekly.DateTime = weekly.DateTime.Add(-30 * time.Minute)
+		require.Nil(t, weekly.SetRecurrence("FREQ=WEEKLY;BYDAY=TU", "2020-07-07 10:30:00"))
+		require.True(t, e.Overlaps(*weekly), "series overlap since the second week")
+		require.True(t, weekly.Overlaps(*e))
+
+		other.DateTime = e.DateTime.Add(OverlapHorizon).AddDate(0, 0, 1)
+		require.True(t, e.Overlaps(*other), "horizon starts when the later event starts")
+		require.Nil(t, e.SetRecurrence("FREQ=DAILY;UNTIL=20200710", ""))
+		require.False(t, e.Overlaps(*other), "series is over before event")
+	})
+	t.Run("serialization", func(t *testing.T) {
+		r, err := ParseRecurrence("RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=1MO,-1FR;UNTIL=20201231T000000Z", "2020-08-03 10:00:00")
+		require.Nil(t, err)
+
+		parsed, err := ParseRecurrence(r.RRule(), r.ExDate())
+
+		require.Nil(t, err)
+		require.Equal(t, r, parsed)
+	})
+	t.Run("bad rules", func(t *testing.T) {
+		for _, rule := range []string{"FREQ=HOURLY", "INTERVAL=2", "FREQ=DAILY;COUNT=0", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;COUNT=2;UNTIL=20201231"} {
+			_, err := ParseRecurrence(rule, "")
+			require.Truef(t, errors.Is(err, ErrRRuleFormat), "rule %v must be invalid", rule)
+		}
+		_, err := ParseRecurrence("FREQ=DAILY", "tomorrow")
+		require.True(t, errors.Is(err, ErrExDateFormat))
+	})
+	t.Run("ends before", func(t *testing.T) {
+		e, err := NewEvent("title", dtStart, "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, e.SetRecurrence("FREQ=DAILY;COUNT=3", ""))
+		require.True(t, e.EndsBefore(mustParse(t, "2020-07-09 00:00:00")))
+		require.False(t, e.EndsBefore(mustParse(t, "2020-07-08 00:00:00")))
+
+		require.Nil(t, e.SetRecurrence("FREQ=DAILY", ""))
+		require.False(t, e.EndsBefore(mustParse(t, "2030-07-08 00:00:00")))
+	})
+	t.Run("time zone across DST", func(t *testing.T) {
+		// DST in Europe/Berlin ends on 2020-10-25.
+		e, err := NewEvent("title", "2020-10-19 10:00:00", "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, e.SetTimeZone("Europe/Berlin"))
+		require.Nil(t, e.SetRecurrence("FREQ=WEEKLY", "2020-11-02 10:00:00"))
+		require.Equal(t, mustParse(t, "2020-10-19 08:00:00"), e.DateTime)
+
+		occurrences := e.Occurrences(mustParse(t, "2020-10-19 00:00:00"), mustParse(t, "2020-11-10 00:00:00"))
+
+		actual := make([]string, 0, len(occurrences))
+		for _, o := range occurrences {
+			actual = append(actual, o.DateTime.Format(LayoutISO))
+		}
+		require.Equal(t, []string{"2020-10-19 08:00:00", "2020-10-26 09:00:00", "2020-11-09 09:00:00"}, actual)
+		require.Equal(t, "2020-11-02 10:00:00", e.ExDate())
+		require.Equal(t, "2020-10-26 10:00:00", occurrences[1].In(e.Location()).DateTime.Format(LayoutISO))
+	})
+	t.Run("set time zone", func(t *testing.T) {
+		e, err := NewEvent("title", dtStart, "1h", "text", "user", "")
+		require.Nil(t, err)
+		require.Nil(t, e.SetTimeZone("Asia/Tokyo"))
+		require.Equal(t, "Asia/Tokyo", e.TimeZone)
+		require.Equal(t, mustParse(t, "2020-07-06 01:00:00"), e.DateTime)
+
+		updated, err := e.Update("", "2020-07-07 09:00:00", "", "", "", "", "", "")
+		require.Nil(t, err)
+		require.Equal(t, mustParse(t, "2020-07-07 00:00:00"), updated.DateTime)
+
+		require.True(t, errors.Is(e.SetTimeZone("Mars/Olympus"), ErrTimeZone))
+	})
+}
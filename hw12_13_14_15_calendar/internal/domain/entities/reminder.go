@@ -0,0 +1,130 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Channel is delivery channel of reminder, empty channel is default channel of sender.
+type Channel string
+
+const (
+	ChannelDefault Channel = ""
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+)
+
+// NoReminders is list of reminders which removes all reminders of event.
+const NoReminders = "none"
+
+// Reminder notifies about event Offset before its start by Channel.
+type Reminder struct {
+	Offset  time.Duration
+	Channel Channel
+}
+
+// ParseChannel returns channel by its name, empty name is ChannelDefault.
+func ParseChannel(channel string) (Channel, error) {
+	switch c := Channel(channel); c {
+	case ChannelDefault, ChannelEmail, ChannelWebhook:
+		return c, nil
+	}
+	return "", errors.Wrapf(ErrNotifyFormat, "unknown channel: %v", channel)
+}
+
+// ParseReminder parses reminder in OFFSET[:CHANNEL] format, e.g. 10m:webhook.
+func ParseReminder(reminder string) (Reminder, error) {
+	parts := strings.SplitN(strings.TrimSpace(reminder), ":", 2)
+	offset, err := time.ParseDuration(parts[0])
+	if err != nil || offset < 0 {
+		return Reminder{}, errors.Wrapf(ErrNotifyFormat, "error parse reminder: %v", reminder)
+	}
+	r := Reminder{Offset: offset}
+	if len(parts) == 2 {
+		if r.Channel, err = ParseChannel(parts[1]); err != nil {
+			return Reminder{}, err
+		}
+	}
+	return r, nil
+}
+
+// ParseReminders parses comma separated list of reminders, NoReminders is empty list.
+func ParseReminders(reminders string) ([]Reminder, error) {
+	if reminders == NoReminders {
+		return []Reminder{}, nil
+	}
+	rs := []Reminder{}
+	for _, reminder := range strings.Split(reminders, ",") {
+		r, err := ParseReminder(reminder)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return SortReminders(rs), nil
+}
+
+// SortReminders sorts reminders from the earliest one and removes duplicates.
+func SortReminders(rs []Reminder) []Reminder {
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Offset != rs[j].Offset {
+			return rs[i].Offset > rs[j].Offset
+		}
+		return rs[i].Channel < rs[j].Channel
+	})
+	unique := rs[:0]
+	for _, r := range rs {
+		if len(unique) == 0 || r != unique[len(unique)-1] {
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
+// FormatReminders returns list of reminders in format of ParseReminders.
+func FormatReminders(rs []Reminder) string {
+	if len(rs) == 0 {
+		return NoReminders
+	}
+	formatted := make([]string, 0, len(rs))
+	for _, r := range rs {
+		formatted = append(formatted, r.String())
+	}
+	return strings.Join(formatted, ",")
+}
+
+func (r Reminder) String() string {
+	if r.Channel == ChannelDefault {
+		return r.Offset.String()
+	}
+	return r.Offset.String() + ":" + string(r.Channel)
+}
+
+// DueReminder is reminder of event occurrence which notify time has come.
+type DueReminder struct {
+	Event    *Event
+	Reminder Reminder
+	// ClaimedAt is time of claim which is held by caller of FetchDueUnnotified, it is updated by RenewClaim.
+	ClaimedAt time.Time
+}
+
+// MessageID returns id of alert to the user, it is the same for every push of the reminder, so duplicates are skipped.
+func (d DueReminder) MessageID(userID string) string {
+	return fmt.Sprintf("reminder-%v-%v-%v-%v", d.Event.ID, d.Event.DateTime.Unix(), d.Reminder, userID)
+}
+
+// NotifyTime returns time when the reminder must fire.
+func (d DueReminder) NotifyTime() time.Time {
+	return d.Event.NotifyTime(d.Reminder)
+}
+
+// SortDueReminders sorts reminders by notify time, reminders of the same time keep their order.
+func SortDueReminders(due []*DueReminder) {
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NotifyTime().Before(due[j].NotifyTime())
+	})
+}
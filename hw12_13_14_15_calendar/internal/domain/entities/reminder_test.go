@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminders(t *testing.T) {
+	rs, err := ParseReminders("10m:webhook, 24h:email,10m:webhook,10m")
+	require.Nil(t, err)
+	require.Equal(t, []Reminder{
+		{Offset: 24 * time.Hour, Channel: ChannelEmail},
+		{Offset: 10 * time.Minute},
+		{Offset: 10 * time.Minute, Channel: ChannelWebhook},
+	}, rs, "sorted from the earliest reminder without duplicates")
+	require.Equal(t, "24h0m0s:email,10m0s,10m0s:webhook", FormatReminders(rs))
+
+	rs, err = ParseReminders(NoReminders)
+	require.Nil(t, err)
+	require.Empty(t, rs)
+	require.Equal(t, NoReminders, FormatReminders(rs))
+
+	for _, bad := range []string{"-5m", "10m:pigeon", "soon", "10m,"} {
+		_, err := ParseReminders(bad)
+		require.Truef(t, errors.Is(err, ErrNotifyFormat), "reminders %q must be rejected", bad)
+	}
+}
+
+func TestDueReminders(t *testing.T) {
+	start := time.Date(2020, 6, 25, 12, 0, 0, 0, time.UTC)
+	event, err := NewEvent("title", start.Format(LayoutISO), "1h", "text", "owner", "1h:email,10m:webhook")
+	require.Nil(t, err)
+
+	due := event.DueReminders(start.Add(-2*time.Hour), start.Add(-30*time.Minute))
+	require.Len(t, due, 1)
+	require.Equal(t, Reminder{Offset: time.Hour, Channel: ChannelEmail}, due[0].Reminder)
+	require.Equal(t, start.Add(-time.Hour), event.NotifyTime(due[0].Reminder))
+
+	due = event.DueReminders(start.Add(-2*time.Hour), start)
+	require.Len(t, due, 2)
+
+	updated, err := event.Update("", "", "", "", NoReminders, "", "", "")
+	require.Nil(t, err)
+	require.Empty(t, updated.DueReminders(start.Add(-2*time.Hour), start), "all reminders are removed")
+
+	defaulted, err := NewEvent("title", start.Format(LayoutISO), "1h", "text", "owner", "")
+	require.Nil(t, err)
+	require.Equal(t, []Reminder{{}}, defaulted.Reminders, "event is reminded at its start by default")
+}
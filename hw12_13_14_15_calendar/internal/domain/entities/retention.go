@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetentionMode is what happens to old events.
+type RetentionMode string
+
+const (
+	RetentionDelete  RetentionMode = "delete"
+	RetentionArchive RetentionMode = "archive"
+)
+
+// ParseRetentionMode returns retention mode by its name, empty name is RetentionDelete.
+func ParseRetentionMode(mode string) (RetentionMode, error) {
+	switch m := RetentionMode(mode); m {
+	case "":
+		return RetentionDelete, nil
+	case RetentionDelete, RetentionArchive:
+		return m, nil
+	}
+	return "", errors.Wrapf(ErrRetentionMode, "mode: %v", mode)
+}
+
+// Retention describes how long events are kept after their last occurrence.
+type Retention struct {
+	Mode RetentionMode
+	// Keep is retention period of events without own period.
+	Keep time.Duration
+	// Users and Calendars are own retention periods of events of users and calendars, period of calendar wins.
+	Users     map[string]time.Duration
+	Calendars map[string]time.Duration
+}
+
+// CleanQuery selects events which last occurrence started before Before for DeleteOlderThan.
+type CleanQuery struct {
+	Before time.Time
+	// UserID and CalendarID limit events to events of the user or the calendar if they are set.
+	UserID     string
+	CalendarID string
+	// ExceptUserIDs and ExceptCalendarIDs are users and calendars which events are cleaned by other queries.
+	ExceptUserIDs     []string
+	ExceptCalendarIDs []string
+	Mode              RetentionMode
+	// Batch is maximum number of events removed in one transaction.
+	Batch int
+}
+
+// Queries returns clean queries of retention at time now, every event is selected by one query at most.
+func (r Retention) Queries(now time.Time, batch int) []CleanQuery {
+	calendarIDs := make([]string, 0, len(r.Calendars))
+	queries := []CleanQuery{}
+	for calendarID, keep := range r.Calendars {
+		calendarIDs = append(calendarIDs, calendarID)
+		queries = append(queries, CleanQuery{Before: now.Add(-keep), CalendarID: calendarID, Mode: r.Mode, Batch: batch})
+	}
+	userIDs := make([]string, 0, len(r.Users))
+	for userID, keep := range r.Users {
+		userIDs = append(userIDs, userID)
+		queries = append(queries, CleanQuery{Before: now.Add(-keep), UserID: userID, ExceptCalendarIDs: calendarIDs, Mode: r.Mode, Batch: batch})
+	}
+	if r.Keep > 0 {
+		queries = append(queries, CleanQuery{Before: now.Add(-r.Keep), ExceptUserIDs: userIDs, ExceptCalendarIDs: calendarIDs, Mode: r.Mode, Batch: batch})
+	}
+	return queries
+}
+
+// Match reports whether the query selects the event.
+func (q CleanQuery) Match(e Event) bool {
+	if q.UserID != "" && e.UserID != q.UserID || q.CalendarID != "" && e.CalendarID != q.CalendarID {
+		return false
+	}
+	for _, id := range q.ExceptUserIDs {
+		if e.UserID == id {
+			return false
+		}
+	}
+	for _, id := range q.ExceptCalendarIDs {
+		if e.CalendarID == id {
+			return false
+		}
+	}
+	return e.EndsBefore(q.Before)
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionMode(t *testing.T) {
+	mode, err := ParseRetentionMode("")
+	require.Nil(t, err)
+	require.Equal(t, RetentionDelete, mode)
+
+	mode, err = ParseRetentionMode("archive")
+	require.Nil(t, err)
+	require.Equal(t, RetentionArchive, mode)
+
+	_, err = ParseRetentionMode("shred")
+	require.Truef(t, errors.Is(err, ErrRetentionMode), "return error must be: %q", ErrRetentionMode)
+}
+
+func TestRetentionQueries(t *testing.T) {
+	now := time.Date(2020, 6, 25, 12, 0, 0, 0, time.UTC)
+	r := Retention{
+		Mode:      RetentionArchive,
+		Keep:      30 * 24 * time.Hour,
+		Users:     map[string]time.Duration{"vip": 365 * 24 * time.Hour},
+		Calendars: map[string]time.Duration{"work": 24 * time.Hour},
+	}
+	queries := r.Queries(now, 100)
+	require.Len(t, queries, 3)
+
+	event := func(userID, calendarID string, age time.Duration) Event {
+		return Event{DateTime: now.Add(-age), UserID: userID, CalendarID: calendarID}
+	}
+	matched := func(e Event) []int {
+		var ids []int
+		for i, q := range queries {
+			require.Equal(t, RetentionArchive, q.Mode)
+			require.Equal(t, 100, q.Batch)
+			if q.Match(e) {
+				ids = append(ids, i)
+			}
+		}
+		return ids
+	}
+	require.Equal(t, []int{0}, matched(event("vip", "work", 48*time.Hour)), "period of calendar wins")
+	require.Empty(t, matched(event("vip", "", 60*24*time.Hour)), "vip keeps events for a year")
+	require.Equal(t, []int{1}, matched(event("vip", "", 400*24*time.Hour)))
+	require.Equal(t, []int{2}, matched(event("user", "", 60*24*time.Hour)))
+	require.Empty(t, matched(event("user", "", 48*time.Hour)))
+
+	require.Empty(t, Retention{}.Queries(now, 100), "events are kept forever by default")
+}
@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	// TitleWeight and TextWeight are weights of term found in title and found only in text, they are weights of
+	// A and B labels of search column of database repository.
+	TitleWeight = 1.0
+	TextWeight  = 0.4
+)
+
+// SearchHit is event found by search with its relevance, rank is in (0,1].
+type SearchHit struct {
+	Event *Event
+	Rank  float64
+}
+
+// Tokenize splits text into distinct lowercase words of letters and digits in order of their appearance.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// Rank returns relevance of event to search terms, term is found if it is prefix of a word of title or text.
+// Term found in title weighs more than term found only in text, event without any term has zero rank.
+func (e Event) Rank(terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	title, text := Tokenize(e.Title), Tokenize(e.Text)
+	rank := 0.0
+	for _, term := range terms {
+		switch {
+		case hasPrefixedWord(title, term):
+			rank += TitleWeight
+		case hasPrefixedWord(text, term):
+			rank += TextWeight
+		}
+	}
+	return rank / float64(len(terms))
+}
+
+// SortHits orders hits by rank, hits with the same rank are ordered from the latest event.
+func SortHits(hits []*SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Event.DateTime.Equal(b.Event.DateTime) {
+			return a.Event.DateTime.After(b.Event.DateTime)
+		}
+		return a.Event.ID < b.Event.ID
+	})
+}
+
+func hasPrefixedWord(words []string, prefix string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
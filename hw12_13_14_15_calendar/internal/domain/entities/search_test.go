@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Run("tokenize", func(t *testing.T) {
+		require.Equal(t, []string{"retro", "of", "q1", "ретро"}, Tokenize("Retro of Q1: retro, РЕТРО!"))
+		require.Empty(t, Tokenize(" ,.!"))
+	})
+	t.Run("rank", func(t *testing.T) {
+		e := Event{Title: "Sprint retro", Text: "March retrospective of the team"}
+		require.Equal(t, 1.0, e.Rank([]string{"retro"}))
+		require.Equal(t, 0.7, e.Rank([]string{"retro", "march"}))
+		require.Equal(t, 0.4, e.Rank([]string{"team"}))
+		require.Equal(t, 0.5, e.Rank([]string{"retro", "april"}))
+		require.Equal(t, 0.0, e.Rank([]string{"april"}))
+		require.Equal(t, 0.0, e.Rank([]string{"etro"}), "terms are prefixes of words")
+	})
+	t.Run("sort hits", func(t *testing.T) {
+		dt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+		old := &SearchHit{Event: &Event{ID: "a", DateTime: dt}, Rank: 1}
+		recent := &SearchHit{Event: &Event{ID: "b", DateTime: dt.AddDate(0, 1, 0)}, Rank: 1}
+		weak := &SearchHit{Event: &Event{ID: "c", DateTime: dt.AddDate(0, 2, 0)}, Rank: 0.4}
+		hits := []*SearchHit{weak, old, recent}
+		SortHits(hits)
+		require.Equal(t, []*SearchHit{recent, old, weak}, hits)
+	})
+}
@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// InitialVersion is version of added event.
+const InitialVersion int64 = 1
+
+// CheckVersion returns ErrVersionConflict if version isn't version of the event, zero version means any version.
+func (e Event) CheckVersion(version int64) error {
+	if version != 0 && version != e.Version {
+		return errors.Wrapf(ErrVersionConflict, "event %v has version %v, not %v", e.ID, e.Version, version)
+	}
+	return nil
+}
+
+// ParseVersion parses expected version of event, empty version means any version and it is parsed as zero.
+func ParseVersion(version string) (int64, error) {
+	if version == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v < 1 {
+		return 0, ErrVersionFormat
+	}
+	return v, nil
+}
+
+// ParseVersions parses comma separated list of expected versions of event, empty list means any version.
+func ParseVersions(versions string) ([]int64, error) {
+	if versions == "" {
+		return nil, nil
+	}
+	parsed := []int64{}
+	for _, version := range strings.Split(versions, ",") {
+		v, err := ParseVersion(strings.TrimSpace(version))
+		if err != nil || v == 0 {
+			return nil, ErrVersionFormat
+		}
+		parsed = append(parsed, v)
+	}
+	return parsed, nil
+}
+
+// MatchVersion returns version of the event if it is one of expected versions, otherwise it returns ErrVersionConflict.
+// Empty list matches any version and zero version is returned, so it isn't checked by repository.
+func (e Event) MatchVersion(versions []int64) (int64, error) {
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	for _, version := range versions {
+		if version == e.Version {
+			return version, nil
+		}
+	}
+	return 0, errors.Wrapf(ErrVersionConflict, "event %v has version %v, not any of %v", e.ID, e.Version, versions)
+}
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+	version, err := ParseVersion("")
+	require.Nil(t, err)
+	require.Zero(t, version)
+
+	version, err = ParseVersion("3")
+	require.Nil(t, err)
+	require.Equal(t, int64(3), version)
+
+	for _, v := range []string{"0", "-1", "v3"} {
+		_, err = ParseVersion(v)
+		require.Truef(t, errors.Is(err, ErrVersionFormat), "return error must be: %q", ErrVersionFormat)
+	}
+
+	event := Event{ID: "id", Version: 2}
+	require.Nil(t, event.CheckVersion(0), "zero version isn't checked")
+	require.Nil(t, event.CheckVersion(2))
+	err = event.CheckVersion(1)
+	require.Truef(t, errors.Is(err, ErrVersionConflict), "return error must be: %q", ErrVersionConflict)
+}
+
+func TestMatchVersion(t *testing.T) {
+	versions, err := ParseVersions("")
+	require.Nil(t, err)
+	require.Empty(t, versions)
+
+	versions, err = ParseVersions("1, 3")
+	require.Nil(t, err)
+	require.Equal(t, []int64{1, 3}, versions)
+
+	for _, v := range []string{"1,", "0,3", "1,v3"} {
+		_, err = ParseVersions(v)
+		require.Truef(t, errors.Is(err, ErrVersionFormat), "return error must be: %q", ErrVersionFormat)
+	}
+
+	event := Event{ID: "id", Version: 3}
+	version, err := event.MatchVersion(nil)
+	require.Nil(t, err)
+	require.Zero(t, version, "empty list isn't checked")
+	version, err = event.MatchVersion([]int64{1, 3})
+	require.Nil(t, err)
+	require.Equal(t, int64(3), version)
+	_, err = event.MatchVersion([]int64{1, 2})
+	require.Truef(t, errors.Is(err, ErrVersionConflict), "return error must be: %q", ErrVersionConflict)
+}
@@ -0,0 +1,158 @@
+// Package health checks dependencies of calendar, scheduler and sender for probes of orchestrator. Liveness checks
+// fail when the service must be restarted, readiness checks fail when the service can't serve requests for a while.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+)
+
+// Statuses of checks.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const (
+	ErrCheck = "health check %v is failed"
+	ErrStale = "the last heartbeat was %v ago"
+)
+
+// checkTimeout limits duration of every check.
+const checkTimeout = 3 * time.Second
+
+// Checker checks one dependency, nil error means that dependency is healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is function which is Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Heartbeat returns checker which fails when the last beat of loop is older than timeout.
+func Heartbeat(last func() time.Time, timeout time.Duration) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if age := time.Since(last()); age > timeout {
+			return errors.Errorf(ErrStale, age.Round(time.Second))
+		}
+		return nil
+	})
+}
+
+// Report is result of checks, statuses of checks are kept by names. Errors aren't reported, they are logged,
+// because they can contain addresses and credentials of dependencies.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+type Health struct {
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+	logger    usecases.Logger
+}
+
+func NewHealth(logger usecases.Logger) *Health {
+	return &Health{logger: logger}
+}
+
+// AddLiveness adds check which failure means that service is broken, it is checked by readiness too.
+func (h *Health) AddLiveness(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, check{name: name, checker: checker})
+}
+
+// AddReadiness adds check of dependency without which service can't serve requests.
+func (h *Health) AddReadiness(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, check{name: name, checker: checker})
+}
+
+// Live runs liveness checks.
+func (h *Health) Live(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := append([]check(nil), h.liveness...)
+	h.mu.RUnlock()
+	return h.run(ctx, checks)
+}
+
+// Ready runs liveness and readiness checks.
+func (h *Health) Ready(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := append(append([]check(nil), h.liveness...), h.readiness...)
+	h.mu.RUnlock()
+	return h.run(ctx, checks)
+}
+
+// run runs checks concurrently, every check is limited by checkTimeout.
+func (h *Health) run(ctx context.Context, checks []check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(checks))}
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			status := StatusOK
+			if err := c.checker.Check(cctx); err != nil {
+				h.logger.Warn(ctx, errors.Wrapf(err, ErrCheck, c.name).Error())
+				status = StatusFail
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = status
+			if status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+// LiveHandler serves /healthz, it responds 503 if liveness check is failed.
+func (h *Health) LiveHandler() http.Handler {
+	return reportHandler(h.Live)
+}
+
+// ReadyHandler serves /readyz, it responds 503 if any check is failed.
+func (h *Health) ReadyHandler() http.Handler {
+	return reportHandler(h.Ready)
+}
+
+func reportHandler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !report.OK() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	h := NewHealth(mocks.NewMockLogger())
+	lastBeat := time.Now()
+	h.AddLiveness("loop", Heartbeat(func() time.Time { return lastBeat }, time.Minute))
+	var dbErr error
+	h.AddReadiness("postgres", CheckerFunc(func(ctx context.Context) error { return dbErr }))
+
+	require.Equal(t, Report{Status: StatusOK, Checks: map[string]string{"loop": StatusOK}}, h.Live(ctx))
+	require.Equal(t, Report{Status: StatusOK, Checks: map[string]string{"loop": StatusOK, "postgres": StatusOK}}, h.Ready(ctx))
+
+	dbErr = errors.New("password authentication failed for user igor")
+	require.True(t, h.Live(ctx).OK(), "dependency doesn't break liveness")
+	require.Equal(t, Report{Status: StatusFail, Checks: map[string]string{"loop": StatusOK, "postgres": StatusFail}}, h.Ready(ctx))
+
+	lastBeat = time.Now().Add(-2 * time.Minute)
+	require.Equal(t, Report{Status: StatusFail, Checks: map[string]string{"loop": StatusFail}}, h.Live(ctx))
+}
+
+func TestHandlers(t *testing.T) {
+	h := NewHealth(mocks.NewMockLogger())
+	ready := false
+	h.AddReadiness("rabbit", CheckerFunc(func(ctx context.Context) error {
+		if !ready {
+			return errors.New("connection to rabbit is closed")
+		}
+		return nil
+	}))
+
+	tCases := []struct {
+		title        string
+		handler      http.Handler
+		ready        bool
+		expectedCode int
+		expected     Report
+	}{
+		{"live without liveness checks", h.LiveHandler(), false, http.StatusOK, Report{Status: StatusOK}},
+		{"not ready", h.ReadyHandler(), false, http.StatusServiceUnavailable, Report{Status: StatusFail, Checks: map[string]string{"rabbit": StatusFail}}},
+		{"ready", h.ReadyHandler(), true, http.StatusOK, Report{Status: StatusOK, Checks: map[string]string{"rabbit": StatusOK}}},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.title, func(t *testing.T) {
+			ready = tCase.ready
+			rec := httptest.NewRecorder()
+			tCase.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, tCase.expectedCode, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			report := Report{}
+			require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			require.Equal(t, tCase.expected, report)
+			require.NotContains(t, rec.Body.String(), "connection", "errors aren't exposed")
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	h := NewHealth(mocks.NewMockLogger())
+	h.AddReadiness("hanging", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.False(t, h.Ready(ctx).OK())
+}
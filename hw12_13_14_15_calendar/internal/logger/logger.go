@@ -12,6 +12,7 @@ import (
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/controllers/util"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/usecases"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/tracing"
 )
 
 var _ usecases.Logger = (*Logger)(nil)
@@ -72,36 +73,45 @@ func (l *Logger) Error(ctx context.Context, message interface{}, args ...interfa
 
 func (l *Logger) debug(ctx context.Context, message string, args ...interface{}) {
 	if args == nil {
-		l.logger.Info().Str("Request id", util.GetRequestID(ctx)).Msg(message)
+		withContext(ctx, l.logger.Info()).Msg(message)
 	} else {
-		l.logger.Info().Str("Request id", util.GetRequestID(ctx)).Msgf(message, args...)
+		withContext(ctx, l.logger.Info()).Msgf(message, args...)
 	}
 }
 
 func (l *Logger) Info(ctx context.Context, message string, args ...interface{}) {
 	if args == nil {
-		l.logger.Info().Str("Request id", util.GetRequestID(ctx)).Msg(message)
+		withContext(ctx, l.logger.Info()).Msg(message)
 	} else {
-		l.logger.Info().Str("Request id", util.GetRequestID(ctx)).Msgf(message, args...)
+		withContext(ctx, l.logger.Info()).Msgf(message, args...)
 	}
 }
 
 func (l *Logger) Warn(ctx context.Context, message string, args ...interface{}) {
 	if args == nil {
-		l.logger.Warn().Str("Request id", util.GetRequestID(ctx)).Msg(message)
+		withContext(ctx, l.logger.Warn()).Msg(message)
 	} else {
-		l.logger.Warn().Str("Request id", util.GetRequestID(ctx)).Msgf(message, args...)
+		withContext(ctx, l.logger.Warn()).Msgf(message, args...)
 	}
 }
 
 func (l *Logger) error(ctx context.Context, message string, args ...interface{}) {
 	if args == nil {
-		l.logger.Error().Str("Request id", util.GetRequestID(ctx)).Msg(message)
+		withContext(ctx, l.logger.Error()).Msg(message)
 	} else {
-		l.logger.Error().Str("Request id", util.GetRequestID(ctx)).Msgf(message, args...)
+		withContext(ctx, l.logger.Error()).Msgf(message, args...)
 	}
 }
 
+// withContext adds request id and trace ids of span in ctx to log event.
+func withContext(ctx context.Context, e *zerolog.Event) *zerolog.Event {
+	e = e.Str("Request id", util.GetRequestID(ctx))
+	if traceID, spanID := tracing.IDs(ctx); traceID != "" {
+		e = e.Str("trace_id", traceID).Str("span_id", spanID)
+	}
+	return e
+}
+
 type stackTracer interface {
 	StackTrace() errors.StackTrace
 }
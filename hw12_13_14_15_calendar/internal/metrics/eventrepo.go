@@ -0,0 +1,196 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+var _ entities.EventRepo = (*EventRepo)(nil)
+
+// EventRepo observes latency and errors of operations of wrapped repository.
+type EventRepo struct {
+	repo entities.EventRepo
+	name string
+}
+
+// NewEventRepo wraps repository, name is repository type in labels of metrics.
+func NewEventRepo(repo entities.EventRepo, name string) *EventRepo {
+	return &EventRepo{repo: repo, name: name}
+}
+
+func (e *EventRepo) Add(ctx context.Context, event entities.Event) (id string, err error) {
+	defer observe(e.name, "Add", time.Now(), &err)
+	return e.repo.Add(ctx, event)
+}
+
+func (e *EventRepo) GetByID(ctx context.Context, userID, eventID string) (event *entities.Event, err error) {
+	defer observe(e.name, "GetByID", time.Now(), &err)
+	return e.repo.GetByID(ctx, userID, eventID)
+}
+
+func (e *EventRepo) GetByDate(ctx context.Context, userID string, date time.Time) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetByDate", time.Now(), &err)
+	return e.repo.GetByDate(ctx, userID, date)
+}
+
+func (e *EventRepo) GetDueReminders(ctx context.Context, from, to time.Time) (reminders []*entities.DueReminder, err error) {
+	defer observe(e.name, "GetDueReminders", time.Now(), &err)
+	return e.repo.GetDueReminders(ctx, from, to)
+}
+
+func (e *EventRepo) GetForPeriodByUserID(ctx context.Context, userID string, dateStart time.Time, dateEnd time.Time) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetForPeriodByUserID", time.Now(), &err)
+	return e.repo.GetForPeriodByUserID(ctx, userID, dateStart, dateEnd)
+}
+
+func (e *EventRepo) GetForPeriod(ctx context.Context, dateStart time.Time, dateEnd time.Time) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetForPeriod", time.Now(), &err)
+	return e.repo.GetForPeriod(ctx, dateStart, dateEnd)
+}
+
+func (e *EventRepo) GetOverlapping(ctx context.Context, userIDs []string, dateStart time.Time, dateEnd time.Time) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetOverlapping", time.Now(), &err)
+	return e.repo.GetOverlapping(ctx, userIDs, dateStart, dateEnd)
+}
+
+func (e *EventRepo) UpdateByID(ctx context.Context, userID, eventID string, event entities.Event) (err error) {
+	defer observe(e.name, "UpdateByID", time.Now(), &err)
+	return e.repo.UpdateByID(ctx, userID, eventID, event)
+}
+
+func (e *EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string, version int64) (err error) {
+	defer observe(e.name, "DeleteByUserID", time.Now(), &err)
+	return e.repo.DeleteByUserID(ctx, userID, eventID, version)
+}
+
+func (e *EventRepo) DeleteByID(ctx context.Context, eventID string) (err error) {
+	defer observe(e.name, "DeleteByID", time.Now(), &err)
+	return e.repo.DeleteByID(ctx, eventID)
+}
+
+func (e *EventRepo) FetchDueUnnotified(ctx context.Context, from, now time.Time) (reminders []*entities.DueReminder, err error) {
+	defer observe(e.name, "FetchDueUnnotified", time.Now(), &err)
+	return e.repo.FetchDueUnnotified(ctx, from, now)
+}
+
+func (e *EventRepo) RenewClaim(ctx context.Context, d *entities.DueReminder, now time.Time) (renewed bool, err error) {
+	defer observe(e.name, "RenewClaim", time.Now(), &err)
+	return e.repo.RenewClaim(ctx, d, now)
+}
+
+func (e *EventRepo) MarkNotified(ctx context.Context, eventID string, occurrence time.Time, reminder entities.Reminder) (err error) {
+	defer observe(e.name, "MarkNotified", time.Now(), &err)
+	return e.repo.MarkNotified(ctx, eventID, occurrence, reminder)
+}
+
+func (e *EventRepo) GetOldestUnnotified(ctx context.Context, from time.Time) (oldest time.Time, err error) {
+	defer observe(e.name, "GetOldestUnnotified", time.Now(), &err)
+	return e.repo.GetOldestUnnotified(ctx, from)
+}
+
+func (e *EventRepo) GetWatermark(ctx context.Context, name string) (watermark time.Time, err error) {
+	defer observe(e.name, "GetWatermark", time.Now(), &err)
+	return e.repo.GetWatermark(ctx, name)
+}
+
+func (e *EventRepo) SetWatermark(ctx context.Context, name string, watermark time.Time) (err error) {
+	defer observe(e.name, "SetWatermark", time.Now(), &err)
+	return e.repo.SetWatermark(ctx, name, watermark)
+}
+
+func (e *EventRepo) AddAttendees(ctx context.Context, eventID string, userIDs []string) (err error) {
+	defer observe(e.name, "AddAttendees", time.Now(), &err)
+	return e.repo.AddAttendees(ctx, eventID, userIDs)
+}
+
+func (e *EventRepo) SetAttendeeStatus(ctx context.Context, eventID, userID string, status entities.AttendeeStatus) (err error) {
+	defer observe(e.name, "SetAttendeeStatus", time.Now(), &err)
+	return e.repo.SetAttendeeStatus(ctx, eventID, userID, status)
+}
+
+func (e *EventRepo) GetInvitations(ctx context.Context, userID string) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetInvitations", time.Now(), &err)
+	return e.repo.GetInvitations(ctx, userID)
+}
+
+func (e *EventRepo) GetByEventID(ctx context.Context, eventID string) (event *entities.Event, err error) {
+	defer observe(e.name, "GetByEventID", time.Now(), &err)
+	return e.repo.GetByEventID(ctx, eventID)
+}
+
+func (e *EventRepo) GetForPeriodByCalendarID(ctx context.Context, calendarID string, dateStart time.Time, dateEnd time.Time) (events []*entities.Event, err error) {
+	defer observe(e.name, "GetForPeriodByCalendarID", time.Now(), &err)
+	return e.repo.GetForPeriodByCalendarID(ctx, calendarID, dateStart, dateEnd)
+}
+
+func (e *EventRepo) AddCalendar(ctx context.Context, calendar entities.Calendar) (id string, err error) {
+	defer observe(e.name, "AddCalendar", time.Now(), &err)
+	return e.repo.AddCalendar(ctx, calendar)
+}
+
+func (e *EventRepo) GetCalendar(ctx context.Context, calendarID string) (calendar *entities.Calendar, err error) {
+	defer observe(e.name, "GetCalendar", time.Now(), &err)
+	return e.repo.GetCalendar(ctx, calendarID)
+}
+
+func (e *EventRepo) GetCalendarsByUserID(ctx context.Context, userID string) (calendars []*entities.Calendar, err error) {
+	defer observe(e.name, "GetCalendarsByUserID", time.Now(), &err)
+	return e.repo.GetCalendarsByUserID(ctx, userID)
+}
+
+func (e *EventRepo) SetRole(ctx context.Context, calendarID, userID string, role entities.Role) (err error) {
+	defer observe(e.name, "SetRole", time.Now(), &err)
+	return e.repo.SetRole(ctx, calendarID, userID, role)
+}
+
+func (e *EventRepo) DeleteCalendar(ctx context.Context, calendarID string) (err error) {
+	defer observe(e.name, "DeleteCalendar", time.Now(), &err)
+	return e.repo.DeleteCalendar(ctx, calendarID)
+}
+
+func (e *EventRepo) ListEvents(ctx context.Context, query entities.ListQuery) (page *entities.EventPage, err error) {
+	defer observe(e.name, "ListEvents", time.Now(), &err)
+	return e.repo.ListEvents(ctx, query)
+}
+
+func (e *EventRepo) SearchEvents(ctx context.Context, userID string, terms []string, limit int) (hits []*entities.SearchHit, err error) {
+	defer observe(e.name, "SearchEvents", time.Now(), &err)
+	return e.repo.SearchEvents(ctx, userID, terms, limit)
+}
+
+func (e *EventRepo) DeleteOlderThan(ctx context.Context, query entities.CleanQuery) (removed int, err error) {
+	defer observe(e.name, "DeleteOlderThan", time.Now(), &err)
+	return e.repo.DeleteOlderThan(ctx, query)
+}
+
+func (e *EventRepo) RelayOutbox(ctx context.Context, limit int, publish entities.OutboxPublisher) (published int, err error) {
+	defer observe(e.name, "RelayOutbox", time.Now(), &err)
+	return e.repo.RelayOutbox(ctx, limit, publish)
+}
+
+func (e *EventRepo) ReserveIdempotency(ctx context.Context, record entities.Idempotency, now time.Time) (existing *entities.Idempotency, err error) {
+	defer observe(e.name, "ReserveIdempotency", time.Now(), &err)
+	return e.repo.ReserveIdempotency(ctx, record, now)
+}
+
+func (e *EventRepo) CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) (err error) {
+	defer observe(e.name, "CompleteIdempotency", time.Now(), &err)
+	return e.repo.CompleteIdempotency(ctx, userID, key, response, expiresAt)
+}
+
+func (e *EventRepo) RenewIdempotency(ctx context.Context, userID, key string, expiresAt time.Time) (err error) {
+	defer observe(e.name, "RenewIdempotency", time.Now(), &err)
+	return e.repo.RenewIdempotency(ctx, userID, key, expiresAt)
+}
+
+func (e *EventRepo) ReleaseIdempotency(ctx context.Context, userID, key string) (err error) {
+	defer observe(e.name, "ReleaseIdempotency", time.Now(), &err)
+	return e.repo.ReleaseIdempotency(ctx, userID, key)
+}
+
+// observe observes operation, err is pointer to named result, so it is read after the operation returns.
+func observe(repo, operation string, start time.Time, err *error) {
+	ObserveRepo(repo, operation, start, *err)
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/domain/entities"
+)
+
+func TestEventRepo(t *testing.T) {
+	ctx := context.Background()
+	mem, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), nil)
+	require.Nil(t, err)
+	repo := NewEventRepo(mem, "test")
+
+	event, err := entities.NewEvent("title", time.Now().Format(entities.LayoutISO), "1h", "text", "owner", "")
+	require.Nil(t, err)
+	id, err := repo.Add(ctx, *event)
+	require.Nil(t, err)
+	_, err = repo.GetByID(ctx, "owner", id)
+	require.Nil(t, err)
+	_, err = repo.GetByID(ctx, "owner", "00000000-0000-0000-0000-000000000000")
+	require.NotNil(t, err, "errors are passed through")
+
+	require.Equal(t, 0.0, testutil.ToFloat64(repoErrors.WithLabelValues("test", "Add")))
+	require.Equal(t, 1.0, testutil.ToFloat64(repoErrors.WithLabelValues("test", "GetByID")))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	require.True(t, strings.Contains(body, `calendar_repository_operation_duration_seconds_count{operation="GetByID",repo="test"} 2`), body)
+}
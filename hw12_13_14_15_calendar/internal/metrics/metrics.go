@@ -0,0 +1,147 @@
+// Package metrics keeps prometheus collectors of calendar, scheduler and sender. Collectors are registered in default
+// registry, every binary exposes the collectors it uses by Handler.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "calendar"
+
+// Results of operations.
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// Results of consumed messages.
+const (
+	ConsumedAcked        = "acked"
+	ConsumedRedelivered  = "redelivered"
+	ConsumedRequeued     = "requeued"
+	ConsumedDeadLettered = "dead_lettered"
+)
+
+// Outcomes of delivery of alerts by sender.
+const (
+	Delivered = "delivered"
+	Duplicate = "duplicate"
+	Failed    = "failed"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_total",
+		Help: "Count of http requests by method, route and status code.",
+	}, []string{"method", "path", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "Latency of http requests by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "grpc", Name: "requests_total",
+		Help: "Count of grpc calls by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "grpc", Name: "request_duration_seconds",
+		Help:    "Latency of grpc calls by method, latency of stream is its duration.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	repoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "repository", Name: "operation_duration_seconds",
+		Help:    "Latency of repository operations by repository type and operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"repo", "operation"})
+	repoErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "repository", Name: "errors_total",
+		Help: "Count of failed repository operations by repository type and operation.",
+	}, []string{"repo", "operation"})
+
+	scanDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "scheduler", Name: "scan_duration_seconds",
+		Help:    "Duration of scans of due reminders.",
+		Buckets: prometheus.DefBuckets,
+	})
+	alertsPushed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "scheduler", Name: "alerts_pushed_total",
+		Help: "Count of alerts pushed to queue by result.",
+	}, []string{"result"})
+
+	queuePublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "queue", Name: "published_total",
+		Help: "Count of published messages by queue and result.",
+	}, []string{"queue", "result"})
+	queueConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "queue", Name: "consumed_total",
+		Help: "Count of consumed messages by queue and result: acked, redelivered, requeued or dead_lettered.",
+	}, []string{"queue", "result"})
+
+	deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "sender", Name: "deliveries_total",
+		Help: "Count of alerts handled by sender by channel and outcome: delivered, duplicate or failed.",
+	}, []string{"channel", "outcome"})
+)
+
+// Handler serves metrics of default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTP observes http request, path must be route of request, not raw path, to keep labels bounded.
+func ObserveHTTP(method, path string, code int, latency time.Duration) {
+	httpRequests.WithLabelValues(method, path, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, path).Observe(latency.Seconds())
+}
+
+func ObserveGRPC(method, code string, latency time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method).Observe(latency.Seconds())
+}
+
+// ObserveRepo observes repository operation started at start.
+func ObserveRepo(repo, operation string, start time.Time, err error) {
+	repoDuration.WithLabelValues(repo, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repoErrors.WithLabelValues(repo, operation).Inc()
+	}
+}
+
+func ObserveScan(latency time.Duration) {
+	scanDuration.Observe(latency.Seconds())
+}
+
+func AlertPushed(err error) {
+	alertsPushed.WithLabelValues(result(err)).Inc()
+}
+
+func Published(queue string, err error) {
+	queuePublished.WithLabelValues(queue, result(err)).Inc()
+}
+
+func Consumed(queue, result string) {
+	queueConsumed.WithLabelValues(queue, result).Inc()
+}
+
+// Deliver counts outcome of alert, alert without channel is delivered by default notifier.
+func Deliver(channel, outcome string) {
+	if channel == "" {
+		channel = "default"
+	}
+	deliveries.WithLabelValues(channel, outcome).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultOK
+}
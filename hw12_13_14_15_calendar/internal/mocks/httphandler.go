@@ -51,6 +51,81 @@ func (m MockHandler) DeleteEvent(res http.ResponseWriter, req *http.Request) {
 	res.Write([]byte("DeleteEvent"))
 }
 
+func (m MockHandler) ExportEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("ExportEvents"))
+}
+
+func (m MockHandler) ImportEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("ImportEvents"))
+}
+
+func (m MockHandler) FreeBusy(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("FreeBusy"))
+}
+
+func (m MockHandler) InviteAttendees(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("InviteAttendees"))
+}
+
+func (m MockHandler) RespondInvitation(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("RespondInvitation"))
+}
+
+func (m MockHandler) GetInvitations(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("GetInvitations"))
+}
+
+func (m MockHandler) AddCalendar(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("AddCalendar"))
+}
+
+func (m MockHandler) GetCalendars(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("GetCalendars"))
+}
+
+func (m MockHandler) ShareCalendar(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("ShareCalendar"))
+}
+
+func (m MockHandler) UnshareCalendar(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("UnshareCalendar"))
+}
+
+func (m MockHandler) DeleteCalendar(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("DeleteCalendar"))
+}
+
+func (m MockHandler) GetCalendarEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("GetCalendarEvents"))
+}
+
+func (m MockHandler) ListEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("ListEvents"))
+}
+
+func (m MockHandler) SearchEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("SearchEvents"))
+}
+
+func (m MockHandler) WatchEvents(res http.ResponseWriter, req *http.Request) {
+	res.WriteHeader(http.StatusOK)
+	res.Write([]byte("WatchEvents"))
+}
+
 func NewMockHandler() *MockHandler {
 	return &MockHandler{}
 }
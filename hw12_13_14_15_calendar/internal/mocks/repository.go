@@ -17,15 +17,37 @@ type EventRepo struct {
 	testEvent *entities.Event
 }
 
-func (i *EventRepo) GetByNotifyDate(ctx context.Context, date time.Time) ([]*entities.Event, error) {
-	return []*entities.Event{i.testEvent}, nil
+func (i *EventRepo) GetDueReminders(ctx context.Context, from, to time.Time) ([]*entities.DueReminder, error) {
+	return []*entities.DueReminder{{Event: i.testEvent}}, nil
 }
 
 func (i *EventRepo) GetForPeriodByUserID(ctx context.Context, userID string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
 	return []*entities.Event{i.testEvent}, nil
 }
 
-func (i *EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string) error {
+func (i *EventRepo) GetOverlapping(ctx context.Context, userIDs []string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	return []*entities.Event{i.testEvent}, nil
+}
+
+func (i *EventRepo) AddAttendees(ctx context.Context, eventID string, userIDs []string) error {
+	if eventID == "" {
+		return errors.New("")
+	}
+	return nil
+}
+
+func (i *EventRepo) SetAttendeeStatus(ctx context.Context, eventID, userID string, status entities.AttendeeStatus) error {
+	if eventID == "" || userID == "" {
+		return errors.New("")
+	}
+	return nil
+}
+
+func (i *EventRepo) GetInvitations(ctx context.Context, userID string) ([]*entities.Event, error) {
+	return []*entities.Event{i.testEvent}, nil
+}
+
+func (i *EventRepo) DeleteByUserID(ctx context.Context, userID, eventID string, version int64) error {
 	if userID == "" || eventID == "" {
 		return errors.New("")
 	}
@@ -67,3 +89,102 @@ func (i *EventRepo) DeleteByID(ctx context.Context, eventID string) error {
 	}
 	return nil
 }
+
+func (i *EventRepo) FetchDueUnnotified(ctx context.Context, from, now time.Time) ([]*entities.DueReminder, error) {
+	return []*entities.DueReminder{{Event: i.testEvent}}, nil
+}
+
+func (i *EventRepo) MarkNotified(ctx context.Context, eventID string, occurrence time.Time, reminder entities.Reminder) error {
+	if eventID == "" {
+		return errors.New("")
+	}
+	return nil
+}
+
+func (i *EventRepo) RenewClaim(ctx context.Context, d *entities.DueReminder, now time.Time) (bool, error) {
+	return true, nil
+}
+
+func (i *EventRepo) GetOldestUnnotified(ctx context.Context, from time.Time) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (i *EventRepo) GetWatermark(ctx context.Context, name string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (i *EventRepo) SetWatermark(ctx context.Context, name string, watermark time.Time) error {
+	return nil
+}
+
+func (i *EventRepo) DeleteOlderThan(ctx context.Context, query entities.CleanQuery) (int, error) {
+	return 0, nil
+}
+
+func (i *EventRepo) GetByEventID(ctx context.Context, eventID string) (*entities.Event, error) {
+	return i.testEvent, nil
+}
+
+func (i *EventRepo) GetForPeriodByCalendarID(ctx context.Context, calendarID string, dateStart time.Time, dateEnd time.Time) ([]*entities.Event, error) {
+	return []*entities.Event{i.testEvent}, nil
+}
+
+func (i *EventRepo) AddCalendar(ctx context.Context, calendar entities.Calendar) (string, error) {
+	return i.testEvent.CalendarID, nil
+}
+
+// GetCalendar returns calendar owned by user of test event.
+func (i *EventRepo) GetCalendar(ctx context.Context, calendarID string) (*entities.Calendar, error) {
+	return &entities.Calendar{
+		ID:    calendarID,
+		Title: "calendar",
+		ACL:   []entities.ACLEntry{{UserID: i.testEvent.UserID, Role: entities.RoleOwner}},
+	}, nil
+}
+
+func (i *EventRepo) GetCalendarsByUserID(ctx context.Context, userID string) ([]*entities.Calendar, error) {
+	calendar, _ := i.GetCalendar(ctx, i.testEvent.CalendarID)
+	return []*entities.Calendar{calendar}, nil
+}
+
+func (i *EventRepo) SetRole(ctx context.Context, calendarID, userID string, role entities.Role) error {
+	if calendarID == "" || userID == "" {
+		return errors.New("")
+	}
+	return nil
+}
+
+func (i *EventRepo) DeleteCalendar(ctx context.Context, calendarID string) error {
+	if calendarID == "" {
+		return errors.New("")
+	}
+	return nil
+}
+
+func (i *EventRepo) ListEvents(ctx context.Context, query entities.ListQuery) (*entities.EventPage, error) {
+	return &entities.EventPage{Events: []*entities.Event{i.testEvent}}, nil
+}
+
+func (i *EventRepo) SearchEvents(ctx context.Context, userID string, terms []string, limit int) ([]*entities.SearchHit, error) {
+	return []*entities.SearchHit{{Event: i.testEvent, Rank: 1}}, nil
+}
+
+func (i *EventRepo) RelayOutbox(ctx context.Context, limit int, publish entities.OutboxPublisher) (int, error) {
+	return 0, nil
+}
+
+func (i *EventRepo) ReserveIdempotency(ctx context.Context, record entities.Idempotency, now time.Time) (*entities.Idempotency, error) {
+	return nil, nil
+}
+
+func (i *EventRepo) CompleteIdempotency(ctx context.Context, userID, key, response string, expiresAt time.Time) error {
+	return nil
+}
+
+func (i *EventRepo) RenewIdempotency(ctx context.Context, userID, key string, expiresAt time.Time) error {
+	return nil
+}
+
+func (i *EventRepo) ReleaseIdempotency(ctx context.Context, userID, key string) error {
+	return nil
+}
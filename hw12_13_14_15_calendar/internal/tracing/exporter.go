@@ -0,0 +1,258 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	ErrEncodeSpans = "can't encode spans"
+	ErrSendSpans   = "can't send spans to %v"
+	ErrWriteSpans  = "can't write spans"
+	ErrRejected    = "spans are rejected by %v with status %v: %s"
+)
+
+const otlpTimeout = 10 * time.Second
+
+var (
+	_ sdktrace.SpanExporter = (*OTLPExporter)(nil)
+	_ sdktrace.SpanExporter = (*WriterExporter)(nil)
+)
+
+// OTLPExporter sends spans to collector by OTLP/HTTP with JSON encoding, endpoint is full url like
+// http://localhost:4318/v1/traces.
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: otlpTimeout},
+	}
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return errors.Wrap(err, ErrEncodeSpans)
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, ErrSendSpans, e.endpoint)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, ErrSendSpans, e.endpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf(ErrRejected, e.endpoint, resp.StatusCode, msg)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// WriterExporter writes spans as lines of OTLP JSON, it is used for local runs with stdout or file.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+func (e *WriterExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return errors.Wrap(err, ErrEncodeSpans)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(append(body, '\n')); err != nil {
+		return errors.Wrap(err, ErrWriteSpans)
+	}
+	return nil
+}
+
+// Shutdown does nothing, owner of writer closes it.
+func (e *WriterExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+type otlpTraces struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// encodeSpans groups spans by resource and instrumentation library as OTLP ExportTraceServiceRequest does.
+func encodeSpans(spans []sdktrace.ReadOnlySpan) *otlpTraces {
+	traces := &otlpTraces{}
+	resources := make(map[attribute.Distinct]*otlpResourceSpans)
+	scopes := make(map[attribute.Distinct]map[string]*otlpScopeSpans)
+	for _, span := range spans {
+		res := span.Resource()
+		key := resourceKey(res)
+		rs, ok := resources[key]
+		if !ok {
+			rs = &otlpResourceSpans{}
+			if res != nil {
+				rs.Resource.Attributes = encodeAttributes(res.Attributes())
+			}
+			resources[key] = rs
+			scopes[key] = make(map[string]*otlpScopeSpans)
+			traces.ResourceSpans = append(traces.ResourceSpans, rs)
+		}
+		lib := span.InstrumentationLibrary()
+		ss, ok := scopes[key][lib.Name+"@"+lib.Version]
+		if !ok {
+			ss = &otlpScopeSpans{Scope: otlpScope{Name: lib.Name, Version: lib.Version}}
+			scopes[key][lib.Name+"@"+lib.Version] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, encodeSpan(span))
+	}
+	return traces
+}
+
+func resourceKey(res *resource.Resource) attribute.Distinct {
+	if res == nil {
+		return attribute.Distinct{}
+	}
+	return res.Equivalent()
+}
+
+func encodeSpan(span sdktrace.ReadOnlySpan) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: unixNano(span.StartTime()),
+		EndTimeUnixNano:   unixNano(span.EndTime()),
+		Attributes:        encodeAttributes(span.Attributes()),
+	}
+	if span.Parent().HasSpanID() {
+		s.ParentSpanID = span.Parent().SpanID().String()
+	}
+	for _, event := range span.Events() {
+		s.Events = append(s.Events, otlpEvent{
+			TimeUnixNano: unixNano(event.Time),
+			Name:         event.Name,
+			Attributes:   encodeAttributes(event.Attributes),
+		})
+	}
+	// OTLP status codes are Unset=0, Ok=1, Error=2
+	switch span.Status().Code {
+	case codes.Ok:
+		s.Status.Code = 1
+	case codes.Error:
+		s.Status = otlpStatus{Code: 2, Message: span.Status().Description}
+	}
+	return s
+}
+
+func encodeAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		var v otlpValue
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			b := kv.Value.AsBool()
+			v.BoolValue = &b
+		case attribute.INT64:
+			i := strconv.FormatInt(kv.Value.AsInt64(), 10)
+			v.IntValue = &i
+		case attribute.FLOAT64:
+			f := kv.Value.AsFloat64()
+			v.DoubleValue = &f
+		default:
+			s := kv.Value.Emit()
+			v.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: string(kv.Key), Value: v})
+	}
+	return kvs
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
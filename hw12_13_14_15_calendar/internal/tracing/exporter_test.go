@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWriterExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(NewWriterExporter(buf)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("test"))),
+	)
+	tracer := provider.Tracer("tracer")
+
+	ctx, parent := tracer.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "child", trace.WithSpanKind(trace.SpanKindClient))
+	child.SetAttributes(EventID("id"))
+	End(child, errors.New("query failed"))
+
+	traces := &otlpTraces{}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	rs := traces.ResourceSpans[0]
+	require.Equal(t, "service.name", rs.Resource.Attributes[0].Key)
+	require.Equal(t, "test", *rs.Resource.Attributes[0].Value.StringValue)
+	require.Len(t, rs.ScopeSpans, 1)
+	require.Equal(t, "tracer", rs.ScopeSpans[0].Scope.Name)
+	require.Len(t, rs.ScopeSpans[0].Spans, 1)
+
+	span := rs.ScopeSpans[0].Spans[0]
+	require.Equal(t, "child", span.Name)
+	require.Equal(t, 3, span.Kind, "client kind")
+	require.Equal(t, parent.SpanContext().TraceID().String(), span.TraceID)
+	require.Equal(t, parent.SpanContext().SpanID().String(), span.ParentSpanID)
+	require.Equal(t, otlpStatus{Code: 2, Message: "query failed"}, span.Status)
+	require.Equal(t, "calendar.event_id", span.Attributes[0].Key)
+	require.Len(t, span.Events, 1, "error is recorded as exception event")
+	require.Equal(t, "exception", span.Events[0].Name)
+
+	buf.Reset()
+	End(parent, nil)
+	traces = &otlpTraces{}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), traces))
+	span = traces.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	require.Equal(t, "parent", span.Name)
+	require.Empty(t, span.ParentSpanID)
+	require.Equal(t, otlpStatus{}, span.Status)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = ioutil.ReadAll(r.Body)
+		traces := &otlpTraces{}
+		if err := json.Unmarshal(body, traces); err != nil || len(traces.ResourceSpans) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL + "/v1/traces")
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer("tracer").Start(context.Background(), "span")
+	span.End()
+
+	traces := &otlpTraces{}
+	require.Nil(t, json.Unmarshal(body, traces))
+	require.Equal(t, "span", traces.ResourceSpans[0].ScopeSpans[0].Spans[0].Name)
+
+	require.NotNil(t, exporter.ExportSpans(context.Background(), nil), "request without spans is rejected")
+}
+
+func TestPropagation(t *testing.T) {
+	shutdown, err := Init(Config{Service: "test"})
+	require.Nil(t, err)
+	defer shutdown(context.Background())
+
+	ctx, span := Start(context.Background(), "client")
+	defer span.End()
+	carrier := propagation.HeaderCarrier(http.Header{})
+	Inject(ctx, carrier)
+	require.NotEmpty(t, carrier.Get("traceparent"))
+
+	remote := Extract(context.Background(), carrier)
+	traceID, _ := IDs(ctx)
+	remoteTraceID, _ := IDs(remote)
+	require.Equal(t, traceID, remoteTraceID)
+
+	traceID, spanID := IDs(context.Background())
+	require.Empty(t, traceID)
+	require.Empty(t, spanID)
+
+	_, err = Init(Config{Exporter: "jaeger"})
+	require.NotNil(t, err)
+}
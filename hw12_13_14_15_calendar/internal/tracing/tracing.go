@@ -0,0 +1,130 @@
+// Package tracing configures opentelemetry tracer provider of calendar, scheduler and sender. Trace context is
+// propagated between services by W3C traceparent and tracestate headers.
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/shipa988/hw_otus/hw12_13_14_15_calendar"
+
+// Exporters of spans.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+const (
+	ErrUnknownExporter = "unknown tracing exporter %v, I know exporters: none,otlp,stdout,file"
+	ErrOpenFile        = "can't open tracing file %v"
+)
+
+// Config of tracing, empty exporter disables export of spans but spans are still started to propagate and log trace ids.
+type Config struct {
+	Service  string
+	Exporter string
+	Endpoint string
+	File     string
+	Ratio    float64
+}
+
+// Init sets global tracer provider and propagator, returned shutdown flushes spans left in batcher.
+func Init(cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+	case ExporterOTLP:
+		exporter = NewOTLPExporter(cfg.Endpoint)
+	case ExporterStdout:
+		exporter = NewWriterExporter(os.Stdout)
+	case ExporterFile:
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, errors.Wrapf(err, ErrOpenFile, cfg.File)
+		}
+		exporter = NewWriterExporter(file)
+	default:
+		return nil, errors.Errorf(ErrUnknownExporter, cfg.Exporter)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.Ratio > 0 && cfg.Ratio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.Ratio)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.Service))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	if file == nil {
+		return provider.Shutdown, nil
+	}
+	return func(ctx context.Context) error {
+		defer file.Close()
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// Start starts span as child of span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err in span and ends it.
+func End(span trace.Span, err error) {
+	Fail(span, err)
+	span.End()
+}
+
+// Fail records err in span and marks span as failed, nil err is ignored.
+func Fail(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Inject puts trace context of ctx to carrier.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract returns ctx with remote trace context from carrier.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// IDs returns trace and span ids of span in ctx, ids are empty without valid span.
+func IDs(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+
+// EventID is attribute of calendar event id.
+func EventID(id string) attribute.KeyValue {
+	return attribute.String("calendar.event_id", id)
+}
@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -21,7 +22,9 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/db"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/data/repository/inmemory"
 	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/logger"
+	"github.com/shipa988/hw_otus/hw12_13_14_15_calendar/internal/mocks"
 )
 
 var (
@@ -35,11 +38,25 @@ var (
 	id      = "00112233-4455-6677-8899-aabbccddeeff"
 )
 
+// secret is HS256 secret of calendar, it is set by AUTH_SECRET.
+var secret = os.Getenv("AUTH_SECRET")
+
 var (
-	validMetadata   = metadata.New(map[string]string{"x-user-id": "75e45c9c-5365-45fc-b6f9-8343c438666c"})
-	invalidMetadata = metadata.New(map[string]string{"x-user-id-invalid": "11111"})
+	validMetadata   = metadata.New(map[string]string{"authorization": "Bearer " + signToken(userid)})
+	invalidMetadata = metadata.New(map[string]string{"x-user-id": "11111"})
 )
 
+func signToken(userID string) string {
+	if secret == "" {
+		log.Fatal("AUTH_SECRET isn't set")
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID, "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte(secret))
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "can't sign token"))
+	}
+	return token
+}
+
 type Suite struct {
 	suite.Suite
 	client    grpcserver.CalendarServiceClient
@@ -472,6 +489,49 @@ func (s *Suite) TestIntegration_GetMonth() {
 	}
 }
 
+// TestIntegration_SearchEvents runs the same search against database and in-memory repositories, they must find
+// the same events with the same ranks in the same order.
+func (s *Suite) TestIntegration_SearchEvents() {
+	ctx := context.Background()
+	memory, err := inmemory.NewInMemoryEventRepo(inmemory.NewMapRepo(), mocks.NewMockLogger())
+	require.Nil(s.T(), err)
+	start := time.Now().Truncate(time.Hour)
+	events := []struct{ title, text, userID string }{
+		{"Sprint retro", "March retrospective of the team", userid},
+		{"Review", "retro items, Q1 plans", userid},
+		{"Marketing sync", "retrospective", userid},
+		{"Retro", "march", id},
+		{"Planning", "no matches", userid},
+	}
+	for i, e := range events {
+		event, err := entities.NewEvent(e.title, start.Add(time.Duration(i)*time.Hour).Format(entities.LayoutISO), "1h", e.text, e.userID, "")
+		require.Nil(s.T(), err)
+		for _, repo := range []entities.EventRepo{s.repo, memory} {
+			_, err = repo.Add(ctx, *event)
+			require.Nil(s.T(), err)
+		}
+	}
+	defer s.AfterTest("", "")
+
+	for _, query := range []string{"retro", "retro mar", "RETROSPECTIVE", "q1 team", "etro", "planning items"} {
+		terms := entities.Tokenize(query)
+		dbHits, err := s.repo.SearchEvents(ctx, userid, terms, 10)
+		require.Nil(s.T(), err)
+		memoryHits, err := memory.SearchEvents(ctx, userid, terms, 10)
+		require.Nil(s.T(), err)
+		require.Equal(s.T(), searchResults(memoryHits), searchResults(dbHits), query)
+	}
+}
+
+// searchResults returns titles and ranks of hits, ids of the same event differ in repositories.
+func searchResults(hits []*entities.SearchHit) []string {
+	results := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, fmt.Sprintf("%v=%v", hit.Event.Title, hit.Rank))
+	}
+	return results
+}
+
 /*
 func (s *Suite) TestIntegration_ClickOnBanner() {
 	tcases := []struct {